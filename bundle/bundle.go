@@ -2,18 +2,22 @@ package bundle
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/nitrous-io/rise-cli-go/pkg/ignore"
 	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+	"github.com/nitrous-io/rise-cli-go/pkg/pgzip"
 	"github.com/nitrous-io/rise-cli-go/progressbar"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/tui"
@@ -21,67 +25,148 @@ import (
 	log "github.com/Sirupsen/logrus"
 )
 
+// Name of the file listing patterns of files to exclude from a bundle. It may
+// be placed in any directory of a project, and its patterns are relative to
+// that directory.
+const IgnoreFileName = ".stormignore"
+
 var (
 	// From http://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html#object-keys
 	FilenamePatternRe = regexp.MustCompile("[^0-9A-Za-z,!_'()\\.\\*\\-@]+")
 
 	ErrFileChanged = errors.New("file changed while processing")
+
+	// Modification time of every file in a reproducible tarball
+	ReproducibleModTime = time.Unix(0, 0)
 )
 
 type Bundle struct {
-	path     string
-	fileList []string
+	// Makes Pack produce the same tarball, byte for byte, whenever the names
+	// and contents of the files are the same, by leaving out modification
+	// times, owners and all permission bits but the executable ones. New
+	// turns it on.
+	Reproducible bool
+
+	path      string
+	fileList  []string
+	fileSizes map[string]int64
+	skipped   []*SkippedFile
+	matcher   *pathmatch.Matcher
+}
+
+// ManifestEntry describes a single file in a bundle. Path always uses forward
+// slashes so that manifests are identical across platforms.
+type ManifestEntry struct {
+	Path     string      `json:"path"`
+	Checksum string      `json:"checksum"`
+	Size     int64       `json:"size"`
+	Mode     os.FileMode `json:"mode"`
 }
 
 func New(path string) *Bundle {
-	return &Bundle{path: path}
+	return &Bundle{path: path, Reproducible: true}
 }
 
-// Walks the path and forms a list of files that should be included in the bundle
-func (b *Bundle) Assemble(ignoreList []string, showWarnings bool) (count int, size int64, err error) {
+// Walks the path and forms a list of files that should be included in the bundle.
+// ignoreList holds compiled gitignore-style patterns, such as those of
+// pathmatch.CompileAll or ParentIgnoreFile; the patterns in IgnoreFileName
+// files found while walking are applied as well.
+// Directories are scanned concurrently, but the file list is always sorted in
+// the same order so that packing the same files gives the same tarball.
+func (b *Bundle) Assemble(ignoreList []*pathmatch.Pattern, showWarnings bool) (count int, size int64, err error) {
 	b.fileList = []string{}
+	b.fileSizes = map[string]int64{}
+	b.skipped = []*SkippedFile{}
 
 	basePath, err := filepath.Abs(b.path)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	walkFn := func(path string, fi os.FileInfo, err error) error {
-		// if there is an error lstat-ing a file, just skip it
-		if err != nil {
-			return nil
+	matcher := pathmatch.NewMatcher()
+	matcher.AddPatterns(ignoreList...)
+
+	w := newWalker(matcher)
+	if err := w.walk(basePath); err != nil {
+		return 0, 0, err
+	}
+
+	b.fileList = w.fileList
+	b.fileSizes = w.fileSizes
+	b.skipped = w.skipped
+	b.matcher = matcher
+
+	for _, path := range b.fileList {
+		size += b.fileSizes[path]
+	}
+	if showWarnings {
+		for _, skipped := range b.skipped {
+			log.Warnf(tr.T("ignore_file_reason"), skipped.Path, skipped.Message())
 		}
+	}
 
-		incl, fileSize, err := shouldInclude(path, basePath, ignoreList, fi, showWarnings)
-		if err != nil {
-			// SkipDir error should fall through
-			if err == filepath.SkipDir {
-				return err
-			}
-			return nil
+	return len(b.fileList), size, nil
+}
+
+// Reads the ignore file in dirPath, a directory that contains the bundle root
+// absPath rather than being inside it. Its patterns are relative to dirPath, so
+// they are given the path of absPath relative to dirPath as their prefix.
+// Returns no patterns if there is no ignore file, or if dirPath is not above
+// absPath; an ignore file inside the bundle is read while walking.
+func ParentIgnoreFile(dirPath, absPath string) ([]*pathmatch.Pattern, error) {
+	prefix, err := filepath.Rel(dirPath, absPath)
+	if err != nil {
+		return nil, err
+	}
+	prefix = filepath.ToSlash(prefix)
+	if prefix == "." || prefix == ".." || strings.HasPrefix(prefix, "../") {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dirPath, IgnoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
+		return nil, err
+	}
 
-		if incl {
-			relPath, err := filepath.Rel(basePath, path)
-			if err != nil {
-				return err
-			}
+	file, err := filepath.Rel(absPath, filepath.Join(dirPath, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := pathmatch.CompileAll("", ignore.Parse(string(b))...)
+	for _, p := range patterns {
+		p.Prefix = prefix
+		p.File = filepath.ToSlash(file)
+	}
+	return patterns, nil
+}
 
-			b.fileList = append(b.fileList, relPath)
-			size += fileSize
+func addIgnoreFile(matcher *pathmatch.Matcher, dirPath, relDirPath string) error {
+	b, err := ioutil.ReadFile(filepath.Join(dirPath, IgnoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
-		return nil
+		return err
 	}
 
-	if err := filepath.Walk(basePath, walkFn); err != nil {
-		b.fileList = []string{}
-		return 0, 0, err
+	if relDirPath == "." {
+		relDirPath = ""
 	}
 
-	return len(b.fileList), size, nil
+	patterns := pathmatch.CompileAll(relDirPath, ignore.Parse(string(b))...)
+	for _, p := range patterns {
+		p.File = path.Join(filepath.ToSlash(relDirPath), IgnoreFileName)
+	}
+	matcher.AddPatterns(patterns...)
+
+	return nil
 }
 
-func shouldInclude(path, basePath string, ignoreList []string, fi os.FileInfo, showWarnings bool) (incl bool, fileSize int64, err error) {
+func shouldInclude(path, relPath string, matcher *pathmatch.Matcher, fi os.FileInfo) (incl bool, fileSize int64, skipped *SkippedFile, err error) {
 	var (
 		isDir = fi.IsDir()
 		mode  = fi.Mode()
@@ -89,21 +174,12 @@ func shouldInclude(path, basePath string, ignoreList []string, fi os.FileInfo, s
 	)
 
 	// if path is a directory, skip the entire directory by returning SkipDir
-	skip := func() (bool, int64, error) {
+	skip := func(reason string) (bool, int64, *SkippedFile, error) {
+		skipped := &SkippedFile{Path: relPath, Reason: reason, IsDir: isDir}
 		if isDir {
-			return false, 0, filepath.SkipDir
-		}
-		return false, 0, nil
-	}
-
-	logWarn := func(m string) {
-		if showWarnings {
-			relPath, _ := filepath.Rel(basePath, path)
-			if relPath == "" {
-				relPath = path
-			}
-			log.Warnf(tr.T("ignore_file_reason"), relPath, m)
+			return false, 0, skipped, filepath.SkipDir
 		}
+		return false, 0, skipped, nil
 	}
 
 	// follow symlink
@@ -112,76 +188,124 @@ func shouldInclude(path, basePath string, ignoreList []string, fi os.FileInfo, s
 		fi, err = os.Stat(path)
 		// if there is an error following the symlink, skip
 		if err != nil {
-			logWarn(tr.T("symlink_error"))
-			return skip()
+			return skip(SkipReasonSymlinkError)
 		}
 
 		// if symlink points to a directory, skip
 		if fi.IsDir() {
-			logWarn(tr.T("symlink_to_dir"))
-			return skip()
+			return skip(SkipReasonSymlinkToDir)
 		}
 
 		// if symlink points to a non-regular file, skip
 		if !fi.Mode().IsRegular() {
-			logWarn(tr.T("special_mode_bits"))
-			return skip()
+			return skip(SkipReasonSpecialMode)
 		}
 	}
 
 	// if file name starts with ".", "#" or ends with "~", skip
 	if base[0] == '.' {
-		logWarn(tr.T("name_has_dot_prefix"))
-		return skip()
+		return skip(SkipReasonDotPrefix)
 	}
 
 	if base[0] == '#' {
-		logWarn(tr.T("name_has_hash_prefix"))
-		return skip()
+		return skip(SkipReasonHashPrefix)
 	}
 
 	if base[len(base)-1] == '~' {
-		logWarn(tr.T("name_has_tilde_suffix"))
-		return skip()
+		return skip(SkipReasonTildeSuffix)
 	}
 
 	if FilenamePatternRe.MatchString(base) {
-		logWarn(tr.T("name_has_non_safe_character"))
-		return skip()
+		return skip(SkipReasonUnsafeCharacter)
 	}
 
 	// if file is in the ignore list, skip
-	if ignoreList != nil && pathmatch.PathMatchAny(path, ignoreList...) {
-		logWarn(tr.T("name_in_ignore_list"))
-		return skip()
+	if ignored, p := matcher.Match(relPath, isDir); relPath != "." && ignored {
+		incl, fileSize, skipped, err := skip(SkipReasonIgnored)
+		skipped.Pattern = p.Source
+		skipped.PatternBase = p.Base
+		skipped.PatternFile = p.File
+		return incl, fileSize, skipped, err
 	}
 
 	// if file is not a regular file or symlink to a regular file (tested earlier), skip
 	if mode&os.ModeSymlink != os.ModeSymlink && !isDir && !mode.IsRegular() {
-		logWarn(tr.T("special_mode_bits"))
-		return skip()
+		return skip(SkipReasonSpecialMode)
 	}
 
 	// let this directory to be scanned
 	if isDir {
-		return false, 0, nil
+		return false, 0, nil, nil
 	}
 
 	// if the file can't be read, skip
 	f, err := os.Open(path)
 	if err != nil {
-		logWarn(tr.T("file_unreadable"))
-		return false, 0, nil
+		return skip(SkipReasonUnreadable)
 	}
 	f.Close()
 
-	return true, fi.Size(), nil
+	return true, fi.Size(), nil, nil
 }
 
 func (b *Bundle) FileList() []string {
 	return b.fileList
 }
 
+// Returns the ignore patterns that the last call to Assemble applied: those of
+// its ignoreList, followed by those of the ignore files that were read
+func (b *Bundle) IgnorePatterns() []*pathmatch.Pattern {
+	if b.matcher == nil {
+		return nil
+	}
+	return b.matcher.Patterns()
+}
+
+// Returns the files and directories that were excluded from the bundle. The
+// contents of a skipped directory are not listed.
+func (b *Bundle) Skipped() []*SkippedFile {
+	return b.skipped
+}
+
+// Computes the SHA-256 checksum, size and permission bits of every file in the
+// assembled file list. Files are hashed concurrently, but the manifest is in
+// the order of the file list.
+func (b *Bundle) Manifest() ([]*ManifestEntry, error) {
+	basePath, err := filepath.Abs(b.path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make([]*ManifestEntry, len(b.fileList))
+	err = forEach(len(b.fileList), func(i int) error {
+		path := b.fileList[i]
+		absPath := filepath.Join(basePath, path)
+
+		fi, err := os.Stat(absPath)
+		if err != nil {
+			return err
+		}
+
+		checksum, err := Sha256Sum(absPath)
+		if err != nil {
+			return err
+		}
+
+		manifest[i] = &ManifestEntry{
+			Path:     filepath.ToSlash(path),
+			Checksum: checksum,
+			Size:     fi.Size(),
+			Mode:     fi.Mode().Perm(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
 func (b *Bundle) Pack(tarballPath string, showError, showProgress bool) error {
 	logErr := func(m string) {
 		if showError {
@@ -195,17 +319,14 @@ func (b *Bundle) Pack(tarballPath string, showError, showProgress bool) error {
 	}
 	defer f.Close()
 
-	gw := gzip.NewWriter(f)
-	defer func() {
-		gw.Flush()
-		gw.Close()
-	}()
+	// Compressing is what takes the longest, so it is spread over all cores.
+	// Closing twice is harmless, so the deferred calls only matter when
+	// returning early.
+	gw := pgzip.NewWriter(f)
+	defer gw.Close()
 
 	tw := tar.NewWriter(gw)
-	defer func() {
-		tw.Flush()
-		tw.Close()
-	}()
+	defer tw.Close()
 
 	basePath, err := filepath.Abs(b.path)
 	if err != nil {
@@ -229,11 +350,16 @@ func (b *Bundle) Pack(tarballPath string, showError, showProgress bool) error {
 			unixPath = strings.Replace(path, string(filepath.Separator), "/", -1)
 		}
 
-		hdr, err := tar.FileInfoHeader(fi, unixPath)
-		hdr.Name = unixPath
-		if err != nil {
-			logErr(fmt.Sprintf(tr.T("stat_failed"), absPath))
-			return err
+		var hdr *tar.Header
+		if b.Reproducible {
+			hdr = reproducibleHeader(unixPath, fi)
+		} else {
+			hdr, err = tar.FileInfoHeader(fi, unixPath)
+			if err != nil {
+				logErr(fmt.Sprintf(tr.T("stat_failed"), absPath))
+				return err
+			}
+			hdr.Name = unixPath
 		}
 
 		if err := tw.WriteHeader(hdr); err != nil {
@@ -265,9 +391,40 @@ func (b *Bundle) Pack(tarballPath string, showError, showProgress bool) error {
 		}
 	}
 
+	// The last blocks and the gzip trailer are only written when closing, so
+	// the tarball is incomplete if any of these fail.
+	err = tw.Close()
+	if gwErr := gw.Close(); err == nil {
+		err = gwErr
+	}
+	if fErr := f.Close(); err == nil {
+		err = fErr
+	}
+	if err != nil {
+		logErr(fmt.Sprintf(tr.T("write_failed"), tarballPath))
+		return err
+	}
+
 	return nil
 }
 
+// Returns a tar header for a regular file that only depends on its name, size
+// and whether it is executable
+func reproducibleHeader(name string, fi os.FileInfo) *tar.Header {
+	var mode int64 = 0644
+	if fi.Mode()&0111 != 0 {
+		mode = 0755
+	}
+
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     fi.Size(),
+		Mode:     mode,
+		ModTime:  ReproducibleModTime,
+	}
+}
+
 func Sha256Sum(path string) (string, error) {
 	hasher := sha256.New()
 
@@ -13,6 +13,7 @@ import (
 
 	"github.com/nitrous-io/rise-cli-go/bundle"
 	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -99,7 +100,7 @@ var _ = Describe("Bundle", func() {
 
 		It("return all files", func() {
 			b := bundle.New("public")
-			count, size, err := b.Assemble([]string{"log", "development.rb", "vendor/assets", config.ProjectJSON}, false)
+			count, size, err := b.Assemble(pathmatch.CompileAll("", "log", "development.rb", "vendor/assets", config.ProjectJSON), false)
 			Expect(err).To(BeNil())
 
 			expectedFiles := []string{
@@ -123,6 +124,233 @@ var _ = Describe("Bundle", func() {
 		})
 	})
 
+	Describe("Assemble() with ignore files", func() {
+		BeforeEach(func() {
+			files := []string{
+				"public/index.html",
+				"public/js/app.js",
+				"public/js/app.js.map",
+				"public/dist/bundle.js",
+				"public/src/dist/keep.js",
+				"public/docs/a.txt",
+				"public/docs/keep.txt",
+				"public/docs/drafts/b.html",
+				"public/build/x/y.tmp",
+				"public/build/x/y.html",
+			}
+
+			for _, f := range files {
+				err = os.MkdirAll(filepath.Dir(f), 0700)
+				Expect(err).To(BeNil())
+				err = ioutil.WriteFile(f, []byte("foo"), 0600)
+				Expect(err).To(BeNil())
+			}
+
+			err = ioutil.WriteFile("public/docs/"+bundle.IgnoreFileName, []byte("*.txt\n!keep.txt\n/drafts/\n"), 0600)
+			Expect(err).To(BeNil())
+		})
+
+		It("applies gitignore semantics", func() {
+			b := bundle.New("public")
+			_, _, err := b.Assemble(pathmatch.CompileAll("", "*.map", "/dist", "build/**/*.tmp"), false)
+			Expect(err).To(BeNil())
+
+			Expect(b.FileList()).To(ConsistOf(
+				"index.html",
+				"js/app.js",
+				"src/dist/keep.js",
+				"docs/keep.txt",
+				"build/x/y.html",
+			))
+
+			skipped := map[string]*bundle.SkippedFile{}
+			for _, f := range b.Report(0).Skipped {
+				skipped[f.Path] = f
+			}
+			Expect(skipped).To(HaveKey("docs/a.txt"))
+			Expect(skipped["docs/a.txt"].Pattern).To(Equal("*.txt"))
+			Expect(skipped["docs/a.txt"].PatternFile).To(Equal("docs/" + bundle.IgnoreFileName))
+			Expect(skipped["js/app.js.map"].Pattern).To(Equal("*.map"))
+			Expect(skipped["js/app.js.map"].PatternFile).To(Equal(""))
+		})
+
+		It("applies the ignore file above the bundle relative to its own directory", func() {
+			err := ioutil.WriteFile(bundle.IgnoreFileName, []byte("public/js/app.js\n/public/dist/\n*.tmp\n/docs\n"), 0600)
+			Expect(err).To(BeNil())
+
+			cwd, err := os.Getwd()
+			Expect(err).To(BeNil())
+
+			patterns, err := bundle.ParentIgnoreFile(cwd, filepath.Join(cwd, "public"))
+			Expect(err).To(BeNil())
+			Expect(patterns).To(HaveLen(4))
+
+			b := bundle.New("public")
+			_, _, err = b.Assemble(patterns, false)
+			Expect(err).To(BeNil())
+
+			Expect(b.FileList()).To(ConsistOf(
+				"index.html",
+				"js/app.js.map",
+				"src/dist/keep.js",
+				"docs/keep.txt",
+				"build/x/y.html",
+			))
+
+			skipped := map[string]*bundle.SkippedFile{}
+			for _, f := range b.Report(0).Skipped {
+				skipped[f.Path] = f
+			}
+			Expect(skipped).To(HaveKey("js/app.js"))
+			Expect(skipped["js/app.js"].Pattern).To(Equal("public/js/app.js"))
+			Expect(skipped["js/app.js"].PatternFile).To(Equal("../" + bundle.IgnoreFileName))
+		})
+
+		It("leaves the ignore file of a directory inside the bundle to be read while walking", func() {
+			cwd, err := os.Getwd()
+			Expect(err).To(BeNil())
+
+			patterns, err := bundle.ParentIgnoreFile(filepath.Join(cwd, "public", "docs"), filepath.Join(cwd, "public"))
+			Expect(err).To(BeNil())
+			Expect(patterns).To(BeEmpty())
+		})
+	})
+
+	Describe("Assemble() with many directories", func() {
+		BeforeEach(func() {
+			for _, d := range []string{"a", "a-b", "a/b", "a/b/c", "b", "b.c", "z"} {
+				for _, f := range []string{"1.txt", "2.txt"} {
+					err = os.MkdirAll(filepath.Join("public", d), 0700)
+					Expect(err).To(BeNil())
+					err = ioutil.WriteFile(filepath.Join("public", d, f), []byte("foo"), 0600)
+					Expect(err).To(BeNil())
+				}
+			}
+		})
+
+		It("lists files in the order filepath.Walk visits them", func() {
+			expected := []string{}
+			filepath.Walk("public", func(path string, fi os.FileInfo, err error) error {
+				if !fi.IsDir() {
+					relPath, _ := filepath.Rel("public", path)
+					expected = append(expected, relPath)
+				}
+				return nil
+			})
+
+			defer func(c int) { bundle.Concurrency = c }(bundle.Concurrency)
+			for _, c := range []int{1, 4, 16} {
+				bundle.Concurrency = c
+
+				b := bundle.New("public")
+				count, _, err := b.Assemble(nil, false)
+				Expect(err).To(BeNil())
+				Expect(count).To(Equal(14))
+				Expect(b.FileList()).To(Equal(expected))
+			}
+		})
+	})
+
+	Describe("Assemble() in a directory with an unsafe name", func() {
+		BeforeEach(func() {
+			err = os.MkdirAll("my site", 0700)
+			Expect(err).To(BeNil())
+			err = ioutil.WriteFile("my site/index.html", []byte("foo"), 0600)
+			Expect(err).To(BeNil())
+		})
+
+		It("includes the files in it", func() {
+			b := bundle.New("my site")
+			count, _, err := b.Assemble(nil, false)
+			Expect(err).To(BeNil())
+			Expect(count).To(Equal(1))
+			Expect(b.FileList()).To(Equal([]string{"index.html"}))
+			Expect(b.Skipped()).To(BeEmpty())
+		})
+	})
+
+	Describe("Report()", func() {
+		BeforeEach(func() {
+			files := map[string]string{
+				"public/index.html":   "hello",
+				"public/big.jpg":      "0123456789",
+				"public/app.js.map":   "{}",
+				"public/.env":         "SECRET=1",
+				"public/my photo.jpg": "foo",
+			}
+
+			for f, content := range files {
+				err = os.MkdirAll(filepath.Dir(f), 0700)
+				Expect(err).To(BeNil())
+				err = ioutil.WriteFile(f, []byte(content), 0600)
+				Expect(err).To(BeNil())
+			}
+
+			err = os.MkdirAll("public/.git/objects", 0700)
+			Expect(err).To(BeNil())
+		})
+
+		It("lists included and skipped files with reasons", func() {
+			b := bundle.New("public")
+			_, _, err := b.Assemble(pathmatch.CompileAll("", "*.map"), false)
+			Expect(err).To(BeNil())
+
+			report := b.Report(1)
+			Expect(report.Included).To(Equal([]*bundle.IncludedFile{
+				{Path: "big.jpg", Size: 10},
+				{Path: "index.html", Size: 5},
+			}))
+			Expect(report.IncludedCount).To(Equal(2))
+			Expect(report.IncludedSize).To(Equal(int64(15)))
+
+			Expect(report.Skipped).To(Equal([]*bundle.SkippedFile{
+				{Path: ".env", Reason: bundle.SkipReasonDotPrefix},
+				{Path: ".git", IsDir: true, Reason: bundle.SkipReasonDotPrefix},
+				{Path: "app.js.map", Reason: bundle.SkipReasonIgnored, Pattern: "*.map"},
+				{Path: "my photo.jpg", Reason: bundle.SkipReasonUnsafeCharacter},
+			}))
+			Expect(report.SkippedCount).To(Equal(4))
+
+			Expect(report.Largest).To(Equal([]*bundle.IncludedFile{
+				{Path: "big.jpg", Size: 10},
+			}))
+		})
+	})
+
+	Describe("Manifest()", func() {
+		BeforeEach(func() {
+			err = os.MkdirAll("public/css", 0700)
+			Expect(err).To(BeNil())
+			err = ioutil.WriteFile("public/index.html", []byte("hello"), 0644)
+			Expect(err).To(BeNil())
+			err = ioutil.WriteFile("public/css/app.css", []byte("body {}"), 0600)
+			Expect(err).To(BeNil())
+		})
+
+		It("returns checksum, size and mode of every file", func() {
+			b := bundle.New("public")
+			_, _, err := b.Assemble(nil, false)
+			Expect(err).To(BeNil())
+
+			manifest, err := b.Manifest()
+			Expect(err).To(BeNil())
+			Expect(manifest).To(ConsistOf(
+				&bundle.ManifestEntry{
+					Path:     "index.html",
+					Checksum: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+					Size:     5,
+					Mode:     0644,
+				},
+				&bundle.ManifestEntry{
+					Path:     "css/app.css",
+					Checksum: "62368a1a29259b30bac235c0e75dc700c9b3bacf1513ad5708e4fe4a6c0d6560",
+					Size:     7,
+					Mode:     0600,
+				},
+			))
+		})
+	})
+
 	Describe("Pack", func() {
 		var (
 			files     map[string][]byte
@@ -193,5 +421,140 @@ var _ = Describe("Bundle", func() {
 			Expect(err).To(Equal(io.EOF))
 			Expect(fileNames).To(ConsistOf(filesRead))
 		})
+
+		It("returns an error if the end of the tarball can't be written", func() {
+			if _, err := os.Stat("/dev/full"); err != nil {
+				Skip("/dev/full is not available")
+			}
+
+			b := bundle.New("public")
+			_, _, err := b.Assemble(nil, false)
+			Expect(err).To(BeNil())
+
+			// The files are small enough that nothing is written before
+			// the tarball is closed.
+			Expect(b.Pack("/dev/full", false, false)).NotTo(Succeed())
+		})
+
+		It("creates the same tarball every time", func() {
+			pack := func(name string) []byte {
+				b := bundle.New("public")
+				_, _, err := b.Assemble(nil, false)
+				Expect(err).To(BeNil())
+
+				tarballPath := filepath.Join(tempDir, name)
+				Expect(b.Pack(tarballPath, false, false)).To(Succeed())
+
+				data, err := ioutil.ReadFile(tarballPath)
+				Expect(err).To(BeNil())
+				return data
+			}
+
+			first := pack("first.tar.gz")
+			for i := 0; i < 5; i++ {
+				Expect(pack("again.tar.gz")).To(Equal(first))
+			}
+		})
+
+		Context("when the bundle is reproducible", func() {
+			It("does not depend on modification times or permissions", func() {
+				checksum := func() string {
+					b := bundle.New("public")
+					Expect(b.Reproducible).To(BeTrue())
+					_, _, err := b.Assemble(nil, false)
+					Expect(err).To(BeNil())
+
+					tarballPath := filepath.Join(tempDir, "bundle.tar.gz")
+					Expect(b.Pack(tarballPath, false, false)).To(Succeed())
+
+					sum, err := bundle.Sha256Sum(tarballPath)
+					Expect(err).To(BeNil())
+					return sum
+				}
+
+				before := checksum()
+
+				later := time.Now().Add(time.Hour)
+				Expect(os.Chtimes("public/qux.php", later, later)).To(Succeed())
+				Expect(os.Chmod("public/bar.sql", 0640)).To(Succeed())
+
+				Expect(checksum()).To(Equal(before))
+			})
+
+			It("normalizes headers", func() {
+				Expect(os.Chmod("public/foo/foo.rb", 0700)).To(Succeed())
+
+				b := bundle.New("public")
+				_, _, err := b.Assemble(nil, false)
+				Expect(err).To(BeNil())
+
+				tarballPath := filepath.Join(tempDir, "bundle.tar.gz")
+				Expect(b.Pack(tarballPath, false, false)).To(Succeed())
+
+				f, err := os.Open(tarballPath)
+				Expect(err).To(BeNil())
+				defer f.Close()
+
+				gr, err := gzip.NewReader(f)
+				Expect(err).To(BeNil())
+				Expect(gr.ModTime.IsZero()).To(BeTrue())
+
+				modes := map[string]int64{}
+				tr := tar.NewReader(gr)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					Expect(err).To(BeNil())
+
+					Expect(hdr.ModTime.Unix()).To(BeZero())
+					Expect(hdr.Uid).To(BeZero())
+					Expect(hdr.Gid).To(BeZero())
+					Expect(hdr.Uname).To(BeEmpty())
+					Expect(hdr.Gname).To(BeEmpty())
+					modes[hdr.Name] = hdr.Mode
+				}
+
+				Expect(modes).To(Equal(map[string]int64{
+					"bar.sql":    0644,
+					"baz/baz.js": 0644,
+					"foo/foo.rb": 0755,
+					"qux.php":    0644,
+				}))
+			})
+		})
+
+		Context("when the bundle is not reproducible", func() {
+			It("keeps modification times", func() {
+				mtime := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+				Expect(os.Chtimes("public/qux.php", mtime, mtime)).To(Succeed())
+
+				b := bundle.New("public")
+				b.Reproducible = false
+				_, _, err := b.Assemble(nil, false)
+				Expect(err).To(BeNil())
+
+				tarballPath := filepath.Join(tempDir, "bundle.tar.gz")
+				Expect(b.Pack(tarballPath, false, false)).To(Succeed())
+
+				f, err := os.Open(tarballPath)
+				Expect(err).To(BeNil())
+				defer f.Close()
+
+				gr, err := gzip.NewReader(f)
+				Expect(err).To(BeNil())
+
+				tr := tar.NewReader(gr)
+				for {
+					hdr, err := tr.Next()
+					Expect(err).To(BeNil())
+					if hdr.Name == "qux.php" {
+						Expect(hdr.ModTime.Equal(mtime)).To(BeTrue())
+						break
+					}
+				}
+			})
+		})
 	})
 })
@@ -0,0 +1,76 @@
+package bundle
+
+import "sort"
+
+// ManifestChange describes a file whose content differs between two
+// manifests, or that became executable or stopped being so.
+type ManifestChange struct {
+	From *ManifestEntry
+	To   *ManifestEntry
+}
+
+// ManifestDiff lists the files that were added, removed or changed going from
+// one manifest to another, each sorted by path.
+type ManifestDiff struct {
+	Added   []*ManifestEntry
+	Removed []*ManifestEntry
+	Changed []*ManifestChange
+}
+
+// Compares two manifests, treating from as the old and to as the new one
+func DiffManifests(from, to []*ManifestEntry) *ManifestDiff {
+	old := make(map[string]*ManifestEntry, len(from))
+	for _, entry := range from {
+		old[entry.Path] = entry
+	}
+
+	diff := &ManifestDiff{}
+	for _, entry := range to {
+		prev, ok := old[entry.Path]
+		if !ok {
+			diff.Added = append(diff.Added, entry)
+			continue
+		}
+		delete(old, entry.Path)
+
+		// Only the executable bits end up in a bundle, so other differences
+		// in permissions, e.g. from another umask, are not changes.
+		if prev.Checksum != entry.Checksum || prev.Mode&0111 != entry.Mode&0111 {
+			diff.Changed = append(diff.Changed, &ManifestChange{From: prev, To: entry})
+		}
+	}
+	for _, entry := range old {
+		diff.Removed = append(diff.Removed, entry)
+	}
+
+	sort.Sort(entriesByPath(diff.Added))
+	sort.Sort(entriesByPath(diff.Removed))
+	sort.Sort(changesByPath(diff.Changed))
+
+	return diff
+}
+
+// Returns whether the manifests are identical
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Returns the number of files in a manifest and their total size
+func ManifestSize(manifest []*ManifestEntry) (count int, size int64) {
+	for _, entry := range manifest {
+		size += entry.Size
+	}
+	return len(manifest), size
+}
+
+type entriesByPath []*ManifestEntry
+
+func (a entriesByPath) Len() int           { return len(a) }
+func (a entriesByPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a entriesByPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
+
+type changesByPath []*ManifestChange
+
+func (a changesByPath) Len() int           { return len(a) }
+func (a changesByPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a changesByPath) Less(i, j int) bool { return a[i].To.Path < a[j].To.Path }
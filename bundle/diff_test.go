@@ -0,0 +1,52 @@
+package bundle_test
+
+import (
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DiffManifests()", func() {
+	It("lists added, removed and changed files sorted by path", func() {
+		from := []*bundle.ManifestEntry{
+			{Path: "index.html", Checksum: "aaa", Size: 10, Mode: 0644},
+			{Path: "js/old.js", Checksum: "bbb", Size: 20, Mode: 0644},
+			{Path: "css/app.css", Checksum: "ccc", Size: 30, Mode: 0644},
+			{Path: "run.sh", Checksum: "ddd", Size: 40, Mode: 0644},
+			{Path: "robots.txt", Checksum: "hhh", Size: 70, Mode: 0600},
+		}
+		to := []*bundle.ManifestEntry{
+			{Path: "run.sh", Checksum: "ddd", Size: 40, Mode: 0755},
+			{Path: "index.html", Checksum: "aaa", Size: 10, Mode: 0644},
+			{Path: "js/new.js", Checksum: "eee", Size: 50, Mode: 0644},
+			{Path: "about.html", Checksum: "fff", Size: 60, Mode: 0644},
+			{Path: "css/app.css", Checksum: "ggg", Size: 35, Mode: 0644},
+			{Path: "robots.txt", Checksum: "hhh", Size: 70, Mode: 0664},
+		}
+
+		diff := bundle.DiffManifests(from, to)
+		Expect(diff.Empty()).To(BeFalse())
+
+		Expect(diff.Added).To(HaveLen(2))
+		Expect(diff.Added[0].Path).To(Equal("about.html"))
+		Expect(diff.Added[1].Path).To(Equal("js/new.js"))
+
+		Expect(diff.Removed).To(HaveLen(1))
+		Expect(diff.Removed[0].Path).To(Equal("js/old.js"))
+
+		Expect(diff.Changed).To(HaveLen(2))
+		Expect(diff.Changed[0].From.Size).To(Equal(int64(30)))
+		Expect(diff.Changed[0].To.Size).To(Equal(int64(35)))
+		Expect(diff.Changed[1].To.Path).To(Equal("run.sh"))
+		Expect(diff.Changed[1].To.Mode).To(BeEquivalentTo(0755))
+	})
+
+	It("is empty for identical manifests", func() {
+		m := []*bundle.ManifestEntry{{Path: "index.html", Checksum: "aaa", Size: 10, Mode: 0644}}
+		Expect(bundle.DiffManifests(m, m).Empty()).To(BeTrue())
+
+		count, size := bundle.ManifestSize(m)
+		Expect(count).To(Equal(1))
+		Expect(size).To(Equal(int64(10)))
+	})
+})
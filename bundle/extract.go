@@ -0,0 +1,161 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// UnsafeEntryError is returned by Extract for a tarball entry that could
+// write outside of the destination directory, or that is not a regular file or
+// a directory.
+type UnsafeEntryError struct {
+	Name   string
+	Reason string
+}
+
+func (e *UnsafeEntryError) Error() string {
+	return fmt.Sprintf("refusing to extract %q: %s", e.Name, e.Reason)
+}
+
+// Extracts a gzipped tarball, such as one created by Pack, into dir. Only
+// regular files and directories are extracted, and their paths must be relative
+// and stay inside dir; Extract stops at the first entry that is not, or that
+// would be written through a symlink already in dir. Returns the number of
+// files extracted.
+func Extract(tarballPath, dir string) (count int, err error) {
+	f, err := os.Open(tarballPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, err
+	}
+	defer gr.Close()
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	tarReader := tar.NewReader(gr)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		dest, err := entryPath(absDir, hdr.Name)
+		if err != nil {
+			return count, err
+		}
+		if err := checkNoSymlinks(absDir, dest, hdr.Name); err != nil {
+			return count, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return count, err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := extractFile(tarReader, dest, hdr); err != nil {
+				return count, err
+			}
+			count++
+		case tar.TypeSymlink, tar.TypeLink:
+			return count, &UnsafeEntryError{hdr.Name, "links are not allowed"}
+		default:
+			return count, &UnsafeEntryError{hdr.Name, "only regular files and directories are allowed"}
+		}
+	}
+}
+
+// Returns where an entry is extracted to, if it is safe to extract
+func entryPath(absDir, name string) (string, error) {
+	// Pack always uses forward slashes, but be strict about backslashes too so
+	// that "..\" cannot escape on Windows.
+	unixName := strings.Replace(name, "\\", "/", -1)
+
+	if unixName == "" || path.IsAbs(unixName) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", &UnsafeEntryError{name, "absolute paths are not allowed"}
+	}
+	for _, part := range strings.Split(unixName, "/") {
+		if part == ".." {
+			return "", &UnsafeEntryError{name, "paths may not contain \"..\""}
+		}
+	}
+
+	dest := filepath.Join(absDir, filepath.FromSlash(path.Clean(unixName)))
+	if dest != absDir && !strings.HasPrefix(dest, absDir+string(filepath.Separator)) {
+		return "", &UnsafeEntryError{name, "path is outside of the destination"}
+	}
+
+	return dest, nil
+}
+
+// Makes sure that no existing part of dest below absDir is a symlink, so that
+// an earlier entry or a file already in the destination cannot redirect a
+// write to somewhere outside of it
+func checkNoSymlinks(absDir, dest, name string) error {
+	rel, err := filepath.Rel(absDir, dest)
+	if err != nil || rel == "." {
+		return err
+	}
+
+	p := absDir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, part)
+		fi, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return &UnsafeEntryError{name, "path goes through a symlink"}
+		}
+	}
+
+	return nil
+}
+
+func extractFile(r io.Reader, dest string, hdr *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	// Files are always readable and writable by their owner, but never
+	// setuid, setgid or sticky.
+	mode := os.FileMode(hdr.Mode).Perm() | 0600
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != hdr.Size {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
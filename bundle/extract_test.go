@@ -0,0 +1,144 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extract()", func() {
+	var (
+		tempDir string
+		destDir string
+		err     error
+	)
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "rise-test")
+		Expect(err).To(BeNil())
+		destDir = filepath.Join(tempDir, "dest")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	// Writes a tarball containing the given headers, with the same content for
+	// every regular file
+	writeTarball := func(hdrs ...*tar.Header) string {
+		p := filepath.Join(tempDir, "bundle.tar.gz")
+		f, err := os.Create(p)
+		Expect(err).To(BeNil())
+		defer f.Close()
+
+		gw := gzip.NewWriter(f)
+		tw := tar.NewWriter(gw)
+		for _, hdr := range hdrs {
+			if hdr.Typeflag == tar.TypeReg {
+				hdr.Size = int64(len("hello"))
+			}
+			Expect(tw.WriteHeader(hdr)).To(Succeed())
+			if hdr.Typeflag == tar.TypeReg {
+				_, err := tw.Write([]byte("hello"))
+				Expect(err).To(BeNil())
+			}
+		}
+		Expect(tw.Close()).To(Succeed())
+		Expect(gw.Close()).To(Succeed())
+		return p
+	}
+
+	It("extracts files and directories", func() {
+		p := writeTarball(
+			&tar.Header{Name: "css/", Typeflag: tar.TypeDir, Mode: 0755},
+			&tar.Header{Name: "index.html", Typeflag: tar.TypeReg, Mode: 0644},
+			&tar.Header{Name: "js/app.js", Typeflag: tar.TypeReg, Mode: 04755},
+		)
+
+		count, err := bundle.Extract(p, destDir)
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(2))
+
+		b, err := ioutil.ReadFile(filepath.Join(destDir, "js", "app.js"))
+		Expect(err).To(BeNil())
+		Expect(string(b)).To(Equal("hello"))
+
+		fi, err := os.Stat(filepath.Join(destDir, "js", "app.js"))
+		Expect(err).To(BeNil())
+		Expect(fi.Mode() & os.ModeSetuid).To(BeZero())
+
+		fi, err = os.Stat(filepath.Join(destDir, "css"))
+		Expect(err).To(BeNil())
+		Expect(fi.IsDir()).To(BeTrue())
+	})
+
+	It("round-trips a packed bundle", func() {
+		srcDir := filepath.Join(tempDir, "src")
+		Expect(os.MkdirAll(filepath.Join(srcDir, "a"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "a", "b.txt"), []byte("b"), 0600)).To(Succeed())
+
+		b := bundle.New(srcDir)
+		_, _, err := b.Assemble(nil, false)
+		Expect(err).To(BeNil())
+		p := filepath.Join(tempDir, "packed.tar.gz")
+		Expect(b.Pack(p, false, false)).To(Succeed())
+
+		count, err := bundle.Extract(p, destDir)
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(1))
+
+		content, err := ioutil.ReadFile(filepath.Join(destDir, "a", "b.txt"))
+		Expect(err).To(BeNil())
+		Expect(string(content)).To(Equal("b"))
+	})
+
+	It("does not write through symlinks in the destination", func() {
+		outsideDir := filepath.Join(tempDir, "outside")
+		Expect(os.MkdirAll(outsideDir, 0700)).To(Succeed())
+		Expect(os.MkdirAll(destDir, 0700)).To(Succeed())
+		Expect(os.Symlink(outsideDir, filepath.Join(destDir, "assets"))).To(Succeed())
+		Expect(os.Symlink(filepath.Join(outsideDir, "y"), filepath.Join(destDir, "y"))).To(Succeed())
+
+		p := writeTarball(&tar.Header{Name: "assets/x", Typeflag: tar.TypeReg, Mode: 0644})
+		_, err := bundle.Extract(p, destDir)
+		Expect(err).To(BeAssignableToTypeOf(&bundle.UnsafeEntryError{}))
+
+		p = writeTarball(&tar.Header{Name: "assets/sub/", Typeflag: tar.TypeDir, Mode: 0755})
+		_, err = bundle.Extract(p, destDir)
+		Expect(err).To(BeAssignableToTypeOf(&bundle.UnsafeEntryError{}))
+
+		p = writeTarball(&tar.Header{Name: "y", Typeflag: tar.TypeReg, Mode: 0644})
+		_, err = bundle.Extract(p, destDir)
+		Expect(err).To(BeAssignableToTypeOf(&bundle.UnsafeEntryError{}))
+
+		names, err := ioutil.ReadDir(outsideDir)
+		Expect(err).To(BeNil())
+		Expect(names).To(BeEmpty())
+	})
+
+	itRejects := func(desc string, hdr *tar.Header) {
+		It("rejects "+desc, func() {
+			p := writeTarball(hdr)
+
+			_, err := bundle.Extract(p, destDir)
+			Expect(err).To(BeAssignableToTypeOf(&bundle.UnsafeEntryError{}))
+
+			_, err = os.Stat(filepath.Join(tempDir, "evil"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	}
+
+	itRejects("path traversal", &tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0644})
+	itRejects("path traversal in the middle of a path", &tar.Header{Name: "a/../../evil", Typeflag: tar.TypeReg, Mode: 0644})
+	itRejects("backslash path traversal", &tar.Header{Name: "..\\evil", Typeflag: tar.TypeReg, Mode: 0644})
+	itRejects("absolute paths", &tar.Header{Name: "/evil", Typeflag: tar.TypeReg, Mode: 0644})
+	itRejects("symlinks", &tar.Header{Name: "evil", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"})
+	itRejects("hard links", &tar.Header{Name: "evil", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"})
+	itRejects("devices", &tar.Header{Name: "evil", Typeflag: tar.TypeChar})
+})
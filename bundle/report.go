@@ -0,0 +1,122 @@
+package bundle
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/nitrous-io/rise-cli-go/tr"
+)
+
+// Reasons for excluding a file from a bundle
+const (
+	SkipReasonDotPrefix       = "dot_prefix"
+	SkipReasonHashPrefix      = "hash_prefix"
+	SkipReasonTildeSuffix     = "tilde_suffix"
+	SkipReasonUnsafeCharacter = "unsafe_character"
+	SkipReasonIgnored         = "ignore_rule"
+	SkipReasonSymlinkError    = "symlink_error"
+	SkipReasonSymlinkToDir    = "symlink_to_dir"
+	SkipReasonSpecialMode     = "special_mode"
+	SkipReasonUnreadable      = "unreadable"
+)
+
+var skipReasonMessages = map[string]string{
+	SkipReasonDotPrefix:       "name_has_dot_prefix",
+	SkipReasonHashPrefix:      "name_has_hash_prefix",
+	SkipReasonTildeSuffix:     "name_has_tilde_suffix",
+	SkipReasonUnsafeCharacter: "name_has_non_safe_character",
+	SkipReasonIgnored:         "name_in_ignore_list",
+	SkipReasonSymlinkError:    "symlink_error",
+	SkipReasonSymlinkToDir:    "symlink_to_dir",
+	SkipReasonSpecialMode:     "special_mode_bits",
+	SkipReasonUnreadable:      "file_unreadable",
+}
+
+// SkippedFile is a file or directory that was excluded from a bundle.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	IsDir  bool   `json:"dir"`
+	Reason string `json:"reason"`
+
+	// Pattern is the ignore pattern that excluded the file, PatternBase the
+	// directory it applies to and PatternFile the ignore file it was read
+	// from, if Reason is SkipReasonIgnored. PatternFile is empty for built-in
+	// patterns.
+	Pattern     string `json:"pattern,omitempty"`
+	PatternBase string `json:"pattern_base,omitempty"`
+	PatternFile string `json:"pattern_file,omitempty"`
+}
+
+// Returns a human readable description of why the file was skipped
+func (s *SkippedFile) Message() string {
+	return tr.T(skipReasonMessages[s.Reason])
+}
+
+// IncludedFile is a file that is part of a bundle.
+type IncludedFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Report describes which files are included in and excluded from a bundle.
+// Files are sorted by path so that reports can be compared.
+type Report struct {
+	Included      []*IncludedFile `json:"included"`
+	Skipped       []*SkippedFile  `json:"skipped"`
+	IncludedCount int             `json:"included_count"`
+	IncludedSize  int64           `json:"included_size"`
+	SkippedCount  int             `json:"skipped_count"`
+	Largest       []*IncludedFile `json:"largest"`
+}
+
+// Builds a report of the last call to Assemble, listing up to maxLargest of
+// the largest included files
+func (b *Bundle) Report(maxLargest int) *Report {
+	r := &Report{
+		Included: make([]*IncludedFile, 0, len(b.fileList)),
+		Skipped:  make([]*SkippedFile, len(b.skipped)),
+	}
+
+	for _, path := range b.fileList {
+		f := &IncludedFile{Path: filepath.ToSlash(path), Size: b.fileSizes[path]}
+		r.Included = append(r.Included, f)
+		r.IncludedSize += f.Size
+	}
+	sort.Sort(byPath(r.Included))
+	r.IncludedCount = len(r.Included)
+
+	for i, skipped := range b.skipped {
+		s := *skipped
+		s.Path = filepath.ToSlash(s.Path)
+		r.Skipped[i] = &s
+	}
+	sort.Sort(skippedByPath(r.Skipped))
+	r.SkippedCount = len(r.Skipped)
+
+	r.Largest = make([]*IncludedFile, len(r.Included))
+	copy(r.Largest, r.Included)
+	sort.Stable(bySizeDesc(r.Largest))
+	if len(r.Largest) > maxLargest {
+		r.Largest = r.Largest[:maxLargest]
+	}
+
+	return r
+}
+
+type byPath []*IncludedFile
+
+func (a byPath) Len() int           { return len(a) }
+func (a byPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
+
+type bySizeDesc []*IncludedFile
+
+func (a bySizeDesc) Len() int           { return len(a) }
+func (a bySizeDesc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a bySizeDesc) Less(i, j int) bool { return a[i].Size > a[j].Size }
+
+type skippedByPath []*SkippedFile
+
+func (a skippedByPath) Len() int           { return len(a) }
+func (a skippedByPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a skippedByPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
@@ -0,0 +1,149 @@
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+)
+
+// Rename is a file or directory to rename so that it is no longer left out of
+// the bundle. Paths are relative to the bundle root.
+type Rename struct {
+	From string
+	To   string
+}
+
+// Plain spellings of accented letters, so that "café" becomes "cafe" rather
+// than "caf-"
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ą': "a", 'ă': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ı': "i",
+	'ł': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'ţ': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Returns name with accented letters replaced by plain ones and every other
+// run of characters that FilenamePatternRe rejects replaced by a dash, e.g.
+// "Café Menu (2).pdf" becomes "Cafe-Menu-(2).pdf"
+func SafeName(name string) string {
+	var b bytes.Buffer
+	for _, r := range name {
+		lower := unicode.ToLower(r)
+		if t, ok := transliterations[lower]; ok {
+			if lower != r {
+				t = strings.ToUpper(t[:1]) + t[1:]
+			}
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	safe := FilenamePatternRe.ReplaceAllString(b.String(), "-")
+
+	// Dashes next to dots or at either end are left over from characters
+	// that were replaced, and only make the name harder to read.
+	parts := strings.Split(safe, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(part, "-")
+	}
+	safe = strings.Join(parts, ".")
+
+	// A name starting with a dot would be skipped as well.
+	if parts[0] == "" {
+		return "file" + safe
+	}
+	return safe
+}
+
+// Plans the renames that bring the files and directories that are left out of
+// the bundle at absPath because of unsafe characters in their names back in,
+// including the contents of directories that are renamed themselves.
+// Directories come before their contents, so renaming in reverse order keeps
+// the paths that are still to be renamed valid. Files that ignoreList or an
+// ignore file excludes anyway are left alone.
+func PlanSafeRenames(absPath string, ignoreList []*pathmatch.Pattern) ([]*Rename, error) {
+	return planSafeRenames(absPath, ".", ignoreList, map[string]bool{})
+}
+
+// Plans the renames in the directory relDir, to which ignoreList applies.
+// taken holds the new paths that have been planned already.
+func planSafeRenames(absPath, relDir string, ignoreList []*pathmatch.Pattern, taken map[string]bool) ([]*Rename, error) {
+	bun := New(filepath.Join(absPath, relDir))
+	if _, _, err := bun.Assemble(ignoreList, false); err != nil {
+		return nil, err
+	}
+
+	// Names are checked before ignore rules, so a file that is ignored anyway
+	// is reported as unsafe too. Renaming it could stop the rule from
+	// matching, and so publish it.
+	patterns := bun.IgnorePatterns()
+	matcher := pathmatch.NewMatcher()
+	matcher.AddPatterns(patterns...)
+
+	renames := []*Rename{}
+	for _, f := range bun.Skipped() {
+		if f.Reason != SkipReasonUnsafeCharacter {
+			continue
+		}
+		if ignored, _ := matcher.Match(f.Path, f.IsDir); ignored {
+			continue
+		}
+
+		from := filepath.Join(relDir, f.Path)
+		dir := filepath.Dir(from)
+		to := filepath.Join(dir, availableName(absPath, dir, SafeName(filepath.Base(from)), f.IsDir, taken))
+		taken[to] = true
+		renames = append(renames, &Rename{From: from, To: to})
+
+		if f.IsDir {
+			children, err := planSafeRenames(absPath, from, rebase(patterns, f.Path), taken)
+			if err != nil {
+				return nil, err
+			}
+			renames = append(renames, children...)
+		}
+	}
+
+	return renames, nil
+}
+
+// Returns the patterns that apply inside dir, relative to it
+func rebase(patterns []*pathmatch.Pattern, dir string) []*pathmatch.Pattern {
+	rebased := []*pathmatch.Pattern{}
+	for _, p := range patterns {
+		if p = p.Rebase(dir); p != nil {
+			rebased = append(rebased, p)
+		}
+	}
+	return rebased
+}
+
+// Returns name, or name with a number appended if something with that name is
+// already in dir or is about to be
+func availableName(absPath, dir, name string, isDir bool, taken map[string]bool) string {
+	base, ext := name, ""
+	if !isDir {
+		ext = filepath.Ext(name)
+		base = strings.TrimSuffix(name, ext)
+	}
+
+	candidate := name
+	for n := 2; ; n++ {
+		p := filepath.Join(dir, candidate)
+		if _, err := os.Lstat(filepath.Join(absPath, p)); os.IsNotExist(err) && !taken[p] {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+}
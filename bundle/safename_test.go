@@ -0,0 +1,79 @@
+package bundle_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SafeName()", func() {
+	for name, expected := range map[string]string{
+		"index.html":           "index.html",
+		"my photo.jpg":         "my-photo.jpg",
+		"Café Menu (2).pdf":    "Cafe-Menu-(2).pdf",
+		"wèîrd_föłdér":         "weird_folder",
+		"ÆSIR & co .png":       "AeSIR-co.png",
+		"\"$10dollar\"":        "10dollar",
+		"日本.txt":               "file.txt",
+		".secret file":         "file.secret-file",
+		"post(1.0_'@beta').rb": "post(1.0_'@beta').rb",
+		"straße/../secret.txt": "strasse..secret.txt",
+	} {
+		name, expected := name, expected
+
+		It("turns "+name+" into "+expected, func() {
+			safe := bundle.SafeName(name)
+			Expect(safe).To(Equal(expected))
+			Expect(bundle.FilenamePatternRe.MatchString(safe)).To(BeFalse())
+		})
+	}
+})
+
+var _ = Describe("PlanSafeRenames()", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "rise-test")
+		Expect(err).To(BeNil())
+
+		files := map[string]string{
+			"index.html":                           "index",
+			"my photo.jpg":                         "photo",
+			"my drafts/a.html":                     "draft",
+			"old site/b c.html":                    "b",
+			"old site/notes file.txt":              "notes",
+			"old site/secret file.css":             "secret",
+			"old site/js/d e.js":                   "d",
+			"old site/js/x y.map":                  "x",
+			bundle.IgnoreFileName:                  "my drafts/\n/old site/secret file.css\n",
+			"old site/js/" + bundle.IgnoreFileName: "*.map\n",
+		}
+		for name, content := range files {
+			p := filepath.Join(tempDir, name)
+			Expect(os.MkdirAll(filepath.Dir(p), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(p, []byte(content), 0600)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("renames what is left out for its name, but not what is ignored anyway", func() {
+		renames, err := bundle.PlanSafeRenames(tempDir, pathmatch.CompileAll("", "*.txt"))
+		Expect(err).To(BeNil())
+
+		Expect(renames).To(Equal([]*bundle.Rename{
+			{From: "my photo.jpg", To: "my-photo.jpg"},
+			{From: "old site", To: "old-site"},
+			{From: filepath.Join("old site", "b c.html"), To: filepath.Join("old site", "b-c.html")},
+			{From: filepath.Join("old site", "js", "d e.js"), To: filepath.Join("old site", "js", "d-e.js")},
+		}))
+	})
+})
@@ -0,0 +1,207 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+)
+
+// Maximum number of directories that are scanned, or files that are hashed,
+// at the same time
+var Concurrency = runtime.NumCPU()
+
+// walker finds the files to include in a bundle by scanning directories
+// concurrently. A directory is only scanned after its parent, so the ignore
+// files of all of its ancestors have been loaded by then.
+type walker struct {
+	matcher *pathmatch.Matcher
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mu        sync.Mutex
+	fileList  []string
+	fileSizes map[string]int64
+	skipped   []*SkippedFile
+	err       error
+}
+
+func newWalker(matcher *pathmatch.Matcher) *walker {
+	return &walker{
+		matcher:   matcher,
+		sem:       make(chan struct{}, concurrency()),
+		fileList:  []string{},
+		fileSizes: map[string]int64{},
+		skipped:   []*SkippedFile{},
+	}
+}
+
+// Walks the directory at basePath. The results are sorted in the order
+// filepath.Walk would have visited them, whatever order they were found in.
+func (w *walker) walk(basePath string) error {
+	// if there is an error lstat-ing a file, just skip it
+	fi, err := os.Lstat(basePath)
+	if err != nil {
+		return nil
+	}
+
+	// The name of the project's own directory never ends up in the bundle,
+	// so it is scanned even if it would be skipped otherwise.
+	if fi.IsDir() {
+		w.wg.Add(1)
+		go w.scan(basePath, ".")
+	} else {
+		w.visit(basePath, ".", fi)
+	}
+	w.wg.Wait()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	// The entries of a directory follow it in name order, the way
+	// filepath.Walk visits them.
+	sort.Stable(filesInWalkOrder(w.fileList))
+	sort.Stable(skippedInWalkOrder(w.skipped))
+
+	return nil
+}
+
+// Decides whether a file belongs in the bundle, and starts scanning it if it
+// is a directory that is not skipped
+func (w *walker) visit(path, relPath string, fi os.FileInfo) {
+	incl, fileSize, skipped, _ := shouldInclude(path, relPath, w.matcher, fi)
+
+	w.mu.Lock()
+	if skipped != nil {
+		w.skipped = append(w.skipped, skipped)
+	}
+	if incl {
+		w.fileList = append(w.fileList, relPath)
+		w.fileSizes[relPath] = fileSize
+	}
+	w.mu.Unlock()
+
+	if !incl && skipped == nil && fi.IsDir() {
+		w.wg.Add(1)
+		go w.scan(path, relPath)
+	}
+}
+
+func (w *walker) scan(dirPath, relDirPath string) {
+	defer w.wg.Done()
+
+	w.sem <- struct{}{}
+	defer func() { <-w.sem }()
+
+	if w.failed() {
+		return
+	}
+
+	// this directory is about to be scanned, so load its ignore file first
+	if err := addIgnoreFile(w.matcher, dirPath, relDirPath); err != nil {
+		w.fail(err)
+		return
+	}
+
+	// like filepath.Walk, skip directories that can't be read
+	f, err := os.Open(dirPath)
+	if err != nil {
+		return
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		path := filepath.Join(dirPath, name)
+		fi, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+		w.visit(path, filepath.Join(relDirPath, name), fi)
+	}
+}
+
+func (w *walker) failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err != nil
+}
+
+func (w *walker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Calls fn for every index from 0 to n-1 using up to Concurrency goroutines,
+// and returns the error of the lowest index that failed
+func forEach(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency() && i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func concurrency() int {
+	if Concurrency < 1 {
+		return 1
+	}
+	return Concurrency
+}
+
+type filesInWalkOrder []string
+
+func (a filesInWalkOrder) Len() int           { return len(a) }
+func (a filesInWalkOrder) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a filesInWalkOrder) Less(i, j int) bool { return walkOrderLess(a[i], a[j]) }
+
+type skippedInWalkOrder []*SkippedFile
+
+func (a skippedInWalkOrder) Len() int           { return len(a) }
+func (a skippedInWalkOrder) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a skippedInWalkOrder) Less(i, j int) bool { return walkOrderLess(a[i].Path, a[j].Path) }
+
+// Reports whether path a comes before b in walk order. A plain string
+// comparison would not do, as it puts "a-b" before "a/b".
+func walkOrderLess(a, b string) bool {
+	as := strings.Split(a, string(filepath.Separator))
+	bs := strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
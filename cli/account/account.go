@@ -0,0 +1,220 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/client/users"
+	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+func Show(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	u := fetchUser(token)
+
+	if common.JSONOutput() {
+		common.PrintJSON(map[string]interface{}{"user": u})
+		return
+	}
+
+	printUser(u)
+}
+
+// Changes the name and organization of the account. Only the attributes given
+// as flags are changed; without flags, both are asked for.
+func Update(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	attrs := map[string]string{}
+	for _, name := range []string{"name", "organization"} {
+		if c.IsSet(name) {
+			attrs[name] = strings.TrimSpace(c.String(name))
+		}
+	}
+
+	if len(attrs) == 0 {
+		u := fetchUser(token)
+
+		name, err := readline.Read(tui.Bold(promptWithDefault(tr.T("account_enter_name"), u.Name)+": "), false, u.Name)
+		util.ExitIfErrorOrEOF(err)
+		organization, err := readline.Read(tui.Bold(promptWithDefault(tr.T("account_enter_organization"), u.Organization)+": "), false, u.Organization)
+		util.ExitIfErrorOrEOF(err)
+
+		attrs["name"] = strings.TrimSpace(name)
+		attrs["organization"] = strings.TrimSpace(organization)
+	}
+
+	u, appErr := users.Update(token, attrs)
+	if appErr != nil {
+		if appErr.Code == users.ErrCodeValidationFailed {
+			common.Exit(common.ExitInvalid, appErr.Description)
+		}
+		appErr.Handle()
+	}
+
+	if common.JSONOutput() {
+		common.PrintJSON(map[string]interface{}{"user": u})
+		return
+	}
+
+	log.Info(tr.T("account_updated"))
+	tui.Println()
+	printUser(u)
+}
+
+// Changes the email address of the account, and asks for the confirmation code
+// that is sent to the new address
+func Email(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	email := strings.TrimSpace(c.Args().First())
+	interactive := email == ""
+
+	for {
+		var err error
+		if interactive {
+			email, err = readline.Read(tui.Bold(tr.T("account_enter_new_email")+": "), true, "")
+			util.ExitIfErrorOrEOF(err)
+		}
+
+		password, err := readline.ReadSecurely(tui.Bold(tr.T("enter_password")+": "), true, "")
+		util.ExitIfErrorOrEOF(err)
+
+		appErr := withOTP(func(otp string) *apperror.Error {
+			return users.ChangeEmail(token, email, password, otp)
+		})
+		if appErr == nil {
+			break
+		}
+
+		if appErr.Code == users.ErrCodeValidationFailed {
+			log.Error(appErr.Description)
+			if interactive {
+				continue
+			}
+			common.Exit(common.ExitInvalid, tr.T("error_in_input"))
+		}
+		appErr.Handle()
+	}
+
+	// The session stays valid, so the profile is updated right away, whether
+	// or not the new address is confirmed now.
+	if config.AccessTokenEnv == "" {
+		config.Email = email
+		if err := config.Save(); err != nil {
+			log.Fatal(tr.T("rise_config_write_failed"))
+		}
+	}
+
+	log.Infof(tr.T("account_email_changed"), email)
+	common.ConfirmEmail(email)
+	log.Info(tr.T("account_email_confirmed"))
+}
+
+// Deletes the account after the user has typed its email address and password
+func Delete(c *cli.Context) {
+	if config.AccessTokenEnv != "" {
+		log.Fatalf(tr.T("access_token_env_set"), config.AccessTokenEnv)
+	}
+
+	token := common.RequireAccessToken()
+
+	u := fetchUser(token)
+
+	log.Warnf(tui.Undl(tui.Bold(tr.T("account_delete_cannot_undo")))+" "+tr.T("account_delete_permanent"), u.Email)
+	for {
+		email, err := readline.Read(tui.Bold(fmt.Sprintf(tr.T("account_enter_email_to_confirm"), u.Email)+": "), true, "")
+		util.ExitIfErrorOrEOF(err)
+
+		if !strings.EqualFold(strings.TrimSpace(email), u.Email) {
+			log.Error(tr.T("account_email_does_not_match"))
+			continue
+		}
+
+		break
+	}
+
+	for {
+		password, err := readline.ReadSecurely(tui.Bold(tr.T("enter_password")+": "), true, "")
+		util.ExitIfErrorOrEOF(err)
+
+		appErr := withOTP(func(otp string) *apperror.Error {
+			return users.Delete(token, password, otp)
+		})
+		if appErr == nil {
+			break
+		}
+		appErr.Handle()
+	}
+
+	config.Email = ""
+	config.ClearTokens()
+	if err := config.Save(); err != nil {
+		log.Fatal(tr.T("rise_config_write_failed"))
+	}
+
+	log.Infof(tr.T("account_deleted"), u.Email)
+}
+
+func fetchUser(token string) *users.User {
+	u, appErr := users.Show(token)
+	if appErr != nil {
+		appErr.Handle()
+	}
+	if u == nil {
+		util.ExitSomethingWentWrong()
+	}
+	return u
+}
+
+func printUser(u *users.User) {
+	name, organization := tr.T("account_not_set"), tr.T("account_not_set")
+	if u.Name != "" {
+		name = u.Name
+	}
+	if u.Organization != "" {
+		organization = u.Organization
+	}
+
+	twoFactor := tr.T("account_2fa_off")
+	if u.TwoFactorEnabled {
+		twoFactor = tr.T("account_2fa_on")
+	}
+
+	tui.Println(tui.Bold(tr.T("account_header")))
+	tui.Printf("%-16s %s\n", tr.T("account_email")+":", u.Email)
+	tui.Printf("%-16s %s\n", tr.T("account_name")+":", name)
+	tui.Printf("%-16s %s\n", tr.T("account_organization")+":", organization)
+	tui.Printf("%-16s %s\n", tr.T("account_2fa")+":", twoFactor)
+}
+
+// Appends the current value to a prompt, which is kept if nothing is entered
+func promptWithDefault(prompt, def string) string {
+	if def == "" {
+		return prompt
+	}
+	return fmt.Sprintf("%s [%s]", prompt, def)
+}
+
+// Makes a request that is authorized with the password, asking for a
+// two-factor authentication code for as long as the server wants one
+func withOTP(request func(otp string) *apperror.Error) *apperror.Error {
+	appErr := request("")
+	for appErr != nil && (appErr.Code == users.ErrCodeOTPRequired || appErr.Code == users.ErrCodeInvalidOTP) {
+		if appErr.Code == users.ErrCodeInvalidOTP {
+			appErr.Print()
+		}
+		appErr = request(common.ReadOTP())
+	}
+	return appErr
+}
@@ -0,0 +1,35 @@
+package check
+
+import (
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/cli/deploy"
+	"github.com/nitrous-io/rise-cli-go/project"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+// Looks for broken links and assets in the HTML and CSS files that would be
+// published
+func Check(c *cli.Context) {
+	var proj *project.Project
+	if c.Bool("offline") {
+		proj = common.RequireLocalProject()
+	} else {
+		proj = common.RequireProject(common.RequireAccessToken())
+	}
+
+	absPath, err := filepath.Abs(proj.Path)
+	util.ExitIfError(err)
+
+	bun := bundle.New(proj.Path)
+	_, _, err = bun.Assemble(deploy.IgnoreList(absPath), false)
+	util.ExitIfError(err)
+
+	if !deploy.CheckSite(absPath, bun, proj.ForceHTTPS, c.Bool("json") || common.JSONOutput()) {
+		common.Exit(common.ExitInvalid, tr.T("check_failed"))
+	}
+}
@@ -24,6 +24,17 @@ func List(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		if cols == nil {
+			cols = []*collaborators.Collaborator{}
+		}
+		common.PrintJSON(map[string]interface{}{
+			"project":       proj.Name,
+			"collaborators": cols,
+		})
+		return
+	}
+
 	tui.Printf(tui.Undl(tui.Bold(tr.T("collab_list_header")))+"\n", proj.Name)
 	for _, col := range cols {
 		tui.Println("- " + col.Email)
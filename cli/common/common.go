@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -9,7 +10,8 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/franela/goreq"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/client/oauth"
 	"github.com/nitrous-io/rise-cli-go/client/projects"
 	"github.com/nitrous-io/rise-cli-go/client/users"
 	"github.com/nitrous-io/rise-cli-go/config"
@@ -19,9 +21,32 @@ import (
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
+// Output formats
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+const (
+	// How long a token that the server has accepted is trusted without asking
+	// it again. A token that is revoked in the meantime still fails the
+	// requests it is used for.
+	TokenValidationTTL = time.Hour
+
+	// Tokens that expire within this are renewed before they are used
+	tokenExpiryMargin = time.Minute
+)
+
 var (
 	sharedDebugLogger *log.Logger
 	once              sync.Once
+
+	// OutputFormat is set by the global --output flag.
+	OutputFormat = OutputText
+
+	// Set once renewing the access token has failed, so that it is not tried
+	// again by every request
+	renewFailed bool
 )
 
 func DebugLog() *log.Logger {
@@ -32,7 +57,10 @@ func DebugLog() *log.Logger {
 		sharedDebugLogger.Level = log.DebugLevel
 		sharedDebugLogger.Out = ioutil.Discard
 
-		if f, err := os.OpenFile(p, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644); err == nil {
+		// Request bodies are logged with --debug-http, so the log is only
+		// readable by the user, including one created by an older version.
+		if f, err := os.OpenFile(p, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600); err == nil {
+			f.Chmod(0600)
 			sharedDebugLogger.Out = f
 		}
 	})
@@ -40,38 +68,132 @@ func DebugLog() *log.Logger {
 	return sharedDebugLogger
 }
 
+// Reports whether commands should print a JSON document to stdout instead of
+// human readable text
+func JSONOutput() bool {
+	return OutputFormat == OutputJSON
+}
+
+// Prints v to stdout as indented JSON
+func PrintJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	util.ExitIfError(err)
+
+	os.Stdout.Write(append(b, '\n'))
+}
+
+// Returns the access token, exiting if the user is not logged in. A stored
+// token is only checked with the server if it has not been for
+// TokenValidationTTL, and is renewed with the refresh token if it has expired.
 func RequireAccessToken() string {
-	token := config.AccessToken
-	if token == "" {
-		log.Fatal(tr.T("not_logged_in"))
+	if err := config.LoadAccessToken(); err != nil {
+		Exitf(ExitAuthFailed, tr.T("credentials_read_failed"), config.Credentials.Name(), err)
 	}
 
-	_, appErr := users.Show(token)
-	if appErr != nil {
-		if appErr.Code == users.ErrCodeAuthFailed {
-			// Remove saved access token since it's invalid.
-			config.Email = ""
-			config.AccessToken = ""
-			if err := config.Save(); err != nil {
-				DebugLog().Warnf("failed to remove access token from config, err: %v", err)
+	if config.AccessToken == "" {
+		Exit(ExitAuthFailed, tr.T("not_logged_in"))
+	}
+
+	// Requests made after the token has been checked may still have it
+	// rejected, e.g. if it is revoked or cannot be renewed.
+	api.OnUnauthorized = exitUnauthorized
+
+	if config.AccessTokenEnv == "" {
+		api.RenewToken = renewAccessToken
+
+		// Renew a token that is about to expire now, rather than have a
+		// request fail with it.
+		if !config.TokenExpiresAt.IsZero() && time.Now().Add(tokenExpiryMargin).After(config.TokenExpiresAt) {
+			if _, ok := renewAccessToken(config.AccessToken); !ok {
+				config.TokenValidatedAt = time.Time{}
 			}
+		}
 
-			log.Fatal(tr.T("login_expired"))
+		if time.Since(config.TokenValidatedAt) < TokenValidationTTL {
+			return config.AccessToken
+		}
+	}
+
+	// A rejected token is renewed by the request itself, if it can be.
+	_, appErr := users.Show(config.AccessToken)
+	if appErr != nil {
+		if appErr.Code == users.ErrCodeAuthFailed {
+			exitUnauthorized(config.AccessToken)
 		}
 
 		appErr.Handle()
 	}
 
-	return token
+	if config.AccessTokenEnv == "" {
+		config.TokenValidatedAt = time.Now()
+		if err := config.Save(); err != nil {
+			DebugLog().Warnf("failed to save token validation time, err: %v", err)
+		}
+	}
+
+	return config.AccessToken
 }
 
-func RequireProject(accessToken string) *project.Project {
+// Exits because the access token has been rejected, removing the saved tokens
+// unless the token comes from the environment
+func exitUnauthorized(token string) {
+	if config.AccessTokenEnv != "" {
+		Exitf(ExitAuthFailed, tr.T("access_token_env_invalid"), config.AccessTokenEnv)
+	}
+
+	// Remove saved access token since it's invalid.
+	config.Email = ""
+	config.ClearTokens()
+	if err := config.Save(); err != nil {
+		DebugLog().Warnf("failed to remove access token from config, err: %v", err)
+	}
+
+	Exit(ExitAuthFailed, tr.T("login_expired"))
+}
+
+// Renews the access token with the refresh token and saves the new tokens.
+// token is the token that was rejected; if it has been renewed since, the new
+// one is returned without renewing it again.
+func renewAccessToken(token string) (string, bool) {
+	if token != config.AccessToken {
+		return config.AccessToken, true
+	}
+	if config.RefreshToken == "" || renewFailed {
+		return "", false
+	}
+
+	t, appErr := oauth.RefreshAccessToken(config.RefreshToken)
+	if appErr != nil {
+		if appErr.Code != oauth.ErrCodeInvalidGrant {
+			appErr.Handle()
+		}
+		DebugLog().Warnf("failed to renew access token, err: %v", appErr)
+		renewFailed = true
+		return "", false
+	}
+
+	config.SetTokens(t.AccessToken, t.RefreshToken, t.ExpiresAt)
+	if err := config.Save(); err != nil {
+		DebugLog().Warnf("failed to save renewed access token, err: %v", err)
+	}
+
+	return t.AccessToken, true
+}
+
+// Loads the project in the current directory without contacting the server
+func RequireLocalProject() *project.Project {
 	proj, err := project.Load()
 	if os.IsNotExist(err) {
 		log.Fatal(tr.T("no_rise_project"))
 	}
 	util.ExitIfError(err)
 
+	return proj
+}
+
+func RequireProject(accessToken string) *project.Project {
+	proj := RequireLocalProject()
+
 	apiProj, appErr := projects.Get(accessToken, proj.Name)
 	if appErr != nil {
 		if appErr.Code == projects.ErrCodeNotFound {
@@ -81,6 +203,7 @@ func RequireProject(accessToken string) *project.Project {
 	}
 
 	proj.DefaultDomainEnabled = apiProj.DefaultDomainEnabled
+	proj.ForceHTTPS = apiProj.ForceHTTPS
 
 	return proj
 }
@@ -116,11 +239,10 @@ func CheckForUpdates() {
 		DebugLog().Errorf("failed to save last update check time, err: %v", err)
 	}
 
-	res, err := goreq.Request{
-		Method:    "GET",
-		Uri:       config.LatestVersionURL,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	res, err := api.Request{
+		Method:  "GET",
+		URL:     config.LatestVersionURL,
+		NoRetry: true,
 	}.Do()
 
 	if err != nil {
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/users"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Asks for the confirmation code that was sent to an email address until the
+// right one is entered. Entering "resend" sends the code again, once.
+func ConfirmEmail(email string) {
+	resendUsed := false
+	for {
+		tui.Println()
+		var prompt string
+		if resendUsed {
+			prompt = tr.T("enter_confirmation")
+		} else {
+			prompt = tr.T("enter_confirmation_resend")
+		}
+		confirmationCode, err := readline.Read(tui.Bold(prompt+": "), true, "")
+		util.ExitIfErrorOrEOF(err)
+
+		var appErr *apperror.Error
+		if confirmationCode == "resend" && !resendUsed {
+			appErr = users.ResendConfirmationCode(email)
+			if appErr == nil {
+				resendUsed = true
+				log.Info(tr.T("confirmation_resent"))
+				continue
+			}
+		} else {
+			appErr = users.Confirm(email, confirmationCode)
+			if appErr == nil {
+				break
+			}
+		}
+
+		appErr.Handle()
+	}
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Exit codes. Failures that scripts may want to react to have their own code,
+// everything else exits with ExitError. These are documented in README.md and
+// must not be renumbered.
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitAuthFailed    = 2
+	ExitInvalid       = 3
+	ExitProjectLocked = 4
+	ExitUploadFailed  = 5
+	ExitBuildFailed   = 6
+	ExitTimeout       = 7
+)
+
+// Prints an error message and exits with the given code
+func Exit(code int, args ...interface{}) {
+	log.Error(args...)
+	os.Exit(code)
+}
+
+// Prints a formatted error message and exits with the given code
+func Exitf(code int, format string, args ...interface{}) {
+	log.Errorf(format, args...)
+	os.Exit(code)
+}
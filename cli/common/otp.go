@@ -0,0 +1,36 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/oauth"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+// Asks for a code from the authenticator app, or a recovery code
+func ReadOTP() string {
+	otp, err := readline.Read(tui.Bold(tr.T("enter_otp")+": "), true, "")
+	util.ExitIfErrorOrEOF(err)
+
+	// Authenticator apps show codes in groups, e.g. "123 456".
+	return strings.Replace(otp, " ", "", -1)
+}
+
+// Fetches a token with an email and password, asking for a two-factor
+// authentication code if the account requires one
+func FetchToken(email, password string) (*oauth.Token, *apperror.Error) {
+	token, appErr := oauth.FetchToken(email, password)
+	for appErr != nil && (appErr.Code == oauth.ErrCodeOTPRequired || appErr.Code == oauth.ErrCodeInvalidOTP) {
+		if appErr.Code == oauth.ErrCodeInvalidOTP {
+			appErr.Print()
+		}
+
+		token, appErr = oauth.FetchTokenWithOTP(email, password, ReadOTP())
+	}
+
+	return token, appErr
+}
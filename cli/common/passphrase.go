@@ -0,0 +1,35 @@
+package common
+
+import (
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Asks for the passphrase of the encrypted credentials file. A new passphrase
+// has to be entered twice.
+func ReadPassphrase(confirm bool) (string, error) {
+	if !confirm {
+		return readline.ReadSecurely(tui.Bold(tr.T("enter_passphrase")+": "), true, "")
+	}
+
+	tui.Println(tr.T("new_passphrase_info"))
+	for {
+		passphrase, err := readline.ReadSecurely(tui.Bold(tr.T("enter_new_passphrase")+": "), true, "")
+		if err != nil {
+			return "", err
+		}
+
+		confirmation, err := readline.ReadSecurely(tui.Bold(tr.T("confirm_passphrase")+": "), true, "")
+		if err != nil {
+			return "", err
+		}
+
+		if passphrase == confirmation {
+			return passphrase, nil
+		}
+		log.Error(tr.T("passphrase_mismatch"))
+	}
+}
@@ -0,0 +1,136 @@
+package deploy
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/sitecheck"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Checks the references in the HTML and CSS files of an assembled bundle, and
+// prints the problems found. Returns false if there are any.
+func CheckSite(absPath string, bun *bundle.Bundle, forceHTTPS, asJSON bool) bool {
+	problems, err := sitecheck.Check(absPath, bun, forceHTTPS)
+	util.ExitIfError(err)
+
+	if asJSON {
+		common.PrintJSON(map[string]interface{}{
+			"problems": problems,
+		})
+		return len(problems) == 0
+	}
+
+	if len(problems) == 0 {
+		log.Info(tr.T("check_ok"))
+		return true
+	}
+
+	printProblems(problems)
+	return false
+}
+
+// Checks the bundle before it is published, exiting if there is a problem.
+// Only references to files that are left out of the bundle are looked for,
+// unless all is set, and not even those if allowDropped is.
+func checkBeforePublish(projName, absPath string, bun *bundle.Bundle, forceHTTPS, all, allowDropped bool) {
+	if !all && allowDropped {
+		return
+	}
+
+	if all {
+		tui.Printf("\n"+tr.T("checking_site")+"\n", projName)
+	}
+
+	problems, err := sitecheck.Check(absPath, bun, forceHTTPS)
+	util.ExitIfError(err)
+
+	reported := []*sitecheck.Problem{}
+	for _, p := range problems {
+		if p.Kind == sitecheck.KindIgnored && !allowDropped || p.Kind != sitecheck.KindIgnored && all {
+			reported = append(reported, p)
+		}
+	}
+
+	if len(reported) == 0 {
+		if all {
+			log.Info(tr.T("check_ok"))
+		}
+		return
+	}
+
+	printProblems(reported)
+	if all {
+		common.Exit(common.ExitInvalid, tr.T("check_failed_publish"))
+	}
+	common.Exit(common.ExitInvalid, tr.T("dropped_referenced"))
+}
+
+func printProblems(problems []*sitecheck.Problem) {
+	for _, p := range problems {
+		var msg string
+		switch p.Kind {
+		case sitecheck.KindBroken:
+			msg = fmt.Sprintf(tr.T("check_broken"), p.Ref)
+		case sitecheck.KindIgnored:
+			msg = fmt.Sprintf(tr.T("check_ignored"), p.Ref, p.Target, skipReason(p.Skipped))
+		case sitecheck.KindCaseMismatch:
+			msg = fmt.Sprintf(tr.T("check_case_mismatch"), p.Ref, p.Target)
+		case sitecheck.KindInsecure:
+			msg = fmt.Sprintf(tr.T("check_insecure"), p.Ref)
+		}
+		tui.Printf("%s %s\n", tui.Bold(fmt.Sprintf("%s:%d:", p.File, p.Line)), msg)
+	}
+
+	tui.Println()
+	log.Warnf(tr.T("check_problems"), len(problems))
+}
+
+// Lists the files that were left out of the bundle because of their names,
+// which is rarely intended, and counts the others that were skipped
+func printDropped(skipped []*bundle.SkippedFile, verbose bool) {
+	unsafe := []*bundle.SkippedFile{}
+	others := 0
+	for _, f := range skipped {
+		switch {
+		case f.Reason == bundle.SkipReasonUnsafeCharacter:
+			unsafe = append(unsafe, f)
+		case f.Reason == bundle.SkipReasonIgnored && f.PatternFile == "":
+			// built-in patterns, e.g. the project's own config, are not
+			// worth mentioning
+		default:
+			others++
+		}
+	}
+
+	if len(unsafe) > 0 {
+		log.Warnf(tr.T("dropped_unsafe_names"), len(unsafe))
+
+		// With --verbose, every skipped file has been listed already.
+		if !verbose {
+			for i, f := range unsafe {
+				if i == MaxListedDropped {
+					tui.Printf("  "+tr.T("dropped_more")+"\n", len(unsafe)-i)
+					break
+				}
+
+				path := filepath.ToSlash(f.Path)
+				if f.IsDir {
+					path += "/"
+				}
+				tui.Printf("  %s\n", tui.Ylo(path))
+			}
+		}
+		tui.Println(tr.T("dropped_fix_hint"))
+	}
+
+	if others > 0 {
+		log.Infof(tr.T("dropped_others"), others)
+	}
+}
@@ -18,8 +18,10 @@ import (
 	"github.com/nitrous-io/rise-cli-go/client/projects"
 	"github.com/nitrous-io/rise-cli-go/client/rawbundles"
 	"github.com/nitrous-io/rise-cli-go/config"
-	"github.com/nitrous-io/rise-cli-go/pkg/ignore"
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
 	"github.com/nitrous-io/rise-cli-go/pkg/spinner"
+	"github.com/nitrous-io/rise-cli-go/progressbar"
+	"github.com/nitrous-io/rise-cli-go/project"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/tui"
 	"github.com/nitrous-io/rise-cli-go/util"
@@ -28,38 +30,66 @@ import (
 )
 
 const (
-	StormIgnoreFile   = ".stormignore"
+	StormIgnoreFile   = bundle.IgnoreFileName
 	MaxIdealFileCount = 3000
+	MaxLargestFiles   = 10
+	MaxListedDropped  = 10
 )
 
+// Returns the patterns of files to exclude from the bundle of the project at
+// absPath, in addition to those in its ignore files
+func IgnoreList(absPath string) []*pathmatch.Pattern {
+	cwd, err := os.Getwd()
+	util.ExitIfError(err)
+
+	ignoreList := pathmatch.CompileAll("", config.ProjectJSON, "Thumbs.db", "desktop.ini")
+
+	// Ignore files inside the project path are picked up while the bundle is
+	// assembled, so the one in the current directory only needs to be read if
+	// it lies above the project path. Its patterns stay relative to the
+	// current directory, as with any other ignore file.
+	patterns, err := bundle.ParentIgnoreFile(cwd, absPath)
+	util.ExitIfError(err)
+
+	return append(ignoreList, patterns...)
+}
+
 func Deploy(c *cli.Context) {
 	verbose := c.Bool("verbose")
-
-	token := common.RequireAccessToken()
-	proj := common.RequireProject(token)
+	dryRun := c.Bool("dry-run")
+	timeout := c.Duration("timeout")
+
+	var (
+		token string
+		proj  *project.Project
+	)
+	if dryRun {
+		proj = common.RequireLocalProject()
+	} else {
+		token = common.RequireAccessToken()
+		proj = common.RequireProject(token)
+	}
 
 	absPath, err := filepath.Abs(proj.Path)
 	util.ExitIfError(err)
 
-	ignoreFiles := []string{config.ProjectJSON, "Thumbs.db", "desktop.ini"}
-	_, err = os.Stat(StormIgnoreFile)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			util.ExitIfError(err)
-		}
-	} else {
-		b, err := ioutil.ReadFile(StormIgnoreFile)
+	ignoreFiles := IgnoreList(absPath)
+
+	if dryRun {
+		bun := bundle.New(proj.Path)
+		_, _, err := bun.Assemble(ignoreFiles, false)
 		util.ExitIfError(err)
 
-		for _, ignoreFile := range ignore.Parse(string(b)) {
-			ignoreFiles = append(ignoreFiles, ignoreFile)
-		}
+		printReport(proj.Name, bun.Report(MaxLargestFiles), c.Bool("json") || common.JSONOutput())
+		return
 	}
 
 	tui.Printf(tr.T("scanning_path")+"\n", absPath)
 
 	bun := bundle.New(proj.Path)
+	bun.Reproducible = !c.Bool("no-reproducible")
 	count, size, err := bun.Assemble(ignoreFiles, verbose)
+	printDropped(bun.Skipped(), verbose)
 
 	if size == 0 || count == 0 {
 		log.Infof(tr.T("emtpy_project"))
@@ -73,7 +103,7 @@ func Deploy(c *cli.Context) {
 	}
 
 	if size > config.MaxProjectSize {
-		log.Fatalf(tr.T("project_size_exceeded"), humanize.Bytes(uint64(config.MaxProjectSize)))
+		common.Exitf(common.ExitInvalid, tr.T("project_size_exceeded"), humanize.Bytes(uint64(config.MaxProjectSize)))
 	}
 
 	indexHTMLPath := filepath.Join(absPath, "index.html")
@@ -81,13 +111,187 @@ func Deploy(c *cli.Context) {
 		log.Warnf(tr.T("bundle_root_index_missing"))
 	}
 
+	checkBeforePublish(proj.Name, absPath, bun, proj.ForceHTTPS, c.Bool("check"), c.Bool("allow-dropped"))
+
+	tui.Printf("\n"+tr.T("computing_manifest")+"\n", humanize.Comma(int64(count)))
+
+	manifest, err := bun.Manifest()
+	util.ExitIfError(err)
+
+	deployment := deployWithManifest(token, proj.Name, absPath, manifest)
+	if deployment == nil {
+		if verbose {
+			log.Info(tr.T("incremental_unsupported"))
+		}
+		deployment = deployWithBundle(token, proj.Name, bun)
+	}
+
+	if c.Bool("no-wait") {
+		if common.JSONOutput() {
+			common.PrintJSON(map[string]interface{}{
+				"project": proj.Name,
+				"deployment": map[string]interface{}{
+					"id":      deployment.ID,
+					"version": deployment.Version,
+					"state":   deployment.State,
+				},
+			})
+			return
+		}
+
+		log.Infof(tr.T("publish_started"), deployment.Version, proj.Name, deployment.ID)
+		return
+	}
+
+	if c.Bool("logs") {
+		deployment = StreamDeploymentLogs(token, proj.Name, deployment, timeout)
+		if deployment.State == deployments.DeploymentStateDeployFailed {
+			common.Exitf(common.ExitBuildFailed, tr.T("deployment_failure"), proj.Name, deployment.ErrorMessage)
+		}
+	} else {
+		deployment = ShowDeploymentProcess(token, proj.Name, deployment, timeout)
+	}
+
+	domainNames, appErr := domains.Index(token, proj.Name)
+	if appErr != nil {
+		appErr.Handle()
+	}
+
+	if common.JSONOutput() {
+		if domainNames == nil {
+			domainNames = []string{}
+		}
+		common.PrintJSON(map[string]interface{}{
+			"project": proj.Name,
+			"deployment": map[string]interface{}{
+				"id":      deployment.ID,
+				"version": deployment.Version,
+				"state":   deployment.State,
+			},
+			"domains": domainNames,
+		})
+		return
+	}
+
+	if len(domainNames) > 0 {
+		log.Infof(tr.T("published"), proj.Name)
+		for _, domainName := range domainNames {
+			tui.Println("=> " + tui.Undl(domainName))
+		}
+	} else {
+		log.Warnf(tr.T("published_no_domain"), proj.Name)
+	}
+}
+
+// Prints which files would be included in and excluded from the bundle
+func printReport(projName string, report *bundle.Report, asJSON bool) {
+	if asJSON {
+		common.PrintJSON(report)
+		return
+	}
+
+	tui.Printf(tui.Undl(tui.Bold(tr.T("dry_run_included")))+"\n", projName)
+	for _, f := range report.Included {
+		tui.Printf("%10s  %s\n", humanize.Bytes(uint64(f.Size)), f.Path)
+	}
+
+	tui.Println()
+	tui.Printf(tui.Undl(tui.Bold(tr.T("dry_run_skipped")))+"\n", projName)
+	for _, f := range report.Skipped {
+		path := f.Path
+		if f.IsDir {
+			path += "/"
+		}
+
+		tui.Printf("%-18s %s %s\n", f.Reason, path, tui.Ylo("("+skipReason(f)+")"))
+	}
+
+	tui.Println()
+	tui.Println(tui.Undl(tui.Bold(tr.T("dry_run_largest"))))
+	for _, f := range report.Largest {
+		tui.Printf("%10s  %s\n", humanize.Bytes(uint64(f.Size)), f.Path)
+	}
+
+	tui.Println()
+	log.Infof(tr.T("dry_run_summary"), humanize.Comma(int64(report.IncludedCount)), humanize.Bytes(uint64(report.IncludedSize)), humanize.Comma(int64(report.SkippedCount)))
+}
+
+// Describes why a file was skipped, including the pattern that matched it if
+// it was ignored
+func skipReason(f *bundle.SkippedFile) string {
+	if f.Pattern == "" {
+		return f.Message()
+	}
+
+	if f.PatternFile == "" {
+		return fmt.Sprintf(tr.T("dry_run_matched_builtin"), f.Pattern)
+	}
+	return fmt.Sprintf(tr.T("dry_run_matched_pattern"), f.Pattern, f.PatternFile)
+}
+
+// Uploads only the files whose content the server does not already have, then
+// creates a deployment from the manifest. Returns nil if the server does not
+// support incremental deployments.
+func deployWithManifest(token, projName, absPath string, manifest []*bundle.ManifestEntry) *deployments.Deployment {
+	missing, appErr := deployments.MissingBlobs(token, projName, manifest)
+	if appErr != nil {
+		if appErr.Code == deployments.ErrCodeNotSupported {
+			return nil
+		}
+		exitUploadFailed(projName, appErr)
+	}
+
+	// Several files may have identical content, so index them by checksum to
+	// upload each blob only once.
+	entries := map[string]*bundle.ManifestEntry{}
+	for _, entry := range manifest {
+		entries[entry.Checksum] = entry
+	}
+
+	var (
+		toUpload []*bundle.ManifestEntry
+		size     int64
+	)
+	for _, checksum := range missing {
+		if entry, ok := entries[checksum]; ok {
+			toUpload = append(toUpload, entry)
+			size += entry.Size
+		}
+	}
+
+	if len(toUpload) == 0 {
+		log.Info(tr.T("no_changed_files"))
+	} else {
+		tui.Printf("\n"+tr.T("uploading_changed_files")+"\n", humanize.Comma(int64(len(toUpload))), humanize.Bytes(uint64(size)))
+
+		pb := progressbar.NewCounter(tui.Out, len(toUpload))
+		for _, entry := range toUpload {
+			path := filepath.Join(absPath, filepath.FromSlash(entry.Path))
+			if appErr := deployments.UploadBlob(token, projName, entry.Checksum, path); appErr != nil {
+				exitUploadFailed(projName, appErr)
+			}
+			pb.Next()
+		}
+	}
+
+	deployment, appErr := deployments.CreateWithManifest(token, projName, manifest)
+	if appErr != nil {
+		exitUploadFailed(projName, appErr)
+	}
+
+	return deployment
+}
+
+// Packs the whole project into a tarball and uploads it, unless a bundle with
+// an identical checksum has been uploaded before.
+func deployWithBundle(token, projName string, bun *bundle.Bundle) *deployments.Deployment {
 	tempDir, err := ioutil.TempDir("", "rise-deploy")
 	util.ExitIfError(err)
 	defer os.RemoveAll(tempDir)
 
 	bunPath := filepath.Join(tempDir, "bundle.tar.gz")
 
-	tui.Printf("\n"+tr.T("packing_bundle")+"\n", proj.Name)
+	tui.Printf("\n"+tr.T("packing_bundle")+"\n", projName)
 
 	err = bun.Pack(bunPath, true, true)
 	util.ExitIfError(err)
@@ -96,58 +300,62 @@ func Deploy(c *cli.Context) {
 	util.ExitIfError(err)
 
 	if fi.Size() > config.MaxBundleSize {
-		log.Fatalf(tr.T("bundle_size_exceeded"), humanize.Bytes(uint64(config.MaxBundleSize)))
+		common.Exitf(common.ExitInvalid, tr.T("bundle_size_exceeded"), humanize.Bytes(uint64(config.MaxBundleSize)))
 	}
 
 	checksum, err := bundle.Sha256Sum(bunPath)
 	util.ExitIfError(err)
 
-	rawBundle, appErr := rawbundles.Get(token, proj.Name, checksum)
+	rawBundle, appErr := rawbundles.Get(token, projName, checksum)
 	if appErr != nil && appErr.Code != rawbundles.ErrCodeNotFound {
-		if appErr.Code == deployments.ErrCodeProjectLocked {
-			log.Fatalf(tr.T("project_is_locked"), proj.Name)
-		}
-		appErr.Handle()
+		exitUploadFailed(projName, appErr)
 	}
 
 	var deployment *deployments.Deployment
 	if rawBundle == nil {
-		tui.Printf("\n"+tr.T("uploading_bundle")+"\n", proj.Name)
-		deployment, appErr = deployments.Create(token, proj.Name, bunPath, false)
+		tui.Printf("\n"+tr.T("uploading_bundle")+"\n", projName)
+		deployment, appErr = deployments.CreateChunked(token, projName, bunPath, false)
 		if appErr != nil {
-			if appErr.Code == projects.ErrCodeNotFound {
-				log.Fatalf(tr.T("project_not_found"), proj.Name)
-			}
-			appErr.Handle()
+			exitUploadFailed(projName, appErr)
 		}
 	} else {
-		deployment, appErr = deployments.CreateWithChecksum(token, proj.Name, checksum)
+		deployment, appErr = deployments.CreateWithChecksum(token, projName, checksum)
 		if appErr != nil {
-			if appErr.Code == projects.ErrCodeNotFound {
-				log.Fatalf(tr.T("project_not_found"), proj.Name)
-			}
-			appErr.Handle()
+			exitUploadFailed(projName, appErr)
 		}
 	}
 
-	ShowDeploymentProcess(token, proj.Name, deployment)
-
-	domainNames, appErr := domains.Index(token, proj.Name)
-	if appErr != nil {
-		appErr.Handle()
-	}
+	return deployment
+}
 
-	if len(domainNames) > 0 {
-		log.Infof(tr.T("published"), proj.Name)
-		for _, domainName := range domainNames {
-			tui.Println("=> " + tui.Undl(domainName))
+// Exits with the code that matches an error from uploading a project or
+// creating a deployment of it
+func exitUploadFailed(projName string, appErr *apperror.Error) {
+	switch appErr.Code {
+	case deployments.ErrCodeAuthFailed:
+		if config.AccessTokenEnv != "" {
+			common.Exitf(common.ExitAuthFailed, tr.T("access_token_env_invalid"), config.AccessTokenEnv)
 		}
-	} else {
-		log.Warnf(tr.T("published_no_domain"), proj.Name)
+		common.Exit(common.ExitAuthFailed, tr.T("login_expired"))
+	case projects.ErrCodeNotFound, deployments.ErrCodeProjectNotFound:
+		common.Exitf(common.ExitError, tr.T("project_not_found"), projName)
+	case deployments.ErrCodeProjectLocked:
+		common.Exitf(common.ExitProjectLocked, tr.T("project_is_locked"), projName)
+	case deployments.ErrCodeValidationFailed:
+		common.Exit(common.ExitInvalid, appErr.Error())
 	}
+	common.Exit(common.ExitUploadFailed, appErr.Error())
 }
 
-func ShowDeploymentProcess(token string, projName string, deployment *deployments.Deployment) {
+// Polls the deployment until it is deployed, and returns it in its final state.
+// Exits if it fails, or if it is still in progress after timeout. A zero
+// timeout waits indefinitely.
+func ShowDeploymentProcess(token string, projName string, deployment *deployments.Deployment, timeout time.Duration) *deployments.Deployment {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
 	spin := spinner.New()
 	currentState := ""
 	optimized := false
@@ -174,12 +382,17 @@ func ShowDeploymentProcess(token string, projName string, deployment *deployment
 			case deployments.DeploymentStateDeployFailed:
 				tui.Println("\b \b") // "Eat up" spinner characters from previous optimizing log.
 
-				log.Fatal(fmt.Sprintf(tr.T("deployment_failure"), projName, deployment.ErrorMessage))
+				common.Exitf(common.ExitBuildFailed, tr.T("deployment_failure"), projName, deployment.ErrorMessage)
 			}
 
 			currentState = deployment.State
 		}
 
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			tui.Println("\b \b")
+			exitTimedOut(projName, deployment, timeout)
+		}
+
 		for i := 0; i < 5; i++ {
 			time.Sleep(100 * time.Millisecond)
 			tui.Printf(tui.Blu("\b%s"), string(spin.Next()))
@@ -192,4 +405,10 @@ func ShowDeploymentProcess(token string, projName string, deployment *deployment
 	}
 
 	tui.Println("\b \b")
+
+	return deployment
+}
+
+func exitTimedOut(projName string, deployment *deployments.Deployment, timeout time.Duration) {
+	common.Exitf(common.ExitTimeout, tr.T("deployment_timed_out"), timeout, deployment.Version, projName, deployment.ID)
 }
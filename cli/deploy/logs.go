@@ -0,0 +1,123 @@
+package deploy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// How often a deployment is polled if the server does not keep its logs
+const PollInterval = 500 * time.Millisecond
+
+// Prints the logs of a deployment from the beginning, along with its state
+// transitions, as they come in until it has been deployed or has failed.
+// Returns the deployment in its final state. Only the state transitions are
+// shown if the server does not keep deployment logs. Exits if the deployment is
+// still in progress after timeout, unless timeout is zero.
+func StreamDeploymentLogs(token, projName string, deployment *deployments.Deployment, timeout time.Duration) *deployments.Deployment {
+	var (
+		state         string
+		after         uint64
+		logsSupported = true
+		deadline      time.Time
+	)
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		var (
+			newState string
+			errMsg   string
+			received int
+		)
+
+		if logsSupported {
+			// The server may hold a waiting request for longer than is left
+			// before the deadline, so poll instead as it draws near.
+			wait := !deployments.Finished(state)
+			if wait && !deadline.IsZero() && time.Now().Add(deployments.LogsWaitTimeout).After(deadline) {
+				wait = false
+				if state != "" {
+					time.Sleep(PollInterval)
+				}
+			}
+
+			logs, appErr := deployments.GetLogs(token, projName, deployment.ID, after, wait)
+			if appErr != nil {
+				if appErr.Code != deployments.ErrCodeNotSupported {
+					appErr.Handle()
+				}
+				log.Warn(tr.T("deployment_logs_unsupported"))
+				logsSupported = false
+				continue
+			}
+
+			for _, entry := range logs.Entries {
+				printLogEntry(entry.Timestamp, entry.Message)
+			}
+			received = len(logs.Entries)
+			after = logs.LastSeq(after)
+			newState, errMsg = logs.State, logs.ErrorMessage
+		} else {
+			if state != "" {
+				time.Sleep(PollInterval)
+			}
+
+			d, appErr := deployments.Get(token, projName, deployment.ID)
+			if appErr != nil {
+				appErr.Handle()
+			}
+			newState, errMsg = d.State, d.ErrorMessage
+		}
+
+		if newState != state {
+			printStateTransition(newState, deployment.Version, errMsg)
+			state = newState
+		}
+
+		// Logs may still be written after the final state transition, so stop
+		// only once there are no more.
+		if deployments.Finished(state) && received == 0 {
+			break
+		}
+
+		if !deployments.Finished(state) && !deadline.IsZero() && time.Now().After(deadline) {
+			exitTimedOut(projName, deployment, timeout)
+		}
+	}
+
+	final, appErr := deployments.Get(token, projName, deployment.ID)
+	if appErr != nil {
+		appErr.Handle()
+	}
+
+	return final
+}
+
+func printLogEntry(t time.Time, message string) {
+	tui.Printf("%s %s\n", tui.Blu(t.Local().Format("15:04:05")), message)
+}
+
+func printStateTransition(state string, version int64, errMsg string) {
+	var desc string
+	switch state {
+	case deployments.DeploymentStateBuilding:
+		desc = tr.T("optimizing")
+	case deployments.DeploymentStateDeploying:
+		desc = fmt.Sprintf(tr.T("launching"), version)
+	case deployments.DeploymentStateDeployed:
+		desc = fmt.Sprintf(tr.T("deployment_state_deployed"), version)
+	case deployments.DeploymentStateDeployFailed:
+		desc = fmt.Sprintf(tr.T("deployment_state_failed"), version, errMsg)
+	default:
+		desc = fmt.Sprintf(tr.T("deployment_state_other"), state)
+	}
+
+	printLogEntry(time.Now(), tui.Bold("==> "+desc))
+}
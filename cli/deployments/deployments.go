@@ -0,0 +1,377 @@
+package deployments
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/cli/deploy"
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Names accepted by --state in addition to the states reported by the server
+var stateAliases = map[string][]string{
+	"pending":    {deployments.DeploymentStateBuilding, deployments.DeploymentStateDeploying},
+	"optimizing": {deployments.DeploymentStateBuilding},
+	"deploying":  {deployments.DeploymentStateDeploying},
+	"failed":     {deployments.DeploymentStateDeployFailed},
+}
+
+// Lists all deployments of a project, most recent first, optionally filtered
+// by state
+func List(c *cli.Context) {
+	token := common.RequireAccessToken()
+	proj := common.RequireProject(token)
+
+	filter, err := parseStates(c.String("state"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	depls := []deployments.Deployment{}
+	for _, depl := range all(token, proj.Name) {
+		if filter(&depl) {
+			depls = append(depls, depl)
+		}
+	}
+	sort.Sort(byIDDesc(depls))
+
+	if common.JSONOutput() {
+		common.PrintJSON(map[string]interface{}{
+			"project":     proj.Name,
+			"deployments": depls,
+		})
+		return
+	}
+
+	if len(depls) == 0 {
+		log.Infof(tr.T("deployments_none_found"), proj.Name)
+		return
+	}
+
+	tui.Printf(tui.Undl(tui.Bold(tr.T("deployments_list")))+"\n", proj.Name)
+	tui.Printf(tui.Undl("%-8s %-8s %-12s %-18s %-9s %s")+"\n", "ID", "Version", "State", "Created", "Size", "Deployer")
+	for _, depl := range depls {
+		line := fmt.Sprintf("%-8d %-8s %-12s %-18s %-9s %s", depl.ID, fmt.Sprintf("v%d", depl.Version), stateName(&depl), timeAgo(createdAt(&depl)), size(depl.Size), orDash(depl.Deployer))
+		if depl.Active {
+			line = tui.Bold(line)
+		}
+		tui.Println(line)
+
+		if depl.State == deployments.DeploymentStateDeployFailed && depl.ErrorMessage != "" {
+			for _, msg := range strings.Split(depl.ErrorMessage, "\n") {
+				tui.Println(tui.Red("         " + msg))
+			}
+		}
+	}
+}
+
+// Prints the details of a deployment, given either its ID or its version
+// prefixed with "v"
+func Show(c *cli.Context) {
+	if !c.Args().Present() {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return
+	}
+
+	token := common.RequireAccessToken()
+	proj := common.RequireProject(token)
+
+	arg := c.Args().First()
+
+	var depl *deployments.Deployment
+	if strings.HasPrefix(strings.ToLower(arg), "v") {
+		version, err := ParseVersion(arg)
+		if err != nil {
+			log.Fatalf(tr.T("version_invalid"), arg)
+		}
+
+		depl = byVersion(all(token, proj.Name), version)
+		if depl == nil {
+			log.Fatalf(tr.T("version_not_found"), version, proj.Name)
+		}
+	} else {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			log.Fatalf(tr.T("deployment_id_invalid"), arg)
+		}
+
+		var appErr *apperror.Error
+		depl, appErr = deployments.Get(token, proj.Name, uint(id))
+		if appErr != nil {
+			if appErr.Code == deployments.ErrCodeNotFound {
+				log.Fatalf(tr.T("deployment_not_found"), id)
+			}
+			appErr.Handle()
+		}
+	}
+
+	if common.JSONOutput() {
+		common.PrintJSON(map[string]interface{}{
+			"project":    proj.Name,
+			"deployment": depl,
+		})
+		return
+	}
+
+	tui.Printf(tui.Undl(tui.Bold(tr.T("deployment_show")))+"\n", depl.ID, depl.Version, proj.Name)
+	tui.Printf("%-10s %s\n", "State:", stateName(depl))
+	tui.Printf("%-10s %s\n", "Created:", timestamp(createdAt(depl)))
+	if depl.State == deployments.DeploymentStateDeployed {
+		tui.Printf("%-10s %s\n", "Deployed:", timestamp(depl.DeployedAt))
+	}
+	tui.Printf("%-10s %s\n", "Size:", size(depl.Size))
+	tui.Printf("%-10s %s\n", "Deployer:", orDash(depl.Deployer))
+
+	if depl.ErrorMessage != "" {
+		// Deployments that were published despite optimizer errors keep
+		// them as warnings.
+		label := "Warnings:"
+		if depl.State == deployments.DeploymentStateDeployFailed {
+			label = "Error:"
+		}
+		tui.Println(label)
+		for _, msg := range strings.Split(depl.ErrorMessage, "\n") {
+			tui.Println("  " + msg)
+		}
+	}
+}
+
+// Streams the output of a deployment until it has been deployed or has
+// failed. Defaults to the most recent deployment.
+func Watch(c *cli.Context) {
+	token := common.RequireAccessToken()
+	proj := common.RequireProject(token)
+
+	var depl *deployments.Deployment
+	if c.Args().Present() {
+		id, err := strconv.ParseUint(c.Args().First(), 10, 64)
+		if err != nil {
+			log.Fatalf(tr.T("deployment_id_invalid"), c.Args().First())
+		}
+
+		var appErr *apperror.Error
+		depl, appErr = deployments.Get(token, proj.Name, uint(id))
+		if appErr != nil {
+			if appErr.Code == deployments.ErrCodeNotFound {
+				log.Fatalf(tr.T("deployment_not_found"), id)
+			}
+			appErr.Handle()
+		}
+	} else {
+		depl = latest(all(token, proj.Name))
+		if depl == nil {
+			log.Fatalf(tr.T("no_deployments"), proj.Name)
+		}
+	}
+
+	finish(proj.Name, deploy.StreamDeploymentLogs(token, proj.Name, depl, c.Duration("timeout")))
+}
+
+// Prints the output of a version, following it if it is still in progress.
+// Defaults to the active version, or the most recent deployment if no version
+// is active.
+func Logs(c *cli.Context) {
+	token := common.RequireAccessToken()
+	proj := common.RequireProject(token)
+
+	depls := all(token, proj.Name)
+
+	var depl *deployments.Deployment
+	if c.Args().Present() {
+		version, err := ParseVersion(c.Args().First())
+		if err != nil {
+			log.Fatalf(tr.T("version_invalid"), c.Args().First())
+		}
+
+		depl = byVersion(depls, version)
+		if depl == nil {
+			log.Fatalf(tr.T("version_not_found"), version, proj.Name)
+		}
+	} else {
+		for i := range depls {
+			if depls[i].Active {
+				depl = &depls[i]
+				break
+			}
+		}
+		if depl == nil {
+			depl = latest(depls)
+		}
+		if depl == nil {
+			log.Fatalf(tr.T("no_deployments"), proj.Name)
+		}
+	}
+
+	finish(proj.Name, deploy.StreamDeploymentLogs(token, proj.Name, depl, c.Duration("timeout")))
+}
+
+// Returns the deployment with the given version, such as "v3", or the live one
+// if version is empty. Exits if there is no such deployment.
+func Resolve(token, projName, version string) *deployments.Deployment {
+	depls := all(token, projName)
+
+	if version == "" {
+		for i := range depls {
+			if depls[i].Active {
+				return &depls[i]
+			}
+		}
+		log.Fatalf(tr.T("no_live_version"), projName)
+	}
+
+	v, err := ParseVersion(version)
+	if err != nil {
+		log.Fatalf(tr.T("version_invalid"), version)
+	}
+
+	depl := byVersion(depls, v)
+	if depl == nil {
+		log.Fatalf(tr.T("version_not_found"), v, projName)
+	}
+	return depl
+}
+
+// Parses a version such as "v3" or "3"
+func ParseVersion(s string) (int64, error) {
+	v, err := strconv.ParseInt(strings.TrimPrefix(strings.ToLower(s), "v"), 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid version %q", s)
+	}
+	return v, nil
+}
+
+func all(token, projName string) []deployments.Deployment {
+	depls, appErr := deployments.List(token, projName)
+	if appErr != nil {
+		appErr.Handle()
+	}
+	return depls
+}
+
+// Returns the most recently created deployment
+func latest(depls []deployments.Deployment) *deployments.Deployment {
+	var l *deployments.Deployment
+	for i := range depls {
+		if l == nil || depls[i].ID > l.ID {
+			l = &depls[i]
+		}
+	}
+	return l
+}
+
+func finish(projName string, depl *deployments.Deployment) {
+	if depl.State == deployments.DeploymentStateDeployFailed {
+		common.Exitf(common.ExitBuildFailed, tr.T("deployment_failure"), projName, depl.ErrorMessage)
+	}
+}
+
+func byVersion(depls []deployments.Deployment, version int64) *deployments.Deployment {
+	for i := range depls {
+		if depls[i].Version == version {
+			return &depls[i]
+		}
+	}
+	return nil
+}
+
+// Returns a filter that matches deployments in any of the comma separated
+// states. "live" matches the active deployment.
+func parseStates(s string) (func(*deployments.Deployment) bool, error) {
+	if s == "" {
+		return func(*deployments.Deployment) bool { return true }, nil
+	}
+
+	var (
+		states = map[string]bool{}
+		live   bool
+	)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "live":
+			live = true
+		case deployments.DeploymentStateDeployed, deployments.DeploymentStateBuilding,
+			deployments.DeploymentStateDeploying, deployments.DeploymentStateDeployFailed:
+			states[name] = true
+		default:
+			aliased, ok := stateAliases[name]
+			if !ok {
+				return nil, fmt.Errorf(tr.T("deployments_state_invalid"), name)
+			}
+			for _, state := range aliased {
+				states[state] = true
+			}
+		}
+	}
+
+	return func(d *deployments.Deployment) bool {
+		return states[d.State] || (live && d.Active)
+	}, nil
+}
+
+func stateName(d *deployments.Deployment) string {
+	switch {
+	case d.Active:
+		return "live"
+	case d.State == deployments.DeploymentStateBuilding:
+		return "optimizing"
+	case d.State == deployments.DeploymentStateDeploying:
+		return "deploying"
+	case d.State == deployments.DeploymentStateDeployFailed:
+		return "failed"
+	}
+	return d.State
+}
+
+// Older servers do not report when a deployment was created
+func createdAt(d *deployments.Deployment) time.Time {
+	if d.CreatedAt.IsZero() {
+		return d.DeployedAt
+	}
+	return d.CreatedAt
+}
+
+func timeAgo(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return humanize.Time(t)
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04:05 MST"), humanize.Time(t))
+}
+
+func size(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return humanize.Bytes(uint64(n))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+type byIDDesc []deployments.Deployment
+
+func (d byIDDesc) Len() int           { return len(d) }
+func (d byIDDesc) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byIDDesc) Less(i, j int) bool { return d[i].ID > d[j].ID }
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/cli/deploy"
+	clideployments "github.com/nitrous-io/rise-cli-go/cli/deployments"
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+type file struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type modifiedFile struct {
+	Path    string `json:"path"`
+	OldSize int64  `json:"old_size"`
+	NewSize int64  `json:"new_size"`
+}
+
+// Compares the files that would be published from the current directory with
+// those in the live version, or in the given version
+func Diff(c *cli.Context) {
+	token := common.RequireAccessToken()
+	proj := common.RequireProject(token)
+
+	depl := clideployments.Resolve(token, proj.Name, c.Args().First())
+
+	remote, appErr := deployments.GetManifest(token, proj.Name, depl.ID)
+	if appErr != nil {
+		if appErr.Code == deployments.ErrCodeNotSupported {
+			log.Fatalf(tr.T("diff_manifest_unavailable"), depl.Version)
+		}
+		appErr.Handle()
+	}
+
+	absPath, err := filepath.Abs(proj.Path)
+	util.ExitIfError(err)
+
+	bun := bundle.New(proj.Path)
+	_, _, err = bun.Assemble(deploy.IgnoreList(absPath), false)
+	util.ExitIfError(err)
+
+	local, err := bun.Manifest()
+	util.ExitIfError(err)
+
+	diff := bundle.DiffManifests(remote, local)
+
+	if c.Bool("json") || common.JSONOutput() {
+		printJSON(proj.Name, depl.Version, diff)
+		return
+	}
+
+	if diff.Empty() {
+		log.Infof(tr.T("diff_none"), depl.Version)
+		return
+	}
+
+	if !c.Bool("stat") {
+		tui.Printf(tui.Undl(tui.Bold(tr.T("diff_title")))+"\n", proj.Name, depl.Version)
+		for _, entry := range diff.Added {
+			tui.Printf(tui.Grn("+ %-60s %s")+"\n", entry.Path, humanize.Bytes(uint64(entry.Size)))
+		}
+		for _, entry := range diff.Removed {
+			tui.Printf(tui.Red("- %-60s %s")+"\n", entry.Path, humanize.Bytes(uint64(entry.Size)))
+		}
+		for _, change := range diff.Changed {
+			tui.Printf(tui.Ylo("M %-60s %s -> %s")+"\n", change.To.Path, humanize.Bytes(uint64(change.From.Size)), humanize.Bytes(uint64(change.To.Size)))
+		}
+		tui.Println()
+	}
+
+	printStat(diff)
+}
+
+// Prints the number of added, removed and modified files and how they change
+// the size of the project
+func printStat(diff *bundle.ManifestDiff) {
+	var added, removed, modified int64
+	for _, entry := range diff.Added {
+		added += entry.Size
+	}
+	for _, entry := range diff.Removed {
+		removed += entry.Size
+	}
+	for _, change := range diff.Changed {
+		modified += change.To.Size - change.From.Size
+	}
+
+	tui.Printf(tr.T("diff_stat")+"\n",
+		tui.Grn(fmt.Sprintf("%d added (+%s)", len(diff.Added), humanize.Bytes(uint64(added)))),
+		tui.Red(fmt.Sprintf("%d removed (-%s)", len(diff.Removed), humanize.Bytes(uint64(removed)))),
+		tui.Ylo(fmt.Sprintf("%d modified (%s)", len(diff.Changed), signedBytes(modified))),
+	)
+}
+
+func printJSON(projName string, version int64, diff *bundle.ManifestDiff) {
+	added := []*file{}
+	for _, entry := range diff.Added {
+		added = append(added, &file{entry.Path, entry.Size})
+	}
+	removed := []*file{}
+	for _, entry := range diff.Removed {
+		removed = append(removed, &file{entry.Path, entry.Size})
+	}
+	modified := []*modifiedFile{}
+	for _, change := range diff.Changed {
+		modified = append(modified, &modifiedFile{change.To.Path, change.From.Size, change.To.Size})
+	}
+
+	common.PrintJSON(map[string]interface{}{
+		"project":  projName,
+		"version":  version,
+		"added":    added,
+		"removed":  removed,
+		"modified": modified,
+	})
+}
+
+func signedBytes(n int64) string {
+	if n < 0 {
+		return "-" + humanize.Bytes(uint64(-n))
+	}
+	return "+" + humanize.Bytes(uint64(n))
+}
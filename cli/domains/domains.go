@@ -33,6 +33,17 @@ func List(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		if domainNames == nil {
+			domainNames = []string{}
+		}
+		common.PrintJSON(map[string]interface{}{
+			"project": proj.Name,
+			"domains": domainNames,
+		})
+		return
+	}
+
 	tui.Printf(tui.Undl(tui.Bold(tr.T("domain_list")))+"\n", proj.Name)
 	for _, domainName := range domainNames {
 		tui.Println("- " + domainName)
@@ -48,7 +48,7 @@ func Add(c *cli.Context) {
 		appErr.Handle()
 	}
 
-	deploy.ShowDeploymentProcess(token, proj.Name, deployment)
+	deploy.ShowDeploymentProcess(token, proj.Name, deployment, 0)
 	log.Infof(tr.T("env_set"), output)
 }
 
@@ -68,7 +68,7 @@ func Delete(c *cli.Context) {
 		appErr.Handle()
 	}
 
-	deploy.ShowDeploymentProcess(token, proj.Name, deployment)
+	deploy.ShowDeploymentProcess(token, proj.Name, deployment, 0)
 	log.Infof(tr.T("env_deleted"), strings.Join(c.Args(), ", "))
 }
 
@@ -81,6 +81,18 @@ func List(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		vars := map[string]string{}
+		if envvars != nil {
+			vars = *envvars
+		}
+		common.PrintJSON(map[string]interface{}{
+			"project": proj.Name,
+			"env":     vars,
+		})
+		return
+	}
+
 	if len(*envvars) > 0 {
 		tui.Printf(tui.Undl(tui.Bold(tr.T("env_list_header")))+"\n", proj.Name)
 		for key, value := range *envvars {
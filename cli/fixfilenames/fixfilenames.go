@@ -0,0 +1,132 @@
+package fixfilenames
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/cli/deploy"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/sitecheck"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Renames the files and directories that are left out of bundles because of
+// unsafe characters in their names, and updates the references to them in
+// HTML, CSS and JavaScript files
+func FixFilenames(c *cli.Context) {
+	proj := common.RequireLocalProject()
+
+	absPath, err := filepath.Abs(proj.Path)
+	util.ExitIfError(err)
+
+	renames, err := bundle.PlanSafeRenames(absPath, deploy.IgnoreList(absPath))
+	util.ExitIfError(err)
+
+	if len(renames) == 0 {
+		log.Info(tr.T("fix_filenames_none"))
+		return
+	}
+
+	tui.Println(tui.Undl(tui.Bold(tr.T("fix_filenames_title"))))
+	for _, r := range renames {
+		tui.Printf("%s -> %s\n", filepath.ToSlash(r.From), tui.Grn(filepath.ToSlash(r.To)))
+	}
+	tui.Println()
+
+	if c.Bool("dry-run") {
+		log.Infof(tr.T("fix_filenames_dry_run"), len(renames))
+		return
+	}
+
+	if !c.Bool("yes") {
+		if !readline.Interactive {
+			log.Fatal(tr.T("fix_filenames_needs_yes"))
+		}
+
+		for {
+			answer, err := readline.Read(tui.Bold(fmt.Sprintf(tr.T("fix_filenames_confirm"), len(renames))+"? [y/N] "), true, "n")
+			util.ExitIfErrorOrEOF(err)
+
+			answer = strings.ToLower(answer)
+			if answer == "n" || answer == "no" {
+				log.Info(tr.T("fix_filenames_aborted"))
+				return
+			}
+			if answer == "y" || answer == "yes" {
+				break
+			}
+		}
+	}
+
+	// Directories are planned before their contents, so renaming in reverse
+	// keeps the paths that are still to be renamed valid.
+	for i := len(renames) - 1; i >= 0; i-- {
+		r := renames[i]
+		if err := os.Rename(filepath.Join(absPath, r.From), filepath.Join(absPath, r.To)); err != nil {
+			log.Fatalf(tr.T("fix_filenames_rename_failed"), r.From, err)
+		}
+	}
+	log.Infof(tr.T("fix_filenames_renamed"), len(renames))
+
+	rewriteReferences(proj.Path, absPath, renames)
+}
+
+// Updates the references to renamed files in the HTML, CSS and JavaScript
+// files that are now in the bundle
+func rewriteReferences(projPath, absPath string, renames []*bundle.Rename) {
+	names := map[string]string{}
+	ambiguous := map[string]bool{}
+	for _, r := range renames {
+		from, to := filepath.Base(r.From), filepath.Base(r.To)
+		if prev, ok := names[from]; ok && prev != to {
+			ambiguous[from] = true
+		}
+		names[from] = to
+	}
+	for name := range ambiguous {
+		delete(names, name)
+		log.Warnf(tr.T("fix_filenames_ambiguous"), name)
+	}
+
+	bun := bundle.New(projPath)
+	_, _, err := bun.Assemble(deploy.IgnoreList(absPath), false)
+	util.ExitIfError(err)
+
+	files, refs := 0, 0
+	for _, path := range bun.FileList() {
+		if !sitecheck.Rewritable(path) {
+			continue
+		}
+
+		p := filepath.Join(absPath, path)
+		fi, err := os.Stat(p)
+		util.ExitIfError(err)
+
+		content, err := ioutil.ReadFile(p)
+		util.ExitIfError(err)
+
+		content, n := sitecheck.RewriteReferences(content, names)
+		if n == 0 {
+			continue
+		}
+
+		if err := ioutil.WriteFile(p, content, fi.Mode().Perm()); err != nil {
+			log.Fatalf(tr.T("fix_filenames_write_failed"), path, err)
+		}
+		tui.Printf("%s %s\n", tui.Grn("M"), filepath.ToSlash(path))
+		files++
+		refs += n
+	}
+
+	log.Infof(tr.T("fix_filenames_rewritten"), refs, files)
+}
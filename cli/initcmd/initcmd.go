@@ -85,6 +85,9 @@ func Init(c *cli.Context) {
 	}
 
 	log.Infof(tr.T("project_initialized"), proj.Name)
+	if config.ProfileName != config.DefaultProfileName {
+		proj.Profile = config.ProfileName
+	}
 	if err = proj.Save(); err != nil {
 		log.Fatal(err.Error())
 	}
@@ -167,6 +170,9 @@ func ReInit(c *cli.Context) {
 	}
 
 	log.Infof(tr.T("project_re_initialized"), proj.Name)
+	if config.ProfileName != config.DefaultProfileName {
+		proj.Profile = config.ProfileName
+	}
 	if err = proj.Save(); err != nil {
 		log.Fatal(err.Error())
 	}
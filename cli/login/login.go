@@ -1,6 +1,8 @@
 package login
 
 import (
+	"time"
+
 	"github.com/codegangsta/cli"
 	"github.com/nitrous-io/rise-cli-go/apperror"
 	"github.com/nitrous-io/rise-cli-go/cli/common"
@@ -15,14 +17,45 @@ import (
 	log "github.com/Sirupsen/logrus"
 )
 
+// How long to wait for a login in the browser to be completed
+const WebLoginTimeout = 5 * time.Minute
+
 func Login(c *cli.Context) {
 	var (
 		email string
-		token string
+		token *oauth.Token
 	)
 
+	if config.AccessTokenEnv != "" {
+		log.Fatalf(tr.T("access_token_env_set"), config.AccessTokenEnv)
+	}
+
+	if c.Bool("web") && c.Bool("device") {
+		common.Exit(common.ExitInvalid, tr.T("login_web_device_conflict"))
+	}
+
 	common.PrintLogo()
 	tui.Println(tui.Bold(tr.T("login_rise")) + "\n")
+
+	switch {
+	case c.Bool("web"):
+		email, token = loginWeb()
+	case c.Bool("device"):
+		email, token = loginDevice()
+	default:
+		email, token = loginPassword()
+	}
+
+	config.Email = email
+	config.SetTokens(token.AccessToken, token.RefreshToken, token.ExpiresAt)
+	if err := config.Save(); err != nil {
+		log.Fatal(tr.T("rise_config_write_failed"))
+	}
+	log.Infof(tr.T("login_success"), email)
+}
+
+// Logs in with the email and password of the account
+func loginPassword() (email string, token *oauth.Token) {
 	tui.Println(tr.T("enter_credentials"))
 	for {
 		tui.Println()
@@ -38,46 +71,19 @@ func Login(c *cli.Context) {
 		password, err = readline.ReadSecurely(tui.Bold(tr.T("enter_password")+": "), true, "")
 		util.ExitIfErrorOrEOF(err)
 
-		token, appErr = oauth.FetchToken(email, password)
+		token, appErr = common.FetchToken(email, password)
 
 		if appErr != nil && appErr.Code == oauth.ErrCodeUnconfirmedEmail {
 			log.Info(tr.T("confirmation_required"))
 
-			resendUsed := false
-			for {
-				tui.Println()
-				var prompt string
-				if resendUsed {
-					prompt = tr.T("enter_confirmation")
-				} else {
-					prompt = tr.T("enter_confirmation_resend")
-				}
-				confirmationCode, err := readline.Read(tui.Bold(prompt+": "), true, "")
-				util.ExitIfErrorOrEOF(err)
-
-				if confirmationCode == "resend" && !resendUsed {
-					appErr = users.ResendConfirmationCode(email)
-					if appErr == nil {
-						resendUsed = true
-						log.Info(tr.T("confirmation_resent"))
-						continue
-					}
-				} else {
-					appErr = users.Confirm(email, confirmationCode)
-					if appErr == nil {
-						break
-					}
-				}
-
-				appErr.Handle()
-			}
+			common.ConfirmEmail(email)
 			log.Info(tr.T("confirmation_success"))
 			tui.Println()
 
-			token, appErr = oauth.FetchToken(email, password)
+			token, appErr = common.FetchToken(email, password)
 		}
 
-		if token != "" {
+		if token != nil {
 			break
 		}
 
@@ -92,10 +98,74 @@ func Login(c *cli.Context) {
 		}
 	}
 
-	config.Email = email
-	config.AccessToken = token
-	if err := config.Save(); err != nil {
-		log.Fatal(tr.T("rise_config_write_failed"))
+	return email, token
+}
+
+// Logs in through the browser, which is redirected back to the CLI
+func loginWeb() (email string, token *oauth.Token) {
+	w, appErr := oauth.StartWebLogin()
+	if appErr != nil {
+		appErr.Handle()
+		util.ExitSomethingWentWrong()
 	}
-	log.Infof(tr.T("login_success"), email)
+
+	tui.Printf(tr.T("login_web_open")+"\n\n", tui.Undl(w.URL))
+	if err := openBrowser(w.URL); err != nil {
+		log.Debugf("Failed to open browser: %v", err)
+	}
+	log.Info(tr.T("login_web_waiting"))
+
+	token, appErr = w.Wait(WebLoginTimeout)
+	if appErr != nil {
+		exitLoginFailed(appErr)
+	}
+
+	return accountEmail(token.AccessToken), token
+}
+
+// Logs in with a code that is entered on another device, for machines without
+// a browser
+func loginDevice() (email string, token *oauth.Token) {
+	dc, appErr := oauth.RequestDeviceCode()
+	if appErr != nil {
+		exitLoginFailed(appErr)
+	}
+
+	tui.Printf(tr.T("login_device_visit")+"\n\n", tui.Undl(dc.VerificationURI))
+	tui.Printf("    %s\n\n", tui.Bold(dc.UserCode))
+	if dc.VerificationURIComplete != "" {
+		tui.Printf(tr.T("login_device_visit_complete")+"\n\n", tui.Undl(dc.VerificationURIComplete))
+	}
+	log.Info(tr.T("login_device_waiting"))
+
+	token, appErr = oauth.WaitForDeviceToken(dc)
+	if appErr != nil {
+		exitLoginFailed(appErr)
+	}
+
+	return accountEmail(token.AccessToken), token
+}
+
+// Returns the email address of the account that token belongs to
+func accountEmail(token string) string {
+	user, appErr := users.Show(token)
+	if appErr != nil {
+		appErr.Handle()
+		util.ExitSomethingWentWrong()
+	}
+	return user.Email
+}
+
+func exitLoginFailed(appErr *apperror.Error) {
+	switch appErr.Code {
+	case oauth.ErrCodeOAuthMisconfigured:
+		log.Fatal(tr.T("oauth_misconfigured"))
+	case oauth.ErrCodeAccessDenied:
+		common.Exit(common.ExitAuthFailed, tr.T("login_access_denied"))
+	case oauth.ErrCodeTimedOut, oauth.ErrCodeExpiredToken:
+		common.Exit(common.ExitAuthFailed, tr.T("login_code_expired"))
+	}
+
+	appErr.Handle()
+	common.Exit(common.ExitAuthFailed, tr.T("login_fail"))
 }
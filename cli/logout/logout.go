@@ -11,6 +11,10 @@ import (
 )
 
 func Logout(c *cli.Context) {
+	if config.AccessTokenEnv != "" {
+		log.Fatalf(tr.T("access_token_env_set"), config.AccessTokenEnv)
+	}
+
 	token := common.RequireAccessToken()
 
 	if appErr := oauth.InvalidateToken(token); appErr != nil {
@@ -20,7 +24,7 @@ func Logout(c *cli.Context) {
 	}
 
 	config.Email = ""
-	config.AccessToken = ""
+	config.ClearTokens()
 	if err := config.Save(); err != nil {
 		log.Fatal(tr.T("rise_config_write_failed"))
 	}
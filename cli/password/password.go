@@ -5,7 +5,6 @@ import (
 
 	"github.com/codegangsta/cli"
 	"github.com/nitrous-io/rise-cli-go/cli/common"
-	"github.com/nitrous-io/rise-cli-go/client/oauth"
 	"github.com/nitrous-io/rise-cli-go/client/password"
 	"github.com/nitrous-io/rise-cli-go/client/users"
 	"github.com/nitrous-io/rise-cli-go/config"
@@ -49,6 +48,12 @@ func Change(c *cli.Context) {
 		}
 
 		appErr := users.ChangePassword(config.AccessToken, existingPassword, password)
+		for appErr != nil && (appErr.Code == users.ErrCodeOTPRequired || appErr.Code == users.ErrCodeInvalidOTP) {
+			if appErr.Code == users.ErrCodeInvalidOTP {
+				appErr.Print()
+			}
+			appErr = users.ChangePasswordWithOTP(config.AccessToken, existingPassword, password, common.ReadOTP())
+		}
 		if appErr == nil {
 			break
 		}
@@ -66,8 +71,8 @@ func Change(c *cli.Context) {
 		util.ExitIfErrorOrEOF(err)
 	}
 
-	token, appErr := oauth.FetchToken(email, password)
-	if token == "" {
+	token, appErr := common.FetchToken(email, password)
+	if token == nil {
 		log.Error(tr.T("login_fail"))
 
 		if appErr != nil {
@@ -76,7 +81,7 @@ func Change(c *cli.Context) {
 	}
 
 	config.Email = email
-	config.AccessToken = token
+	config.SetTokens(token.AccessToken, token.RefreshToken, token.ExpiresAt)
 	config.Save()
 	log.Infof(tr.T("login_success"), config.Email)
 }
@@ -145,7 +150,7 @@ func Reset(c *cli.Context) {
 	}
 
 	// Remove saved access token, if any, so that user will have to login again.
-	config.AccessToken = ""
+	config.ClearTokens()
 	config.Save()
 
 	log.Infof(tui.Grn(tr.T("password_reset_success")))
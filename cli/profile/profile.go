@@ -0,0 +1,163 @@
+package profile
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+func List(c *cli.Context) {
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if common.JSONOutput() {
+		type profile struct {
+			Name          string `json:"name"`
+			Host          string `json:"host"`
+			Email         string `json:"email"`
+			DefaultDomain string `json:"default_domain"`
+			Selected      bool   `json:"selected"`
+			Active        bool   `json:"active"`
+		}
+
+		profiles := []*profile{}
+		for _, name := range names {
+			p := config.Profiles[name]
+			profiles = append(profiles, &profile{
+				Name:          name,
+				Host:          p.HostOrDefault(),
+				Email:         p.Email,
+				DefaultDomain: p.DefaultDomainOrDefault(),
+				Selected:      name == config.SelectedProfile,
+				Active:        name == config.ProfileName,
+			})
+		}
+		common.PrintJSON(map[string]interface{}{"profiles": profiles})
+		return
+	}
+
+	tui.Println(tui.Undl(tui.Bold(tr.T("profile_list_header"))))
+	for _, name := range names {
+		p := config.Profiles[name]
+
+		email := p.Email
+		if email == "" {
+			email = tr.T("profile_not_logged_in")
+		}
+
+		output := "  " + name
+		if name == config.ProfileName {
+			output = tui.Bold("* " + name)
+		}
+		tui.Printf("%s (%s, %s)\n", output, email, p.HostOrDefault())
+	}
+}
+
+func Add(c *cli.Context) {
+	name := strings.TrimSpace(c.Args().First())
+
+	var err error
+	for {
+		if name == "" {
+			name, err = readline.Read(tui.Bold(tr.T("profile_enter_name")+": "), true, "")
+			util.ExitIfErrorOrEOF(err)
+		}
+
+		if err := config.ValidateProfileName(name); err != nil {
+			log.Error(err.Error())
+			name = ""
+			continue
+		}
+
+		if _, exists := config.Profiles[name]; exists {
+			log.Errorf(tr.T("profile_already_exists"), name)
+			name = ""
+			continue
+		}
+
+		break
+	}
+
+	host := strings.TrimSpace(c.String("host"))
+	if host == "" {
+		def := config.Profiles[config.DefaultProfileName].HostOrDefault()
+		host, err = readline.Read(tui.Bold(tr.T("profile_enter_host")+": ["+def+"] "), true, def)
+		util.ExitIfErrorOrEOF(err)
+	}
+	host = strings.TrimRight(host, "/")
+
+	// Leave the host empty if it is the default, so that it follows the host
+	// the CLI was built with.
+	if host == config.Profiles[config.DefaultProfileName].HostOrDefault() {
+		host = ""
+	}
+
+	config.Profiles[name] = &config.Profile{
+		Host:          host,
+		DefaultDomain: util.SanitizeDomain(c.String("default-domain")),
+	}
+	if err := config.Save(); err != nil {
+		log.Fatal(tr.T("rise_config_write_failed"))
+	}
+
+	log.Infof(tr.T("profile_added"), name, name)
+}
+
+func Use(c *cli.Context) {
+	name := requireProfileName(c)
+
+	config.SelectedProfile = name
+	if err := config.Save(); err != nil {
+		log.Fatal(tr.T("rise_config_write_failed"))
+	}
+
+	log.Infof(tr.T("profile_selected"), name)
+}
+
+func Remove(c *cli.Context) {
+	name := requireProfileName(c)
+
+	if name == config.DefaultProfileName {
+		log.Fatal(tr.T("profile_rm_default"))
+	}
+
+	delete(config.Profiles, name)
+	if err := config.DeleteAccessToken(name); err != nil {
+		common.DebugLog().Warnf("failed to remove access token of profile %s, err: %v", name, err)
+	}
+	if config.SelectedProfile == name {
+		config.SelectedProfile = config.DefaultProfileName
+	}
+	if err := config.Save(); err != nil {
+		log.Fatal(tr.T("rise_config_write_failed"))
+	}
+
+	log.Infof(tr.T("profile_removed"), name)
+}
+
+func requireProfileName(c *cli.Context) string {
+	name := strings.TrimSpace(c.Args().First())
+	if name == "" {
+		var err error
+		name, err = readline.Read(tui.Bold(tr.T("profile_enter_name")+": "), true, "")
+		util.ExitIfErrorOrEOF(err)
+	}
+
+	if _, exists := config.Profiles[name]; !exists {
+		log.Fatalf(tr.T("profile_not_found"), name)
+	}
+
+	return name
+}
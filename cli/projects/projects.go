@@ -26,6 +26,21 @@ func List(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		names := func(projs []*project.Project) []string {
+			n := make([]string, 0, len(projs))
+			for _, proj := range projs {
+				n = append(n, proj.Name)
+			}
+			return n
+		}
+		common.PrintJSON(map[string]interface{}{
+			"projects":        names(projs),
+			"shared_projects": names(sharedProjs),
+		})
+		return
+	}
+
 	if len(projs)+len(sharedProjs) == 0 {
 		log.Info(tr.T("no_project"))
 		return
@@ -0,0 +1,103 @@
+package pull
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	clideployments "github.com/nitrous-io/rise-cli-go/cli/deployments"
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Downloads the bundle of the live version, or of the given version, and
+// extracts it into a directory
+func Pull(c *cli.Context) {
+	token := common.RequireAccessToken()
+	proj := common.RequireProject(token)
+
+	depl := clideployments.Resolve(token, proj.Name, c.Args().Get(0))
+
+	dir := c.Args().Get(1)
+	if dir == "" {
+		dir = fmt.Sprintf("%s-v%d", proj.Name, depl.Version)
+	}
+	if !c.Bool("force") && !isEmptyDir(dir) {
+		log.Fatalf(tr.T("pull_dir_not_empty"), dir)
+	}
+
+	kind := deployments.BundleRaw
+	if c.Bool("optimized") {
+		kind = deployments.BundleOptimized
+	}
+
+	dl, appErr := deployments.GetBundleDownload(token, proj.Name, depl.ID, kind)
+	if appErr != nil {
+		switch appErr.Code {
+		case deployments.ErrCodeBundleNotFound:
+			log.Fatalf(tr.T("pull_bundle_not_found"), kind, depl.Version)
+		case deployments.ErrCodeNotSupported:
+			log.Fatal(tr.T("pull_not_supported"))
+		}
+		appErr.Handle()
+	}
+
+	tempDir, err := ioutil.TempDir("", "rise-pull")
+	util.ExitIfError(err)
+	defer os.RemoveAll(tempDir)
+
+	// Deferred calls do not run on exit, so the temporary directory is removed
+	// before exiting with an error
+	exitf := func(format string, args ...interface{}) {
+		os.RemoveAll(tempDir)
+		common.Exitf(common.ExitError, format, args...)
+	}
+
+	bunPath := filepath.Join(tempDir, "bundle.tar.gz")
+
+	tui.Printf(tr.T("pull_downloading")+"\n", kind, depl.Version, proj.Name, humanize.Bytes(uint64(dl.Size)))
+	if appErr := deployments.DownloadBundle(dl, bunPath, false); appErr != nil {
+		switch appErr.Code {
+		case deployments.ErrCodeChecksumMismatch:
+			exitf("%s", tr.T("pull_checksum_mismatch"))
+		case deployments.ErrCodeChecksumMissing:
+			exitf("%s", tr.T("pull_checksum_missing"))
+		}
+		exitf("%s", appErr.Error())
+	}
+
+	count, err := bundle.Extract(bunPath, dir)
+	if err != nil {
+		exitf(tr.T("pull_extract_failed"), dir, err)
+	}
+
+	log.Infof(tr.T("pull_success"), depl.Version, proj.Name, humanize.Comma(int64(count)), dir)
+}
+
+// Returns true if dir does not exist or has nothing in it
+func isEmptyDir(dir string) bool {
+	f, err := os.Open(dir)
+	if os.IsNotExist(err) {
+		return true
+	}
+	util.ExitIfError(err)
+	defer f.Close()
+
+	fi, err := f.Stat()
+	util.ExitIfError(err)
+	if !fi.IsDir() {
+		return false
+	}
+
+	names, err := f.Readdirnames(1)
+	return len(names) == 0 && err != nil
+}
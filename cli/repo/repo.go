@@ -87,6 +87,10 @@ func Info(c *cli.Context) {
 	repo, appErr := repos.Info(token, proj.Name)
 	if appErr != nil {
 		if appErr.Code == repos.ErrCodeNotLinked {
+			if common.JSONOutput() {
+				common.PrintJSON(map[string]interface{}{"project": proj.Name, "repo": nil})
+				return
+			}
 			log.Errorf(tr.T("project_not_linked"), proj.Name)
 			return
 		}
@@ -94,6 +98,11 @@ func Info(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		common.PrintJSON(map[string]interface{}{"project": proj.Name, "repo": repo})
+		return
+	}
+
 	log.Infof(tr.T("linked_repo_info_repo"), proj.Name, tui.Undl(repo.URI), repo.Branch)
 	printWebhookInstructions(repo)
 }
@@ -1,41 +1,81 @@
 package rollback
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/nitrous-io/rise-cli-go/bundle"
 	"github.com/nitrous-io/rise-cli-go/cli/common"
 	"github.com/nitrous-io/rise-cli-go/client/deployments"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
 	"github.com/nitrous-io/rise-cli-go/pkg/spinner"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
 
 	log "github.com/Sirupsen/logrus"
 )
 
+// At most this many added, removed or changed paths are listed before asking
+// for confirmation
+const MaxListedChanges = 30
+
+// Matches relative targets such as "HEAD~2". "HEAD~" means "HEAD~1".
+var relativeTargetRe = regexp.MustCompile(`(?i)^head~(\d*)$`)
+
 func Rollback(c *cli.Context) {
 	token := common.RequireAccessToken()
 	proj := common.RequireProject(token)
 
-	var (
-		verStr = c.Args().First()
-		ver    int64
-		err    error
-	)
+	depls, appErr := deployments.List(token, proj.Name)
+	if appErr != nil {
+		appErr.Handle()
+	}
 
-	if len(verStr) > 1 {
-		ver, err = strconv.ParseInt(verStr[1:], 10, 64)
+	active, target, err := resolveTarget(c.Args().First(), depls)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if verStr != "" && (verStr[0] != 'v' || err != nil) {
-		log.Error(tr.T("rollback_invalid_version"))
+	printChanges(token, proj.Name, active, target)
+
+	if c.Bool("dry-run") {
+		log.Infof(tr.T("rollback_dry_run"), proj.Name, target.Version)
 		return
 	}
 
-	deployment, appErr := deployments.Rollback(config.AccessToken, proj.Name, ver)
+	if !c.Bool("yes") {
+		if !readline.Interactive {
+			log.Fatal(tr.T("rollback_needs_yes"))
+		}
+
+		for {
+			answer, err := readline.Read(tui.Bold(fmt.Sprintf(tr.T("rollback_confirm"), proj.Name, target.Version)+"? [y/N] "), true, "n")
+			util.ExitIfErrorOrEOF(err)
+
+			answer = strings.ToLower(answer)
+			if answer == "n" || answer == "no" {
+				log.Info(tr.T("rollback_aborted"))
+				return
+			}
+			if answer == "y" || answer == "yes" {
+				break
+			}
+		}
+	}
+
+	deployment, appErr := deployments.Rollback(token, proj.Name, target.Version)
 	if appErr != nil {
+		if appErr.Code == deployments.ErrCodeProjectLocked {
+			common.Exit(common.ExitProjectLocked, appErr.Error())
+		}
 		appErr.Handle()
 	}
 
@@ -48,7 +88,7 @@ func Rollback(c *cli.Context) {
 			tui.Printf(tui.Blu("\b%s"), string(spin.Next()))
 		}
 
-		deployment, appErr = deployments.Get(config.AccessToken, proj.Name, deployment.ID)
+		deployment, appErr = deployments.Get(token, proj.Name, deployment.ID)
 		if appErr != nil {
 			appErr.Handle()
 		}
@@ -57,3 +97,157 @@ func Rollback(c *cli.Context) {
 	tui.Println("\b \b")
 	log.Infof(tr.T("rollback_success"), proj.Name, deployment.Version)
 }
+
+// Finds the active deployment and the one to roll back to. arg is either a
+// version such as "v3", "previous" or a target relative to the active version
+// such as "HEAD~2". Defaults to the previous version.
+func resolveTarget(arg string, depls []deployments.Deployment) (active, target *deployments.Deployment, err error) {
+	// Only versions that were deployed successfully can be rolled back to.
+	var completed []*deployments.Deployment
+	for i := range depls {
+		if depls[i].State == deployments.DeploymentStateDeployed {
+			completed = append(completed, &depls[i])
+		}
+	}
+	sort.Sort(byVersionDesc(completed))
+
+	activeIdx := -1
+	for i, depl := range completed {
+		if depl.Active {
+			activeIdx = i
+			break
+		}
+	}
+	if activeIdx == -1 {
+		return nil, nil, errors.New(tr.T("rollback_no_active_deployment"))
+	}
+	active = completed[activeIdx]
+
+	steps := 1
+	switch lower := strings.ToLower(arg); {
+	case lower == "" || lower == "previous":
+	case lower == "head":
+		steps = 0
+	case relativeTargetRe.MatchString(arg):
+		if n := relativeTargetRe.FindStringSubmatch(arg)[1]; n != "" {
+			if steps, err = strconv.Atoi(n); err != nil {
+				return nil, nil, errors.New(tr.T("rollback_invalid_version"))
+			}
+		}
+	case strings.HasPrefix(lower, "v"):
+		version, err := strconv.ParseInt(lower[1:], 10, 64)
+		if err != nil {
+			return nil, nil, errors.New(tr.T("rollback_invalid_version"))
+		}
+		if version == active.Version {
+			return nil, nil, fmt.Errorf(tr.T("rollback_version_already_active"), version)
+		}
+		for _, depl := range completed {
+			if depl.Version == version {
+				return active, depl, nil
+			}
+		}
+		return nil, nil, fmt.Errorf(tr.T("rollback_version_not_found"), version)
+	default:
+		return nil, nil, errors.New(tr.T("rollback_invalid_version"))
+	}
+
+	if steps == 0 {
+		return nil, nil, fmt.Errorf(tr.T("rollback_version_already_active"), active.Version)
+	}
+	if activeIdx+steps >= len(completed) {
+		if activeIdx+1 >= len(completed) {
+			return nil, nil, errors.New(tr.T("rollback_no_previous_version"))
+		}
+		return nil, nil, fmt.Errorf(tr.T("rollback_not_enough_versions"), arg, len(completed)-activeIdx-1, active.Version)
+	}
+
+	return active, completed[activeIdx+steps], nil
+}
+
+// Prints how the target deployment differs from the active one, listing the
+// files that would change if the server keeps manifests of both
+func printChanges(token, projName string, active, target *deployments.Deployment) {
+	activeManifest := manifest(token, projName, active)
+	targetManifest := manifest(token, projName, target)
+
+	tui.Printf(tui.Undl(tui.Bold(tr.T("rollback_summary")))+"\n", projName, active.Version, target.Version)
+	tui.Printf("%-10s %-28s %s\n", "", fmt.Sprintf("v%d (live)", active.Version), fmt.Sprintf("v%d", target.Version))
+	tui.Printf("%-10s %-28s %s\n", "Deployed:", deployedAt(active), deployedAt(target))
+	tui.Printf("%-10s %-28s %s\n", "Files:", files(active, activeManifest), files(target, targetManifest))
+
+	if activeManifest == nil || targetManifest == nil {
+		tui.Println()
+		log.Info(tr.T("rollback_manifests_unavailable"))
+		tui.Println()
+		return
+	}
+
+	diff := bundle.DiffManifests(activeManifest, targetManifest)
+
+	tui.Println()
+	if diff.Empty() {
+		log.Info(tr.T("rollback_no_file_changes"))
+		tui.Println()
+		return
+	}
+
+	tui.Printf(tr.T("rollback_file_changes")+"\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+
+	listed := 0
+	list := func(line string) {
+		if listed < MaxListedChanges {
+			tui.Println(line)
+		}
+		listed++
+	}
+	for _, entry := range diff.Added {
+		list(tui.Grn("  + " + entry.Path))
+	}
+	for _, entry := range diff.Removed {
+		list(tui.Red("  - " + entry.Path))
+	}
+	for _, change := range diff.Changed {
+		list(tui.Ylo("  ~ " + change.To.Path))
+	}
+	if listed > MaxListedChanges {
+		tui.Printf("  "+tr.T("rollback_more_changes")+"\n", listed-MaxListedChanges)
+	}
+	tui.Println()
+}
+
+// Returns nil if the manifest of the deployment is not available
+func manifest(token, projName string, depl *deployments.Deployment) []*bundle.ManifestEntry {
+	m, appErr := deployments.GetManifest(token, projName, depl.ID)
+	if appErr != nil {
+		if appErr.Code == deployments.ErrCodeNotSupported {
+			return nil
+		}
+		appErr.Handle()
+	}
+	return m
+}
+
+func deployedAt(depl *deployments.Deployment) string {
+	if depl.DeployedAt.IsZero() {
+		return "-"
+	}
+	return humanize.Time(depl.DeployedAt)
+}
+
+func files(depl *deployments.Deployment, manifest []*bundle.ManifestEntry) string {
+	if manifest != nil {
+		count, size := bundle.ManifestSize(manifest)
+		return fmt.Sprintf("%s (%s)", humanize.Comma(int64(count)), humanize.Bytes(uint64(size)))
+	}
+	if depl.Size > 0 {
+		return fmt.Sprintf("? (%s)", humanize.Bytes(uint64(depl.Size)))
+	}
+	return "-"
+}
+
+type byVersionDesc []*deployments.Deployment
+
+func (d byVersionDesc) Len() int           { return len(d) }
+func (d byVersionDesc) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byVersionDesc) Less(i, j int) bool { return d[i].Version > d[j].Version }
@@ -0,0 +1,128 @@
+package serve
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/cli/deploy"
+	"github.com/nitrous-io/rise-cli-go/client/jsenvvars"
+	"github.com/nitrous-io/rise-cli-go/client/projects"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/preview"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// How often the project path is scanned for changes
+const WatchInterval = time.Second
+
+func Serve(c *cli.Context) {
+	offline := c.Bool("offline")
+
+	var token string
+	if !offline {
+		token = common.RequireAccessToken()
+	}
+	proj := common.RequireLocalProject()
+
+	absPath, err := filepath.Abs(proj.Path)
+	util.ExitIfError(err)
+
+	srv := preview.New(absPath, deploy.IgnoreList(absPath))
+	srv.LiveReload = !c.Bool("no-reload")
+
+	if !offline {
+		apiProj, appErr := projects.Get(token, proj.Name)
+		if appErr != nil {
+			if appErr.Code == projects.ErrCodeNotFound {
+				log.Fatalf(tr.T("project_not_found"), proj.Name)
+			}
+			appErr.Handle()
+		}
+
+		envVars, appErr := jsenvvars.List(token, proj.Name)
+		if appErr != nil {
+			appErr.Handle()
+		}
+		srv.JSEnvVars = map[string]string{}
+		if envVars != nil {
+			srv.JSEnvVars = *envVars
+		}
+
+		srv.ForceHTTPS = apiProj.ForceHTTPS
+		if apiProj.BasicAuthUsername != "" {
+			password := c.String("password")
+			if password == "" {
+				tui.Printf(tr.T("serve_protected")+"\n", apiProj.BasicAuthUsername)
+				password, err = readline.ReadSecurely(tui.Bold(tr.T("enter_basic_auth_password")+": "), true, "")
+				util.ExitIfErrorOrEOF(err)
+			}
+			srv.BasicAuthUsername = apiProj.BasicAuthUsername
+			srv.BasicAuthPassword = password
+		}
+	}
+
+	if _, err := srv.Scan(); err != nil {
+		log.Fatalf(tr.T("serve_scan_failed"), err)
+	}
+
+	host := c.String("host")
+	port := c.Int("port")
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+
+	if srv.ForceHTTPS {
+		srv.HTTPSPort = c.Int("https-port")
+		url = "https://" + net.JoinHostPort(host, strconv.Itoa(srv.HTTPSPort))
+
+		cert, err := preview.SelfSignedCert()
+		util.ExitIfError(err)
+
+		ln, err := tls.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(srv.HTTPSPort)), &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		})
+		if err != nil {
+			log.Fatalf(tr.T("serve_listen_failed"), srv.HTTPSPort, err)
+		}
+		go func() {
+			util.ExitIfError(http.Serve(ln, srv))
+		}()
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		log.Fatalf(tr.T("serve_listen_failed"), port, err)
+	}
+
+	if srv.LiveReload {
+		go srv.Watch(WatchInterval, nil, func(err error) {
+			if err != nil {
+				log.Warnf(tr.T("serve_scan_failed"), err)
+				return
+			}
+			tui.Printf("%s %s\n", tui.Blu(time.Now().Format("15:04:05")), tr.T("serve_reloaded"))
+		})
+	}
+
+	tui.Printf(tr.T("serve_listening")+"\n", proj.Name, tui.Undl(url))
+	if srv.JSEnvVars != nil {
+		tui.Printf(tr.T("serve_env_vars")+"\n", len(srv.JSEnvVars), preview.JSEnvVarsPath)
+	}
+	if srv.ForceHTTPS {
+		tui.Println(tr.T("serve_force_https"))
+	}
+	if offline {
+		tui.Println(tr.T("serve_offline"))
+	}
+	tui.Println(tr.T("serve_stop"))
+
+	util.ExitIfError(http.Serve(ln, srv))
+}
@@ -3,7 +3,6 @@ package signup
 import (
 	"github.com/codegangsta/cli"
 	"github.com/nitrous-io/rise-cli-go/cli/common"
-	"github.com/nitrous-io/rise-cli-go/client/oauth"
 	"github.com/nitrous-io/rise-cli-go/client/users"
 	"github.com/nitrous-io/rise-cli-go/config"
 	"github.com/nitrous-io/rise-cli-go/pkg/readline"
@@ -72,8 +71,8 @@ func Signup(c *cli.Context) {
 	log.Info(tr.T("confirmation_success"))
 	tui.Println()
 
-	token, appErr := oauth.FetchToken(email, password)
-	if token == "" {
+	token, appErr := common.FetchToken(email, password)
+	if token == nil {
 		log.Error(tr.T("login_fail"))
 
 		if appErr != nil {
@@ -82,7 +81,7 @@ func Signup(c *cli.Context) {
 	}
 
 	config.Email = email
-	config.AccessToken = token
+	config.SetTokens(token.AccessToken, token.RefreshToken, token.ExpiresAt)
 	config.Save()
 	log.Infof(tr.T("login_success"), email)
 }
@@ -138,6 +138,10 @@ func Info(c *cli.Context) {
 		case certs.ErrCodeProjectNotFound:
 			log.Fatalf(tr.T("project_not_found"), proj.Name)
 		case certs.ErrCodeNotFound:
+			if common.JSONOutput() {
+				common.PrintJSON(map[string]interface{}{"domain": domainName, "cert": nil})
+				return
+			}
 			log.Infof(tr.T("ssl_cert_not_found"), domainName)
 			return
 		}
@@ -145,6 +149,11 @@ func Info(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		common.PrintJSON(map[string]interface{}{"domain": domainName, "cert": ct})
+		return
+	}
+
 	tui.Printf(tui.Undl(tui.Bold(tr.T("ssl_cert_details")+":"))+"\n", domainName)
 	tui.Println(tr.T("ssl_cert_common_name") + ": " + ct.CommonName)
 	tui.Println(tr.T("ssl_cert_issuer") + ": " + ct.Issuer)
@@ -0,0 +1,136 @@
+package tokens
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/dustin/go-humanize"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/client/tokens"
+	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+func List(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	toks, appErr := tokens.List(token)
+	if appErr != nil {
+		appErr.Handle()
+	}
+
+	if common.JSONOutput() {
+		if toks == nil {
+			toks = []*tokens.Token{}
+		}
+		common.PrintJSON(map[string]interface{}{"tokens": toks})
+		return
+	}
+
+	if len(toks) == 0 {
+		log.Info(tr.T("no_tokens"))
+		return
+	}
+
+	tui.Println(tui.Bold(tr.T("token_list_header")))
+	tui.Printf(tui.Undl("%-8s %-24s %-20s %-20s\n"), "ID", "Name", "Created", "Last Used")
+	for _, tok := range toks {
+		lastUsed := tr.T("token_never_used")
+		if tok.LastUsedAt != nil {
+			lastUsed = humanize.Time(*tok.LastUsedAt)
+		}
+		tui.Printf("%-8d %-24s %-20s %-20s\n", tok.ID, tok.Name, humanize.Time(tok.CreatedAt), lastUsed)
+	}
+}
+
+func Create(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	name := strings.TrimSpace(c.Args().First())
+
+	var err error
+	interactive := name == ""
+
+	for {
+		if interactive {
+			name, err = readline.Read(tui.Bold(tr.T("token_enter_name")+": "), true, "")
+			util.ExitIfErrorOrEOF(err)
+		}
+
+		tok, appErr := tokens.Create(token, name)
+		if appErr != nil {
+			if appErr.Code == tokens.ErrCodeValidationFailed {
+				log.Error(appErr.Description)
+				if interactive {
+					continue
+				}
+				log.Fatal(tr.T("error_in_input"))
+			}
+			appErr.Handle()
+		}
+
+		if common.JSONOutput() {
+			common.PrintJSON(tok)
+			return
+		}
+
+		log.Infof(tr.T("token_created"), tok.Name)
+		tui.Println(tui.Bold(tok.Token))
+		log.Warn(tr.T("token_shown_once"))
+		return
+	}
+}
+
+func Revoke(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	idOrName := strings.TrimSpace(c.Args().First())
+	if idOrName == "" {
+		var err error
+		idOrName, err = readline.Read(tui.Bold(tr.T("token_enter_id")+": "), true, "")
+		util.ExitIfErrorOrEOF(err)
+	}
+
+	tok := findToken(token, idOrName)
+	if tok == nil {
+		log.Fatalf(tr.T("token_not_found"), idOrName)
+	}
+
+	if appErr := tokens.Revoke(token, tok.ID); appErr != nil {
+		if appErr.Code == tokens.ErrCodeNotFound {
+			log.Fatalf(tr.T("token_not_found"), idOrName)
+		}
+		appErr.Handle()
+	}
+
+	log.Infof(tr.T("token_revoked_success"), tok.Name)
+}
+
+// Looks up a token by its ID, or failing that, by its name
+func findToken(token, idOrName string) *tokens.Token {
+	toks, appErr := tokens.List(token)
+	if appErr != nil {
+		appErr.Handle()
+	}
+
+	if id, err := strconv.ParseUint(idOrName, 10, 0); err == nil {
+		for _, tok := range toks {
+			if tok.ID == uint(id) {
+				return tok
+			}
+		}
+	}
+
+	for _, tok := range toks {
+		if tok.Name == idOrName {
+			return tok
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package twofactor
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/nitrous-io/rise-cli-go/cli/common"
+	"github.com/nitrous-io/rise-cli-go/client/twofactor"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+	"github.com/skip2/go-qrcode"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Enables two-factor authentication: shows the secret to add to an
+// authenticator app, verifies a code from the app and prints the recovery
+// codes
+func Enable(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	setup, appErr := twofactor.Begin(token)
+	if appErr != nil {
+		if appErr.Code == twofactor.ErrCodeAlreadyEnabled {
+			log.Info(tr.T("2fa_already_enabled"))
+			return
+		}
+		appErr.Handle()
+		util.ExitSomethingWentWrong()
+	}
+
+	tui.Println(tui.Undl(tui.Bold(tr.T("2fa_enable_title"))))
+	tui.Println(tr.T("2fa_scan_qr"))
+	tui.Println()
+	printQRCode(setup.ProvisioningURI)
+	tui.Println(tr.T("2fa_enter_secret"))
+	tui.Printf("    %s\n\n", tui.Bold(groupSecret(setup.Secret)))
+
+	var recoveryCodes []string
+	for {
+		recoveryCodes, appErr = twofactor.Enable(token, common.ReadOTP())
+		if appErr == nil {
+			break
+		}
+		if appErr.Code != twofactor.ErrCodeInvalidCode {
+			appErr.Handle()
+			util.ExitSomethingWentWrong()
+		}
+		appErr.Print()
+	}
+
+	log.Info(tr.T("2fa_enabled"))
+	tui.Println()
+	tui.Println(tui.Bold(tr.T("2fa_recovery_codes")))
+	for _, code := range recoveryCodes {
+		tui.Printf("    %s\n", code)
+	}
+	tui.Println()
+	log.Warn(tr.T("2fa_recovery_codes_warning"))
+}
+
+// Disables two-factor authentication, with a code from the authenticator app
+// or a recovery code
+func Disable(c *cli.Context) {
+	token := common.RequireAccessToken()
+
+	tui.Println(tr.T("2fa_disable_info"))
+	for {
+		appErr := twofactor.Disable(token, common.ReadOTP())
+		if appErr == nil {
+			break
+		}
+
+		switch appErr.Code {
+		case twofactor.ErrCodeNotEnabled:
+			log.Info(tr.T("2fa_not_enabled"))
+			return
+		case twofactor.ErrCodeInvalidCode:
+			appErr.Print()
+			continue
+		}
+		appErr.Handle()
+		util.ExitSomethingWentWrong()
+	}
+
+	log.Info(tr.T("2fa_disabled"))
+}
+
+// Draws text as a QR code with half block characters, two rows of modules per
+// line. Colours are set explicitly, so that the code scans on dark and light
+// terminals alike.
+func printQRCode(text string) {
+	code, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		log.Debugf("Could not draw QR code: %v", err)
+		return
+	}
+
+	// The bitmap includes the light border around the code.
+	bitmap := code.Bitmap()
+	dark := func(x, y int) bool {
+		return y < len(bitmap) && bitmap[y][x]
+	}
+
+	for y := 0; y < len(bitmap); y += 2 {
+		var line bytes.Buffer
+		for x := range bitmap[y] {
+			top := tui.HiWht
+			if dark(x, y) {
+				top = tui.Blk
+			}
+			bottom := tui.BgHiWht
+			if dark(x, y+1) {
+				bottom = tui.BgBlk
+			}
+			line.WriteString(bottom(top("▀")))
+		}
+		tui.Println(line.String())
+	}
+	tui.Println()
+}
+
+// Splits a secret into groups of four characters, which are easier to type
+func groupSecret(secret string) string {
+	var groups []string
+	for len(secret) > 4 {
+		groups = append(groups, secret[:4])
+		secret = secret[4:]
+	}
+	return strings.Join(append(groups, secret), " ")
+}
@@ -2,6 +2,7 @@ package versions
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/codegangsta/cli"
 	"github.com/dustin/go-humanize"
@@ -21,6 +22,27 @@ func Versions(c *cli.Context) {
 		appErr.Handle()
 	}
 
+	if common.JSONOutput() {
+		type version struct {
+			Version    int64     `json:"version"`
+			DeployedAt time.Time `json:"deployed_at"`
+			State      string    `json:"state"`
+			Active     bool      `json:"active"`
+		}
+
+		versions := []*version{}
+		for _, depl := range depls {
+			if depl.Active || depl.State == "deployed" {
+				versions = append(versions, &version{depl.Version, depl.DeployedAt, depl.State, depl.Active})
+			}
+		}
+		common.PrintJSON(map[string]interface{}{
+			"project":  proj.Name,
+			"versions": versions,
+		})
+		return
+	}
+
 	tui.Printf(tui.Bold(tr.T("versions_list"))+"\n", proj.Name)
 	tui.Printf(tui.Undl("%-10s %-20s %-10s\n"), "Version", "Deployed At", "State")
 	for _, depl := range depls {
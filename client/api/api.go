@@ -0,0 +1,231 @@
+// Package api sends requests to the PubStorm API. All client packages go
+// through it, so that headers, timeouts, retries and request logging are
+// handled in one place.
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/franela/goreq"
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+var (
+	// Timeout of requests that do not set their own
+	DefaultTimeout = 60 * time.Second
+
+	// Number of times an idempotent request is retried after a connection
+	// error or a 5xx response. Requests are not retried unless it is set.
+	MaxRetries = 0
+	// Delay before the first retry. It doubles with every retry.
+	RetryBaseDelay = 500 * time.Millisecond
+
+	// RenewToken, if set, is called when a request with a token is answered
+	// with 401 Unauthorized. If it returns a new token, the request is sent
+	// again once with it.
+	RenewToken func(token string) (newToken string, ok bool)
+
+	// OnUnauthorized, if set, is called when a request with a token is
+	// answered with 401 Unauthorized and the token could not be renewed. It
+	// is called with the rejected token, before the response is returned.
+	OnUnauthorized func(token string)
+)
+
+// Request is a request to the API.
+type Request struct {
+	Method string
+	// Path is appended to config.Host
+	Path string
+	// URL is requested instead of config.Host + Path, if set
+	URL         string
+	QueryString url.Values
+
+	// Token is sent as a bearer token, if set
+	Token             string
+	BasicAuthUsername string
+	BasicAuthPassword string
+
+	ContentType string
+	// Body is a string, a []byte, an io.Reader or a value to be encoded as
+	// JSON, as with goreq.Request. Requests with an io.Reader body are never
+	// retried, as the body cannot be read twice.
+	Body interface{}
+	// ContentLength is the length of an io.Reader body, if known
+	ContentLength int64
+	Headers       http.Header
+
+	// Timeout of the whole request, including reading the response. Zero
+	// means DefaultTimeout and a negative value means no timeout.
+	Timeout time.Duration
+
+	// NoRetry disables retries of an otherwise idempotent request, e.g. when
+	// the caller retries the request itself.
+	NoRetry bool
+}
+
+// Adds a header to the request
+func (r *Request) AddHeader(name, value string) {
+	if r.Headers == nil {
+		r.Headers = http.Header{}
+	}
+	r.Headers.Add(name, value)
+}
+
+// Sends the request, retrying it if it is idempotent and fails with a
+// connection error or a 5xx response, or if its token is rejected and can be
+// renewed
+func (r Request) Do() (res *goreq.Response, err error) {
+	res, err = r.send()
+	if err != nil || res.StatusCode != http.StatusUnauthorized || r.Token == "" {
+		return res, err
+	}
+
+	if r.renewable() {
+		if token, ok := RenewToken(r.Token); ok {
+			res.Body.Close()
+
+			r.Token = token
+			res, err = r.send()
+			if err != nil || res.StatusCode != http.StatusUnauthorized {
+				return res, err
+			}
+		}
+	}
+
+	if OnUnauthorized != nil {
+		OnUnauthorized(r.Token)
+	}
+	return res, err
+}
+
+func (r Request) send() (res *goreq.Response, err error) {
+	uri := r.URL
+	if uri == "" {
+		uri = config.Host + r.Path
+	}
+
+	req := goreq.Request{
+		Method:      r.Method,
+		Uri:         uri,
+		Accept:      config.ReqAccept,
+		UserAgent:   config.UserAgent,
+		ContentType: r.ContentType,
+		Body:        r.Body,
+
+		BasicAuthUsername: r.BasicAuthUsername,
+		BasicAuthPassword: r.BasicAuthPassword,
+	}
+	if r.QueryString != nil {
+		req.QueryString = r.QueryString
+	}
+	if r.Token != "" {
+		req.AddHeader("Authorization", "Bearer "+r.Token)
+	}
+	for key, values := range r.Headers {
+		for _, value := range values {
+			req.AddHeader(key, value)
+		}
+	}
+	if r.ContentLength > 0 {
+		req.OnBeforeRequest = func(goreq *goreq.Request, httpreq *http.Request) {
+			httpreq.ContentLength = r.ContentLength
+		}
+	}
+
+	// goreq only ever sets the timeout of its shared client, so reset it for
+	// every request.
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	} else if timeout < 0 {
+		timeout = 0
+	}
+
+	retries := 0
+	if r.retryable() {
+		retries = MaxRetries
+	}
+
+	delay := RetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		goreq.DefaultClient.Timeout = timeout
+
+		res, err = req.Do()
+		if attempt >= retries || (err == nil && res.StatusCode < 500) {
+			return res, err
+		}
+
+		if res != nil && res.Body != nil {
+			res.Body.Close()
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Reports whether the request can be sent again with a renewed token. A
+// request with an io.Reader body can't, as the body has been read.
+func (r Request) renewable() bool {
+	if r.Token == "" || RenewToken == nil {
+		return false
+	}
+
+	_, ok := r.Body.(io.Reader)
+	return !ok
+}
+
+func (r Request) retryable() bool {
+	if r.NoRetry {
+		return false
+	}
+
+	if _, ok := r.Body.(io.Reader); ok {
+		return false
+	}
+
+	switch r.Method {
+	case "", "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// ErrorResponse is the JSON body of an API error response.
+type ErrorResponse struct {
+	Err              string                 `json:"error"`
+	ErrorDescription string                 `json:"error_description"`
+	Errors           map[string]interface{} `json:"errors"`
+}
+
+// Decodes the error response in res. If the body is not a JSON error
+// response, the returned ErrorResponse is empty.
+func DecodeError(res *goreq.Response) *ErrorResponse {
+	e := &ErrorResponse{}
+	if err := res.Body.FromJsonTo(e); err != nil {
+		return &ErrorResponse{}
+	}
+	return e
+}
+
+// Returns the validation errors of the response as a sentence, e.g.
+// "Name is taken"
+func (e *ErrorResponse) ValidationErrors() string {
+	return util.ValidationErrorsToString(map[string]interface{}{"errors": e.Errors})
+}
+
+// Converts the error response into an apperror.Error with the given code. Its
+// description is the validation errors if there are any, or else the error
+// description.
+func (e *ErrorResponse) AppError(code string, isFatal bool) *apperror.Error {
+	desc := e.ValidationErrors()
+	if desc == "" {
+		desc = e.ErrorDescription
+	}
+	return apperror.New(code, nil, desc, isFatal)
+}
@@ -0,0 +1,424 @@
+package api_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/franela/goreq"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "api")
+}
+
+var _ = Describe("Request", func() {
+	var (
+		origHost       string
+		origMaxRetries int
+		origDelay      time.Duration
+		server         *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+
+		origMaxRetries = api.MaxRetries
+		origDelay = api.RetryBaseDelay
+		api.MaxRetries = 2
+		api.RetryBaseDelay = time.Millisecond
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		api.MaxRetries = origMaxRetries
+		api.RetryBaseDelay = origDelay
+		server.Close()
+	})
+
+	It("sends the request with the common headers and the access token", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/tokens"),
+				ghttp.VerifyHeader(http.Header{
+					"Authorization": {"Bearer t0k3n"},
+					"Accept":        {config.ReqAccept},
+					"User-Agent":    {config.UserAgent},
+					"X-Foo":         {"bar"},
+				}),
+				ghttp.VerifyBody([]byte("name=ci")),
+				ghttp.RespondWith(http.StatusCreated, `{}`),
+			),
+		)
+
+		req := api.Request{
+			Method:      "POST",
+			Path:        "/tokens",
+			Token:       "t0k3n",
+			ContentType: "application/x-www-form-urlencoded",
+			Body:        "name=ci",
+		}
+		req.AddHeader("X-Foo", "bar")
+
+		res, err := req.Do()
+		Expect(err).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusCreated))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("retries idempotent requests that fail with a 5xx response", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusBadGateway, ""),
+			ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+			ghttp.RespondWith(http.StatusOK, `{"tokens": []}`),
+		)
+
+		res, err := api.Request{Method: "GET", Path: "/tokens"}.Do()
+		Expect(err).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("gives up after MaxRetries retries", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+		)
+
+		res, err := api.Request{Method: "DELETE", Path: "/tokens/1"}.Do()
+		Expect(err).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("does not retry non-idempotent requests", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+		res, err := api.Request{Method: "POST", Path: "/tokens", Body: "name=ci"}.Do()
+		Expect(err).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("does not retry requests with a streamed body", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+		res, err := api.Request{Method: "PUT", Path: "/blobs/abc", Body: strings.NewReader("data")}.Do()
+		Expect(err).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("does not retry requests with NoRetry set", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+		res, err := api.Request{Method: "GET", Path: "/tokens", NoRetry: true}.Do()
+		Expect(err).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("times out", func() {
+		server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		})
+
+		_, err := api.Request{Method: "POST", Path: "/slow", Timeout: 10 * time.Millisecond}.Do()
+		Expect(err).NotTo(BeNil())
+		Expect(err.(*goreq.Error).Timeout()).To(BeTrue())
+	})
+
+	Context("with RenewToken set", func() {
+		var renewed []string
+
+		BeforeEach(func() {
+			renewed = nil
+			api.RenewToken = func(token string) (string, bool) {
+				renewed = append(renewed, token)
+				return "n3w", token == "3xp1r3d"
+			}
+		})
+
+		AfterEach(func() {
+			api.RenewToken = nil
+		})
+
+		It("sends the request again with a renewed token if the token is rejected", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeader(http.Header{"Authorization": {"Bearer 3xp1r3d"}}),
+					ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_token"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/tokens"),
+					ghttp.VerifyHeader(http.Header{"Authorization": {"Bearer n3w"}}),
+					ghttp.VerifyBody([]byte("name=ci")),
+					ghttp.RespondWith(http.StatusCreated, `{}`),
+				),
+			)
+
+			res, err := api.Request{Method: "POST", Path: "/tokens", Token: "3xp1r3d", Body: "name=ci"}.Do()
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusCreated))
+			Expect(renewed).To(Equal([]string{"3xp1r3d"}))
+		})
+
+		It("returns the 401 response if the token can't be renewed", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_token"}`))
+
+			res, err := api.Request{Method: "GET", Path: "/user", Token: "r3v0k3d"}.Do()
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(api.DecodeError(res).Err).To(Equal("invalid_token"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("does not renew tokens of requests with a streamed body", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+			res, err := api.Request{Method: "PUT", Path: "/blobs/abc", Token: "3xp1r3d", Body: strings.NewReader("data")}.Do()
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(renewed).To(BeEmpty())
+		})
+
+		It("does not renew without a token", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+			res, err := api.Request{Method: "POST", Path: "/oauth/token", BasicAuthUsername: "id"}.Do()
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(renewed).To(BeEmpty())
+		})
+
+		Context("with OnUnauthorized set", func() {
+			var rejected []string
+
+			BeforeEach(func() {
+				rejected = nil
+				api.OnUnauthorized = func(token string) {
+					rejected = append(rejected, token)
+				}
+			})
+
+			AfterEach(func() {
+				api.OnUnauthorized = nil
+			})
+
+			It("is called if the token can't be renewed", func() {
+				server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_token"}`))
+
+				res, err := api.Request{Method: "GET", Path: "/user", Token: "r3v0k3d"}.Do()
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(rejected).To(Equal([]string{"r3v0k3d"}))
+			})
+
+			It("is called if the renewed token is rejected too", func() {
+				server.AppendHandlers(
+					ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_token"}`),
+					ghttp.CombineHandlers(
+						ghttp.VerifyHeader(http.Header{"Authorization": {"Bearer n3w"}}),
+						ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_token"}`),
+					),
+				)
+
+				res, err := api.Request{Method: "GET", Path: "/user", Token: "3xp1r3d"}.Do()
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(rejected).To(Equal([]string{"n3w"}))
+			})
+
+			It("is not called if the token is renewed", func() {
+				server.AppendHandlers(
+					ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_token"}`),
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				)
+
+				res, err := api.Request{Method: "GET", Path: "/user", Token: "3xp1r3d"}.Do()
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode).To(Equal(http.StatusOK))
+				Expect(rejected).To(BeEmpty())
+			})
+
+			It("is not called without a token", func() {
+				server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+				_, err := api.Request{Method: "POST", Path: "/oauth/token", BasicAuthUsername: "id"}.Do()
+				Expect(err).To(BeNil())
+				Expect(rejected).To(BeEmpty())
+			})
+		})
+	})
+})
+
+var _ = Describe("DecodeError", func() {
+	var server *ghttp.Server
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(body string) *api.ErrorResponse {
+		server.AppendHandlers(ghttp.RespondWith(422, body))
+		res, err := api.Request{Method: "POST", URL: server.URL()}.Do()
+		Expect(err).To(BeNil())
+		defer res.Body.Close()
+		return api.DecodeError(res)
+	}
+
+	It("decodes validation errors", func() {
+		e := get(`{"error": "invalid_params", "errors": {"name": "is taken"}}`)
+		Expect(e.Err).To(Equal("invalid_params"))
+
+		appErr := e.AppError("validation_failed", false)
+		Expect(appErr.Code).To(Equal("validation_failed"))
+		Expect(appErr.Description).To(Equal("Name is taken"))
+		Expect(appErr.IsFatal).To(BeFalse())
+	})
+
+	It("uses the error description if there are no validation errors", func() {
+		e := get(`{"error": "invalid_request", "error_description": "project is locked"}`)
+
+		appErr := e.AppError("project_locked", true)
+		Expect(appErr.Description).To(Equal("project is locked"))
+		Expect(appErr.IsFatal).To(BeTrue())
+	})
+
+	It("returns an empty error response if the body is not JSON", func() {
+		e := get(`<html>Bad Gateway</html>`)
+		Expect(*e).To(Equal(api.ErrorResponse{}))
+	})
+})
+
+var _ = Describe("EnableDebug", func() {
+	It("logs requests and responses with credentials redacted", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+
+		server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"access_token": "s3cr3t", "token_type": "bearer"}`, http.Header{
+			"Content-Type": {"application/json"},
+		}))
+
+		var buf bytes.Buffer
+		logger := log.New()
+		logger.Out = &buf
+		logger.Level = log.DebugLevel
+
+		origTransport := goreq.DefaultClient.Transport
+		defer func() { goreq.DefaultClient.Transport = origTransport }()
+		api.EnableDebug(logger)
+
+		res, err := api.Request{
+			Method:            "POST",
+			URL:               server.URL() + "/oauth/token",
+			ContentType:       "application/x-www-form-urlencoded",
+			BasicAuthUsername: "client",
+			BasicAuthPassword: "p4ssw0rd",
+			Body:              "grant_type=password&username=foo%40example.com&password=hunter2",
+		}.Do()
+		Expect(err).To(BeNil())
+		res.Body.Close()
+
+		out := buf.String()
+		Expect(out).To(ContainSubstring("POST /oauth/token"))
+		Expect(out).To(ContainSubstring("foo%40example.com"))
+		Expect(out).To(ContainSubstring("bearer"))
+		Expect(out).NotTo(ContainSubstring("hunter2"))
+		Expect(out).NotTo(ContainSubstring("s3cr3t"))
+		Expect(out).NotTo(ContainSubstring("Basic "))
+	})
+})
+
+var _ = Describe("Redact", func() {
+	It("redacts credentials in headers and bodies", func() {
+		dump := "PUT /user HTTP/1.1\r\nAuthorization: Bearer t0k3n\r\nContent-Type: application/json\r\n\r\n" +
+			`{"name": "Foo", "password": "hunter2", "nested": {"refresh_token": "r3fr3sh"}}`
+
+		out := string(api.Redact([]byte(dump)))
+		Expect(out).To(ContainSubstring("Authorization: [REDACTED]"))
+		Expect(out).To(ContainSubstring(`"name":"Foo"`))
+		Expect(out).NotTo(ContainSubstring("t0k3n"))
+		Expect(out).NotTo(ContainSubstring("hunter2"))
+		Expect(out).NotTo(ContainSubstring("r3fr3sh"))
+	})
+
+	It("redacts form fields that contain the name of a credential", func() {
+		dump := "PUT /user HTTP/1.1\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\n" +
+			"existing_password=0ld-pa55&password=n3w-pa55&otp=123456&name=Foo"
+
+		out := string(api.Redact([]byte(dump)))
+		Expect(out).To(ContainSubstring("name=Foo"))
+		Expect(out).NotTo(ContainSubstring("0ld-pa55"))
+		Expect(out).NotTo(ContainSubstring("n3w-pa55"))
+		Expect(out).NotTo(ContainSubstring("123456"))
+	})
+
+	It("redacts two-factor authentication secrets and recovery codes", func() {
+		dump := "HTTP/1.1 201 Created\r\nContent-Type: application/json\r\n\r\n" +
+			`{"two_factor": {"secret": "JBSWY3DPEHPK3PXP", "provisioning_uri": "otpauth://totp/PubStorm:foo?secret=JBSWY3DPEHPK3PXP"}, ` +
+			`"recovery_codes": ["abcd-efgh", "ijkl-mnop"]}`
+
+		out := string(api.Redact([]byte(dump)))
+		Expect(out).To(ContainSubstring(`"two_factor":{`))
+		Expect(out).NotTo(ContainSubstring("JBSWY3DPEHPK3PXP"))
+		Expect(out).NotTo(ContainSubstring("abcd-efgh"))
+		Expect(out).NotTo(ContainSubstring("ijkl-mnop"))
+	})
+
+	It("redacts device authorization codes and PKCE verifiers", func() {
+		dump := "POST /oauth/token HTTP/1.1\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\n" +
+			"grant_type=authorization_code&code=auth-c0de&code_verifier=v3r1f13r&device_code=d3v1c3"
+
+		out := string(api.Redact([]byte(dump)))
+		Expect(out).To(ContainSubstring("grant_type=authorization_code"))
+		Expect(out).NotTo(ContainSubstring("auth-c0de"))
+		Expect(out).NotTo(ContainSubstring("v3r1f13r"))
+		Expect(out).NotTo(ContainSubstring("d3v1c3"))
+
+		dump = "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n" +
+			`{"device_code": "d3v1c3", "user_code": "WDJB-MJHT", "verification_uri": "https://example.com/device", ` +
+			`"verification_uri_complete": "https://example.com/device?user_code=WDJB-MJHT", "interval": 5}`
+
+		out = string(api.Redact([]byte(dump)))
+		Expect(out).To(ContainSubstring(`"verification_uri":"https://example.com/device"`))
+		Expect(out).NotTo(ContainSubstring("d3v1c3"))
+		Expect(out).NotTo(ContainSubstring("WDJB-MJHT"))
+	})
+
+	It("redacts pre-signed URLs", func() {
+		dump := "GET /bundles/abc.tar.gz?X-Amz-Signature=s1gn4tur3 HTTP/1.1\r\nHost: s3.example.com\r\n\r\n"
+
+		out := string(api.Redact([]byte(dump)))
+		Expect(out).To(HavePrefix("GET /bundles/abc.tar.gz?[REDACTED] HTTP/1.1\r\n"))
+		Expect(out).NotTo(ContainSubstring("s1gn4tur3"))
+
+		dump = "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n" +
+			`{"download": {"url": "https://s3.example.com/bundles/abc.tar.gz?X-Amz-Signature=s1gn4tur3", "checksum": "c0ffee"}}`
+
+		out = string(api.Redact([]byte(dump)))
+		Expect(out).To(ContainSubstring(`"checksum":"c0ffee"`))
+		Expect(out).NotTo(ContainSubstring("s1gn4tur3"))
+	})
+
+	It("leaves bodies without credentials untouched", func() {
+		dump := "GET /projects HTTP/1.1\r\nHost: example.com\r\n\r\n"
+		Expect(string(api.Redact([]byte(dump)))).To(Equal(dump))
+	})
+})
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/franela/goreq"
+)
+
+// Bodies larger than this are left out of the debug log
+const maxDebugBodySize = 64 * 1024
+
+const redacted = "[REDACTED]"
+
+// Form and JSON fields whose names contain any of these are left out of the
+// debug log, so that e.g. "existing_password" and "refresh_token" are covered
+// without being listed
+var sensitiveFields = []string{
+	"password",
+	"token",
+	"secret",
+	"code",
+	"otp",
+}
+
+// Names of fields that are left out of the debug log although they contain
+// none of sensitiveFields
+var sensitiveFieldNames = []string{
+	"key",
+	// holds the two-factor authentication secret
+	"provisioning_uri",
+	// holds the user code of a device authorization
+	"verification_uri_complete",
+	// holds pre-signed download URLs, which work without an access token
+	"url",
+}
+
+// Names of fields that contain one of sensitiveFields, but only describe a
+// credential
+var publicFieldNames = []string{
+	"token_type",
+}
+
+// Logs every request and response to logger, with credentials redacted
+func EnableDebug(logger *log.Logger) {
+	transport := goreq.DefaultClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if _, ok := transport.(*debugTransport); ok {
+		return
+	}
+	goreq.DefaultClient.Transport = &debugTransport{transport, logger}
+}
+
+type debugTransport struct {
+	transport http.RoundTripper
+	logger    *log.Logger
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	dumpBody := shouldDumpBody(req.Header, req.ContentLength)
+	if dump, err := httputil.DumpRequestOut(req, dumpBody); err == nil {
+		t.logger.Debugf("HTTP request:\n%s", Redact(dump))
+	}
+
+	res, err := t.transport.RoundTrip(req)
+	if err != nil {
+		t.logger.Debugf("HTTP request to %s failed: %v", redactQuery(req.URL.String()), err)
+		return res, err
+	}
+
+	dumpBody = shouldDumpBody(res.Header, res.ContentLength)
+	if dump, err := httputil.DumpResponse(res, dumpBody); err == nil {
+		t.logger.Debugf("HTTP response:\n%s", Redact(dump))
+	}
+
+	return res, nil
+}
+
+// Only bodies that are small and not binary are logged. Chunked bodies (with
+// an unknown length) are left out too, so that streamed uploads and downloads
+// are not read into memory.
+func shouldDumpBody(header http.Header, length int64) bool {
+	if length <= 0 || length > maxDebugBodySize {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/octet-stream",
+		strings.HasPrefix(mediaType, "multipart/"),
+		strings.HasPrefix(mediaType, "image/"):
+		return false
+	}
+	return true
+}
+
+var sensitiveHeaderRe = regexp.MustCompile(`(?i)^((?:proxy-)?authorization|cookie|set-cookie):.*$`)
+
+// Redacts credentials from a dumped HTTP request or response. Query strings
+// on the request line are left out, as pre-signed URLs carry their signature
+// there.
+func Redact(dump []byte) []byte {
+	parts := bytes.SplitN(dump, []byte("\r\n\r\n"), 2)
+
+	lines := strings.Split(string(parts[0]), "\r\n")
+	if fields := strings.Fields(lines[0]); len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/") {
+		lines[0] = fields[0] + " " + redactQuery(fields[1]) + " " + fields[2]
+	}
+	for i, line := range lines {
+		if m := sensitiveHeaderRe.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + ": " + redacted
+		}
+	}
+	head := strings.Join(lines, "\r\n")
+
+	if len(parts) == 1 {
+		return []byte(head)
+	}
+	return []byte(head + "\r\n\r\n" + redactBody(parts[1]))
+}
+
+// Replaces the query string of a URL, if it has one
+func redactQuery(uri string) string {
+	if i := strings.Index(uri, "?"); i >= 0 {
+		return uri[:i+1] + redacted
+	}
+	return uri
+}
+
+func redactBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return string(body)
+	}
+
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		var v interface{}
+		if err := json.Unmarshal(trimmed, &v); err != nil {
+			return string(body)
+		}
+		b, err := json.Marshal(redactJSON(v))
+		if err != nil {
+			return string(body)
+		}
+		return string(b)
+	}
+
+	values, err := url.ParseQuery(string(trimmed))
+	if err != nil {
+		return string(body)
+	}
+	changed := false
+	for k := range values {
+		if isSensitiveField(k) {
+			values[k] = []string{redacted}
+			changed = true
+		}
+	}
+	if !changed {
+		return string(body)
+	}
+	return values.Encode()
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if isSensitiveField(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactJSON(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactJSON(val)
+		}
+	}
+	return v
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range publicFieldNames {
+		if name == f {
+			return false
+		}
+	}
+	for _, f := range sensitiveFields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	for _, f := range sensitiveFieldNames {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
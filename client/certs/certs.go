@@ -1,17 +1,12 @@
 package certs
 
 import (
-	"bytes"
-	"io"
-	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/pkg/multipartstream"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -41,36 +36,26 @@ type Cert struct {
 }
 
 func Create(token, name, domainName, crtPath, keyPath string) (c *Cert, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "POST",
-		Uri:       config.Host + "/projects/" + name + "/domains/" + domainName + "/cert",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "POST",
+		Path:   "/projects/" + name + "/domains/" + domainName + "/cert",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writeFileToBody(crtPath, "cert", writer); err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
-	if err := writeFileToBody(keyPath, "key", writer); err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
-	if err := writer.Close(); err != nil {
+	body, err := multipartstream.New(
+		multipartstream.File{FieldName: "cert", Path: crtPath},
+		multipartstream.File{FieldName: "key", Path: keyPath},
+	)
+	if err != nil {
 		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	req.AddHeader("Content-Type", writer.FormDataContentType())
-	bodyLen := int64(body.Len())
+	bodyReader := body.Reader()
+	defer bodyReader.Close()
 
-	req.Body = body
-	req.OnBeforeRequest = func(goreq *goreq.Request, httpreq *http.Request) {
-		httpreq.ContentLength = bodyLen
-	}
+	req.ContentType = body.ContentType
+	req.ContentLength = body.ContentLength
+	req.Body = bodyReader
 
 	res, err := req.Do()
 	if err != nil {
@@ -94,12 +79,7 @@ func Create(token, name, domainName, crtPath, keyPath string) (c *Cert, appErr *
 		return j.Cert, nil
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
-	switch j["error_description"] {
+	switch api.DecodeError(res).ErrorDescription {
 	case "domain could not be found":
 		return nil, apperror.New(ErrCodeNotFound, nil, "domain could not be found", true)
 	case "project could not be found":
@@ -119,13 +99,11 @@ func Create(token, name, domainName, crtPath, keyPath string) (c *Cert, appErr *
 }
 
 func Get(token, name, domainName string) (c *Cert, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + name + "/domains/" + domainName + "/cert",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + name + "/domains/" + domainName + "/cert",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -149,12 +127,7 @@ func Get(token, name, domainName string) (c *Cert, appErr *apperror.Error) {
 		return j.Cert, nil
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
-	switch j["error_description"] {
+	switch api.DecodeError(res).ErrorDescription {
 	case "cert could not be found":
 		return nil, apperror.New(ErrCodeNotFound, nil, "cert could not be found", true)
 	case "project could not be found":
@@ -164,13 +137,11 @@ func Get(token, name, domainName string) (c *Cert, appErr *apperror.Error) {
 }
 
 func Delete(token, name, domainName string) (appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/projects/" + name + "/domains/" + domainName + "/cert",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/projects/" + name + "/domains/" + domainName + "/cert",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -182,13 +153,8 @@ func Delete(token, name, domainName string) (appErr *apperror.Error) {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == http.StatusNotFound {
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "cert could not be found":
 			return apperror.New(ErrCodeNotFound, nil, "cert could not be found", true)
 		case "project could not be found":
@@ -198,17 +164,20 @@ func Delete(token, name, domainName string) (appErr *apperror.Error) {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	return nil
 }
 
 func Enable(token, name, domainName string) *apperror.Error {
-	req := goreq.Request{
-		Method:    "POST",
-		Uri:       config.Host + "/projects/" + name + "/domains/" + domainName + "/cert/letsencrypt",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "POST",
+		Path:   "/projects/" + name + "/domains/" + domainName + "/cert/letsencrypt",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -225,13 +194,8 @@ func Enable(token, name, domainName string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == http.StatusNotFound {
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "project could not be found":
 			return apperror.New(ErrCodeProjectNotFound, nil, "project could not be found", true)
 		case "domain could not be found":
@@ -250,30 +214,16 @@ func Enable(token, name, domainName string) *apperror.Error {
 	}
 
 	if res.StatusCode == http.StatusServiceUnavailable {
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "domain could not be verified":
 			return apperror.New(ErrCodeAcmeServerError, nil, "domain could not be verified - have you changed its DNS configuration yet?", true)
 		}
 		return apperror.New(ErrCodeAcmeServerError, nil, "error communicating with Let's Encrypt", true)
 	}
 
-	return nil
-}
-
-func writeFileToBody(path, paramName string, bodyWriter *multipart.Writer) error {
-	f, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	part, err := bodyWriter.CreateFormFile(paramName, filepath.Base(path))
-	if err != nil {
-		return err
-	}
-
-	if _, err = io.Copy(part, f); err != nil {
-		return err
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
 	return nil
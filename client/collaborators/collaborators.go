@@ -4,9 +4,8 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -26,13 +25,11 @@ type Collaborator struct {
 }
 
 func List(token, projectName string) ([]*Collaborator, *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + projectName + "/collaborators",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + projectName + "/collaborators",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -60,18 +57,16 @@ func List(token, projectName string) ([]*Collaborator, *apperror.Error) {
 }
 
 func Add(token, projectName, email string) *apperror.Error {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects/" + projectName + "/collaborators",
+		Path:        "/projects/" + projectName + "/collaborators",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"email": {email},
 		}.Encode(),
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -96,23 +91,24 @@ func Add(token, projectName, email string) *apperror.Error {
 		return apperror.New(ErrCodeAlreadyExists, nil, "user is already a collaborator", false)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" && j["error_description"] == "email is not found" {
+		e := api.DecodeError(res)
+		if e.Err == "invalid_params" && e.ErrorDescription == "email is not found" {
 			return apperror.New(ErrCodeUserNotFound, nil, "", true)
 		}
 
-		if j["error"] == "invalid_request" && j["error_description"] == "the owner of a project cannot be added as a collaborator" {
+		if e.Err == "invalid_request" && e.ErrorDescription == "the owner of a project cannot be added as a collaborator" {
 			return apperror.New(ErrCodeCannotAddOwner, nil, "", true)
 		}
 
 		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
 	}
 
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	if v, ok := j["added"].(bool); !v || !ok {
 		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
 	}
@@ -121,13 +117,11 @@ func Add(token, projectName, email string) *apperror.Error {
 }
 
 func Remove(token, projectName, email string) *apperror.Error {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/projects/" + projectName + "/collaborators/" + email,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/projects/" + projectName + "/collaborators/" + email,
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -139,18 +133,19 @@ func Remove(token, projectName, email string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == http.StatusNotFound {
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "project could not be found":
 			return apperror.New(ErrCodeNotFound, nil, "project could not be found", true)
 		case "email is not found":
 			return apperror.New(ErrCodeUserNotFound, nil, "", true)
 		}
+		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
 	if v, ok := j["removed"].(bool); !v || !ok {
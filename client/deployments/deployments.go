@@ -1,20 +1,19 @@
 package deployments
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/pkg/multipartstream"
 	"github.com/nitrous-io/rise-cli-go/progressbar"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/tui"
@@ -29,6 +28,8 @@ const (
 	ErrCodeNotFound          = "not_found"
 	ErrCodeProjectLocked     = "project_locked"
 	ErrCodeRawBundleNotFound = "raw_bundle_not_found"
+	ErrCodeNotSupported      = "not_supported"
+	ErrCodeAuthFailed        = "auth_failed"
 
 	DeploymentStateDeployed     = "deployed"
 	DeploymentStateBuilding     = "pending_build"
@@ -40,54 +41,39 @@ type Deployment struct {
 	ID           uint      `json:"id"`
 	State        string    `json:"state"`
 	Active       bool      `json:"active,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
 	DeployedAt   time.Time `json:"deployed_at,omitempty"`
 	Version      int64     `json:"version"`
 	ErrorMessage string    `json:"error_message,omitempty"`
+	// Size of the uploaded bundle in bytes
+	Size int64 `json:"size,omitempty"`
+	// Email address of the user who created the deployment
+	Deployer string `json:"deployer,omitempty"`
 }
 
 func Create(token, name, bunPath string, quiet bool) (depl *Deployment, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "POST",
-		Uri:       config.Host + "/projects/" + name + "/deployments",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "POST",
+		Path:   "/projects/" + name + "/deployments",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
-	f, err := os.Open(bunPath)
-	if err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-	defer f.Close()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("payload", filepath.Base(bunPath))
+	body, err := multipartstream.New(multipartstream.File{FieldName: "payload", Path: bunPath})
 	if err != nil {
 		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	if _, err = io.Copy(part, f); err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
+	bodyReader := body.Reader()
+	defer bodyReader.Close()
 
-	if err = writer.Close(); err != nil {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
-	req.AddHeader("Content-Type", writer.FormDataContentType())
-	bodyLen := int64(body.Len())
+	req.ContentType = body.ContentType
+	req.ContentLength = body.ContentLength
+	req.Timeout = -1
 
 	if quiet {
-		req.Body = body
+		req.Body = bodyReader
 	} else {
-		pb := progressbar.NewReader(body, tui.Out, bodyLen)
-		req.Body = pb
-	}
-
-	req.OnBeforeRequest = func(goreq *goreq.Request, httpreq *http.Request) {
-		httpreq.ContentLength = bodyLen
+		req.Body = progressbar.NewReader(bodyReader, tui.Out, body.ContentLength)
 	}
 
 	res, err := req.Do()
@@ -97,7 +83,7 @@ func Create(token, name, bunPath string, quiet bool) (depl *Deployment, appErr *
 	defer res.Body.Close()
 
 	if !util.ContainsInt([]int{http.StatusAccepted, http.StatusBadRequest, http.StatusNotFound, 423}, res.StatusCode) {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, unexpectedStatus(res, err)
 	}
 
 	switch res.StatusCode {
@@ -112,13 +98,8 @@ func Create(token, name, bunPath string, quiet bool) (depl *Deployment, appErr *
 
 		return &j.Deployment, nil
 	case http.StatusBadRequest:
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		if j["error"] == "invalid_request" {
-			switch j["error_description"] {
+		if e := api.DecodeError(res); e.Err == "invalid_request" {
+			switch e.ErrorDescription {
 			case "request body is too large":
 				return nil, apperror.New(ErrCodeValidationFailed, nil, "project size is too large", true)
 			}
@@ -133,17 +114,15 @@ func Create(token, name, bunPath string, quiet bool) (depl *Deployment, appErr *
 }
 
 func CreateWithChecksum(token, name, checksum string) (depl *Deployment, appErr *apperror.Error) {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects/" + name + "/deployments",
+		Path:        "/projects/" + name + "/deployments",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 		Body: url.Values{
 			"bundle_checksum": {checksum},
 		}.Encode(),
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -152,7 +131,7 @@ func CreateWithChecksum(token, name, checksum string) (depl *Deployment, appErr
 	defer res.Body.Close()
 
 	if !util.ContainsInt([]int{http.StatusAccepted, http.StatusNotFound, 422, 423}, res.StatusCode) {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, unexpectedStatus(res, err)
 	}
 
 	if res.StatusCode == http.StatusAccepted {
@@ -176,15 +155,154 @@ func CreateWithChecksum(token, name, checksum string) (depl *Deployment, appErr
 	return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 }
 
+// Sends the manifest of a bundle and returns the checksums of the files that
+// the server does not have yet. Returns ErrCodeNotSupported if the server does
+// not support incremental deployments.
+func MissingBlobs(token, name string, manifest []*bundle.ManifestEntry) (checksums []string, appErr *apperror.Error) {
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/projects/" + name + "/manifests",
+		Token:       token,
+		ContentType: "application/json",
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"files": manifest})
+	if err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+	req.Body = b
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented, 423}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		var j struct {
+			Missing []string `json:"missing"`
+		}
+
+		if err := res.Body.FromJsonTo(&j); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+
+		return j.Missing, nil
+	case http.StatusNotFound:
+		if e := api.DecodeError(res); e.ErrorDescription == "project could not be found" {
+			return nil, apperror.New(ErrCodeProjectNotFound, nil, fmt.Sprintf(tr.T("project_not_found"), name), true)
+		}
+	case 423:
+		return nil, apperror.New(ErrCodeProjectLocked, nil, "project is locked", true)
+	}
+
+	return nil, apperror.New(ErrCodeNotSupported, nil, "incremental deployments are not supported", false)
+}
+
+// Uploads the content of a single file, identified by its SHA-256 checksum
+func UploadBlob(token, name, checksum, path string) (appErr *apperror.Error) {
+	req := api.Request{
+		Method:      "PUT",
+		Path:        "/projects/" + name + "/blobs/" + checksum,
+		Token:       token,
+		ContentType: "application/octet-stream",
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	req.Body = f
+	req.ContentLength = fi.Size()
+	req.Timeout = -1
+
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusCreated, http.StatusNotFound, 422, 423}, res.StatusCode) {
+		return unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return apperror.New(ErrCodeProjectNotFound, nil, fmt.Sprintf(tr.T("project_not_found"), name), true)
+	case 422:
+		return apperror.New(ErrCodeValidationFailed, nil, fmt.Sprintf(tr.T("file_blob_changed"), path), true)
+	case 423:
+		return apperror.New(ErrCodeProjectLocked, nil, "project is locked", true)
+	}
+
+	return nil
+}
+
+// Creates a deployment from a manifest whose files have all been uploaded
+func CreateWithManifest(token, name string, manifest []*bundle.ManifestEntry) (depl *Deployment, appErr *apperror.Error) {
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/projects/" + name + "/deployments",
+		Token:       token,
+		ContentType: "application/json",
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"manifest": manifest})
+	if err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+	req.Body = b
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusAccepted, http.StatusNotFound, 422, 423}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusAccepted:
+		var j struct {
+			Deployment Deployment `json:"deployment"`
+		}
+
+		if err := res.Body.FromJsonTo(&j); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+
+		return &j.Deployment, nil
+	case http.StatusNotFound:
+		return nil, apperror.New(ErrCodeProjectNotFound, nil, fmt.Sprintf(tr.T("project_not_found"), name), true)
+	case 422:
+		return nil, apperror.New(ErrCodeValidationFailed, nil, "some files in the manifest have not been uploaded", true)
+	case 423:
+		return nil, apperror.New(ErrCodeProjectLocked, nil, "project is locked", true)
+	}
+
+	return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+}
+
 func Get(token, projectName string, deploymentID uint) (depl *Deployment, appErr *apperror.Error) {
-	uri := fmt.Sprintf("%s/projects/%s/deployments/%d", config.Host, projectName, deploymentID)
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       uri,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   fmt.Sprintf("/projects/%s/deployments/%d", projectName, deploymentID),
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -193,7 +311,7 @@ func Get(token, projectName string, deploymentID uint) (depl *Deployment, appErr
 	defer res.Body.Close()
 
 	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound}, res.StatusCode) {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, unexpectedStatus(res, err)
 	}
 
 	if res.StatusCode == http.StatusOK {
@@ -213,15 +331,56 @@ func Get(token, projectName string, deploymentID uint) (depl *Deployment, appErr
 	return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 }
 
+// Fetches the manifest of the files in a deployment. Errors with
+// ErrCodeNotSupported if the server does not keep manifests, or did not keep
+// one for this deployment.
+func GetManifest(token, projectName string, deploymentID uint) (manifest []*bundle.ManifestEntry, appErr *apperror.Error) {
+	req := api.Request{
+		Method: "GET",
+		Path:   fmt.Sprintf("/projects/%s/deployments/%d/manifest", projectName, deploymentID),
+		Token:  token,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		var j struct {
+			Files []*bundle.ManifestEntry `json:"files"`
+		}
+
+		if err := res.Body.FromJsonTo(&j); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+
+		return j.Files, nil
+	case http.StatusNotFound:
+		switch api.DecodeError(res).ErrorDescription {
+		case "project could not be found":
+			return nil, apperror.New(ErrCodeProjectNotFound, nil, fmt.Sprintf(tr.T("project_not_found"), projectName), true)
+		case "deployment could not be found":
+			return nil, apperror.New(ErrCodeNotFound, nil, "deployment could not be found", true)
+		}
+	}
+
+	return nil, apperror.New(ErrCodeNotSupported, nil, "deployment manifests are not supported", false)
+}
+
 func Rollback(token, projectName string, version int64) (depl *Deployment, appErr *apperror.Error) {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects/" + projectName + "/rollback",
+		Path:        "/projects/" + projectName + "/rollback",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	if version != 0 {
 		req.Body = url.Values{"version": {strconv.FormatInt(version, 10)}}.Encode()
@@ -234,7 +393,7 @@ func Rollback(token, projectName string, version int64) (depl *Deployment, appEr
 	defer res.Body.Close()
 
 	if !util.ContainsInt([]int{http.StatusAccepted, http.StatusNotFound, http.StatusPreconditionFailed, 422, 423}, res.StatusCode) {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, unexpectedStatus(res, err)
 	}
 
 	if res.StatusCode == 423 {
@@ -242,24 +401,17 @@ func Rollback(token, projectName string, version int64) (depl *Deployment, appEr
 	}
 
 	if res.StatusCode != http.StatusAccepted {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		if errDesc, ok := j["error_description"].(string); ok {
-			switch errDesc {
-			case "active deployment could not be found":
-				return nil, apperror.New(ErrCodeNotFound, err, tr.T("rollback_no_active_deployment"), true)
-			case "previous completed deployment could not be found":
-				return nil, apperror.New(ErrCodeNotFound, err, tr.T("rollback_no_previous_version"), true)
-			case "project could not be found":
-				return nil, apperror.New(ErrCodeProjectNotFound, err, fmt.Sprintf(tr.T("project_not_found"), projectName), true)
-			case "completed deployment with a given version could not be found":
-				return nil, apperror.New(ErrCodeValidationFailed, err, fmt.Sprintf(tr.T("rollback_version_not_found"), version), true)
-			case "the specified deployment is already active":
-				return nil, apperror.New(ErrCodeValidationFailed, err, fmt.Sprintf(tr.T("rollback_version_already_active"), version), true)
-			}
+		switch api.DecodeError(res).ErrorDescription {
+		case "active deployment could not be found":
+			return nil, apperror.New(ErrCodeNotFound, err, tr.T("rollback_no_active_deployment"), true)
+		case "previous completed deployment could not be found":
+			return nil, apperror.New(ErrCodeNotFound, err, tr.T("rollback_no_previous_version"), true)
+		case "project could not be found":
+			return nil, apperror.New(ErrCodeProjectNotFound, err, fmt.Sprintf(tr.T("project_not_found"), projectName), true)
+		case "completed deployment with a given version could not be found":
+			return nil, apperror.New(ErrCodeValidationFailed, err, fmt.Sprintf(tr.T("rollback_version_not_found"), version), true)
+		case "the specified deployment is already active":
+			return nil, apperror.New(ErrCodeValidationFailed, err, fmt.Sprintf(tr.T("rollback_version_already_active"), version), true)
 		}
 		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
@@ -276,13 +428,11 @@ func Rollback(token, projectName string, version int64) (depl *Deployment, appEr
 }
 
 func List(token, projectName string) (depls []Deployment, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + projectName + "/deployments",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + projectName + "/deployments",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -291,7 +441,7 @@ func List(token, projectName string) (depls []Deployment, appErr *apperror.Error
 	defer res.Body.Close()
 
 	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound}, res.StatusCode) {
-		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, unexpectedStatus(res, err)
 	}
 
 	if res.StatusCode == http.StatusOK {
@@ -305,18 +455,17 @@ func List(token, projectName string) (depls []Deployment, appErr *apperror.Error
 
 		return j.Deployments, nil
 	} else if res.StatusCode == http.StatusNotFound {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		if errDesc, ok := j["error_description"].(string); ok {
-			if errDesc == "project could not be found" {
-				return nil, apperror.New(ErrCodeProjectNotFound, err, fmt.Sprintf(tr.T("project_not_found"), projectName), true)
-			}
-		}
 		return nil, apperror.New(ErrCodeProjectNotFound, err, fmt.Sprintf(tr.T("project_not_found"), projectName), true)
 	}
 
 	return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 }
+
+// Returns the error for a response with a status the request does not expect,
+// telling a rejected access token apart from other failures
+func unexpectedStatus(res *goreq.Response, err error) *apperror.Error {
+	if res.StatusCode == http.StatusUnauthorized {
+		return apperror.New(ErrCodeAuthFailed, nil, "access token was rejected", true)
+	}
+	return apperror.New(ErrCodeUnexpectedError, err, "", true)
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nitrous-io/rise-cli-go/bundle"
 	"github.com/nitrous-io/rise-cli-go/client/deployments"
 	"github.com/nitrous-io/rise-cli-go/config"
 	. "github.com/onsi/ginkgo"
@@ -71,6 +72,10 @@ var _ = Describe("Deployments", func() {
 					},
 
 					func(w http.ResponseWriter, req *http.Request) {
+						// The body is streamed, but not chunked.
+						Expect(req.TransferEncoding).To(BeEmpty())
+						Expect(req.ContentLength).To(BeNumerically(">", len("my-bundle-yo")))
+
 						mr, err := req.MultipartReader()
 						Expect(err).To(BeNil())
 
@@ -129,6 +134,15 @@ var _ = Describe("Deployments", func() {
 			errIsFatal: true,
 		}),
 
+		Entry("401 with rejected token", expectation{
+			resCode:    http.StatusUnauthorized,
+			resBody:    `{"error": "invalid_token"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeAuthFailed,
+			errDesc:    "access token was rejected",
+			errIsFatal: true,
+		}),
+
 		Entry("malformed json", expectation{
 			resCode:    http.StatusCreated,
 			resBody:    `{"foo": }`,
@@ -216,6 +230,15 @@ var _ = Describe("Deployments", func() {
 			errIsFatal: true,
 		}),
 
+		Entry("401 with rejected token", expectation{
+			resCode:    http.StatusUnauthorized,
+			resBody:    `{"error": "invalid_token"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeAuthFailed,
+			errDesc:    "access token was rejected",
+			errIsFatal: true,
+		}),
+
 		Entry("malformed json", expectation{
 			resCode:    http.StatusCreated,
 			resBody:    `{"foo": }`,
@@ -260,6 +283,295 @@ var _ = Describe("Deployments", func() {
 		}),
 	)
 
+	manifest := []*bundle.ManifestEntry{
+		{Path: "index.html", Checksum: "ch3ck5um1", Size: 5, Mode: 0644},
+		{Path: "css/app.css", Checksum: "ch3ck5um2", Size: 7, Mode: 0644},
+	}
+
+	DescribeTable("MissingBlobs",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/manifests"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Content-Type":  {"application/json"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.VerifyJSON(`{
+						"files": [
+							{"path": "index.html", "checksum": "ch3ck5um1", "size": 5, "mode": 420},
+							{"path": "css/app.css", "checksum": "ch3ck5um2", "size": 7, "mode": 420}
+						]
+					}`),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			missing, appErr := deployments.MissingBlobs("t0k3n", "foo-bar-express", manifest)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(missing).To(Equal(e.result))
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with project not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "project could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectNotFound,
+			errDesc:    "could not find a project",
+			errIsFatal: true,
+		}),
+
+		Entry("404 without endpoint", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `404 page not found`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotSupported,
+			errDesc:    "not supported",
+			errIsFatal: false,
+		}),
+
+		Entry("423 with locked", expectation{
+			resCode:    423,
+			resBody:    `{"error": "locked", "error_description": "project is locked"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectLocked,
+			errDesc:    "project is locked",
+			errIsFatal: true,
+		}),
+
+		Entry("successfully fetched", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"missing": ["ch3ck5um2"]}`,
+			errIsNil: true,
+			result:   []string{"ch3ck5um2"},
+		}),
+	)
+
+	DescribeTable("UploadBlob",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/projects/foo-bar-express/blobs/ch3ck5um1"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Content-Type":  {"application/octet-stream"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+
+					func(w http.ResponseWriter, req *http.Request) {
+						data, err := ioutil.ReadAll(req.Body)
+						Expect(err).To(BeNil())
+						Expect(string(data)).To(Equal("hello"))
+						Expect(req.ContentLength).To(Equal(int64(5)))
+					},
+
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			tempDir, err := ioutil.TempDir("", "rise-test")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(tempDir)
+
+			path := filepath.Join(tempDir, "index.html")
+			err = ioutil.WriteFile(path, []byte("hello"), 0600)
+			Expect(err).To(BeNil())
+
+			appErr := deployments.UploadBlob("t0k3n", "foo-bar-express", "ch3ck5um1", path)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("401 with rejected token", expectation{
+			resCode:    http.StatusUnauthorized,
+			resBody:    `{"error": "invalid_token"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeAuthFailed,
+			errDesc:    "access token was rejected",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with project not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "project could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectNotFound,
+			errDesc:    "could not find a project",
+			errIsFatal: true,
+		}),
+
+		Entry("422 with checksum mismatch", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_request", "error_description": "checksum does not match"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeValidationFailed,
+			errDesc:    "changed while it was being uploaded",
+			errIsFatal: true,
+		}),
+
+		Entry("423 with locked", expectation{
+			resCode:    423,
+			resBody:    `{"error": "locked", "error_description": "project is locked"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectLocked,
+			errDesc:    "project is locked",
+			errIsFatal: true,
+		}),
+
+		Entry("successfully uploaded", expectation{
+			resCode:  http.StatusCreated,
+			resBody:  `{"uploaded": true}`,
+			errIsNil: true,
+		}),
+	)
+
+	DescribeTable("CreateWithManifest",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/deployments"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Content-Type":  {"application/json"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.VerifyJSON(`{
+						"manifest": [
+							{"path": "index.html", "checksum": "ch3ck5um1", "size": 5, "mode": 420},
+							{"path": "css/app.css", "checksum": "ch3ck5um2", "size": 7, "mode": 420}
+						]
+					}`),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			deployment, appErr := deployments.CreateWithManifest("t0k3n", "foo-bar-express", manifest)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				depl, ok := e.result.(*deployments.Deployment)
+				Expect(ok).To(BeTrue())
+				Expect(deployment.ID).To(Equal(depl.ID))
+				Expect(deployment.State).To(Equal(depl.State))
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("401 with rejected token", expectation{
+			resCode:    http.StatusUnauthorized,
+			resBody:    `{"error": "invalid_token"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeAuthFailed,
+			errDesc:    "access token was rejected",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusAccepted,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with project not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "project could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectNotFound,
+			errDesc:    "could not find a project",
+			errIsFatal: true,
+		}),
+
+		Entry("422 with missing blobs", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_request", "error_description": "some blobs are missing"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeValidationFailed,
+			errDesc:    "have not been uploaded",
+			errIsFatal: true,
+		}),
+
+		Entry("423 with locked", expectation{
+			resCode:    423,
+			resBody:    `{"error": "locked", "error_description": "project is locked"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectLocked,
+			errDesc:    "project is locked",
+			errIsFatal: true,
+		}),
+
+		Entry("successful deployment", expectation{
+			resCode:  http.StatusAccepted,
+			resBody:  `{"deployment": {"id": 123, "state": "pending_deploy"}}`,
+			errIsNil: true,
+			result:   &deployments.Deployment{ID: 123, State: "pending_deploy"},
+		}),
+	)
+
 	DescribeTable("Get",
 		func(e expectation) {
 			server.AppendHandlers(
@@ -284,6 +596,8 @@ var _ = Describe("Deployments", func() {
 				Expect(deployment.ID).To(Equal(depl.ID))
 				Expect(deployment.State).To(Equal(depl.State))
 				Expect(deployment.DeployedAt.Unix()).To(Equal(depl.DeployedAt.Unix()))
+				Expect(deployment.Size).To(Equal(depl.Size))
+				Expect(deployment.Deployer).To(Equal(depl.Deployer))
 			} else {
 				Expect(appErr).NotTo(BeNil())
 				Expect(appErr.Code).To(Equal(e.errCode))
@@ -327,11 +641,90 @@ var _ = Describe("Deployments", func() {
 						"id": 123,
 					  "state": "deployed",
 						"deployed_at": ` + formattedTime + `,
-						"error_message": "index.html:Unexpected Tag\napp.json:undefined is undefined"
+						"error_message": "index.html:Unexpected Tag\napp.json:undefined is undefined",
+						"size": 1234567,
+						"deployer": "foo@example.com"
 					}
 				}`,
 			errIsNil: true,
-			result:   &deployments.Deployment{ID: 123, State: "deployed", DeployedAt: deployedTime, ErrorMessage: "index.html:Unexpected Tag\napp.json:undefined is undefined"},
+			result:   &deployments.Deployment{ID: 123, State: "deployed", DeployedAt: deployedTime, ErrorMessage: "index.html:Unexpected Tag\napp.json:undefined is undefined", Size: 1234567, Deployer: "foo@example.com"},
+		}),
+	)
+
+	DescribeTable("GetManifest",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/projects/foo-bar-express/deployments/123/manifest"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			manifest, appErr := deployments.GetManifest("t0k3n", "foo-bar-express", 123)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(manifest).To(Equal(e.result))
+			} else {
+				Expect(manifest).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with deployment not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "deployment could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotFound,
+			errDesc:    "deployment could not be found",
+			errIsFatal: true,
+		}),
+
+		Entry("404 from a server without manifests", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotSupported,
+			errDesc:    "not supported",
+			errIsFatal: false,
+		}),
+
+		Entry("successfully fetched", expectation{
+			resCode: http.StatusOK,
+			resBody: `{"files": [
+				{"path": "index.html", "checksum": "abc123", "size": 1024, "mode": 420}
+			]}`,
+			errIsNil: true,
+			result: []*bundle.ManifestEntry{
+				{Path: "index.html", Checksum: "abc123", Size: 1024, Mode: 0644},
+			},
 		}),
 	)
 
@@ -0,0 +1,147 @@
+package deployments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/progressbar"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+// Kinds of bundle that can be downloaded
+const (
+	// The bundle as it was uploaded
+	BundleRaw = "raw"
+	// The bundle as it is served, after optimization
+	BundleOptimized = "optimized"
+)
+
+const (
+	ErrCodeBundleNotFound  = "bundle_not_found"
+	ErrCodeChecksumMissing = "checksum_missing"
+)
+
+// BundleDownload tells where to download the bundle of a deployment from.
+type BundleDownload struct {
+	// URL is pre-signed, so it must be fetched without an access token.
+	URL string `json:"url"`
+	// SHA-256 checksum of the bundle
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+}
+
+// Fetches where to download the raw or optimized bundle of a deployment from.
+// Errors with ErrCodeBundleNotFound if the deployment has no bundle of that
+// kind, and with ErrCodeNotSupported if the server does not allow bundles to be
+// downloaded.
+func GetBundleDownload(token, projectName string, deploymentID uint, kind string) (dl *BundleDownload, appErr *apperror.Error) {
+	req := api.Request{
+		Method: "GET",
+		Path:   fmt.Sprintf("/projects/%s/deployments/%d/download", projectName, deploymentID),
+		Token:  token,
+
+		QueryString: url.Values{
+			"type": {kind},
+		},
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		var j struct {
+			Download *BundleDownload `json:"download"`
+		}
+
+		if err := res.Body.FromJsonTo(&j); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+		if j.Download == nil || j.Download.URL == "" {
+			return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+		}
+
+		return j.Download, nil
+	case http.StatusNotFound:
+		switch api.DecodeError(res).ErrorDescription {
+		case "project could not be found":
+			return nil, apperror.New(ErrCodeProjectNotFound, nil, fmt.Sprintf(tr.T("project_not_found"), projectName), true)
+		case "deployment could not be found":
+			return nil, apperror.New(ErrCodeNotFound, nil, "deployment could not be found", true)
+		case "bundle could not be found":
+			return nil, apperror.New(ErrCodeBundleNotFound, nil, "bundle could not be found", true)
+		}
+	}
+
+	return nil, apperror.New(ErrCodeNotSupported, nil, "downloading bundles is not supported", true)
+}
+
+// Downloads a bundle to path, showing its progress unless quiet is set.
+// Errors with ErrCodeChecksumMismatch if the downloaded file does not match the
+// checksum of the bundle, and with ErrCodeChecksumMissing without downloading
+// anything if the bundle has no checksum to verify it against.
+func DownloadBundle(dl *BundleDownload, path string, quiet bool) (appErr *apperror.Error) {
+	if dl.Checksum == "" {
+		return apperror.New(ErrCodeChecksumMissing, nil, "bundle has no checksum", true)
+	}
+
+	req := api.Request{
+		Method:  "GET",
+		URL:     dl.URL,
+		Timeout: -1,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return apperror.New(ErrCodeUnexpectedError, nil, fmt.Sprintf("download failed with status %d", res.StatusCode), true)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+	defer f.Close()
+
+	size := dl.Size
+	if size <= 0 {
+		size = res.ContentLength
+	}
+
+	h := sha256.New()
+	var w io.Writer = io.MultiWriter(f, h)
+	if !quiet && size > 0 {
+		w = progressbar.NewWriter(w, tui.Out, size)
+	}
+
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), dl.Checksum) {
+		return apperror.New(ErrCodeChecksumMismatch, nil, "downloaded bundle does not match its checksum", true)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package deployments_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Downloads", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		server.Close()
+	})
+
+	type expectation struct {
+		resCode    int
+		resBody    string
+		errIsNil   bool
+		errCode    string
+		errDesc    string
+		errIsFatal bool
+		result     *deployments.BundleDownload
+	}
+
+	DescribeTable("GetBundleDownload",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/projects/foo-bar-express/deployments/123/download", "type=raw"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			dl, appErr := deployments.GetBundleDownload("t0k3n", "foo-bar-express", 123, deployments.BundleRaw)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(dl).To(Equal(e.result))
+			} else {
+				Expect(dl).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with deployment not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "deployment could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotFound,
+			errDesc:    "deployment could not be found",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with bundle not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "bundle could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeBundleNotFound,
+			errDesc:    "bundle could not be found",
+			errIsFatal: true,
+		}),
+
+		Entry("404 from a server without downloads", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotSupported,
+			errDesc:    "not supported",
+			errIsFatal: true,
+		}),
+
+		Entry("successful", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"download": {"url": "https://s3.example.com/bundle.tar.gz?sig=abc", "checksum": "c0ffee", "size": 1234}}`,
+			errIsNil: true,
+			result: &deployments.BundleDownload{
+				URL:      "https://s3.example.com/bundle.tar.gz?sig=abc",
+				Checksum: "c0ffee",
+				Size:     1234,
+			},
+		}),
+	)
+
+	Describe("DownloadBundle", func() {
+		var (
+			tempDir string
+			err     error
+		)
+
+		BeforeEach(func() {
+			tempDir, err = ioutil.TempDir("", "rise-test")
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		download := func(checksum string) (string, *apperror.Error) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bundle.tar.gz", "sig=abc"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.Header.Get("Authorization")).To(BeEmpty())
+					},
+					ghttp.RespondWith(http.StatusOK, "hello"),
+				),
+			)
+
+			p := filepath.Join(tempDir, "bundle.tar.gz")
+			dl := &deployments.BundleDownload{
+				URL:      server.URL() + "/bundle.tar.gz?sig=abc",
+				Checksum: checksum,
+				Size:     5,
+			}
+			return p, deployments.DownloadBundle(dl, p, true)
+		}
+
+		It("downloads the bundle without an access token", func() {
+			// SHA-256 of "hello"
+			p, appErr := download("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+			Expect(appErr).To(BeNil())
+
+			b, err := ioutil.ReadFile(p)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal("hello"))
+		})
+
+		It("accepts a checksum in uppercase", func() {
+			_, appErr := download("2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824")
+			Expect(appErr).To(BeNil())
+		})
+
+		It("errors if the checksum does not match", func() {
+			_, appErr := download("c0ffee")
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(deployments.ErrCodeChecksumMismatch))
+		})
+
+		It("errors without downloading if there is no checksum", func() {
+			dl := &deployments.BundleDownload{URL: server.URL() + "/bundle.tar.gz?sig=abc", Size: 5}
+			appErr := deployments.DownloadBundle(dl, filepath.Join(tempDir, "bundle.tar.gz"), true)
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(deployments.ErrCodeChecksumMissing))
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+})
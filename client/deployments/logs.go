@@ -0,0 +1,103 @@
+package deployments
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+// How long the server holds a request for logs when waiting for new entries
+var LogsWaitTimeout = 30 * time.Second
+
+// LogEntry is a line of output from optimizing or deploying a deployment.
+type LogEntry struct {
+	Seq       uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	// State is the state the deployment was in when the line was logged
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// Logs holds the log entries of a deployment after a given sequence number,
+// along with the deployment's current state.
+type Logs struct {
+	Entries      []*LogEntry `json:"logs"`
+	State        string      `json:"state"`
+	ErrorMessage string      `json:"error_message,omitempty"`
+}
+
+// Returns the sequence number of the last entry, or after if there are none
+func (l *Logs) LastSeq(after uint64) uint64 {
+	if len(l.Entries) == 0 {
+		return after
+	}
+	return l.Entries[len(l.Entries)-1].Seq
+}
+
+// Returns whether the deployment has either been deployed or failed
+func (d *Deployment) Finished() bool {
+	return Finished(d.State)
+}
+
+// Returns whether a deployment in the given state has either been deployed or
+// failed
+func Finished(state string) bool {
+	return state == DeploymentStateDeployed || state == DeploymentStateDeployFailed
+}
+
+// Fetches the log entries of a deployment with sequence numbers greater than
+// after. If wait is set and there are no such entries yet, the server holds
+// the request for up to LogsWaitTimeout until there are, or until the
+// deployment's state changes. Errors with ErrCodeNotSupported if the server
+// does not keep deployment logs.
+func GetLogs(token, projectName string, deploymentID uint, after uint64, wait bool) (logs *Logs, appErr *apperror.Error) {
+	req := api.Request{
+		Method: "GET",
+		Path:   fmt.Sprintf("/projects/%s/deployments/%d/logs", projectName, deploymentID),
+		Token:  token,
+
+		QueryString: url.Values{
+			"after": {strconv.FormatUint(after, 10)},
+		},
+	}
+	if wait {
+		req.QueryString.Set("wait", strconv.Itoa(int(LogsWaitTimeout/time.Second)))
+		req.Timeout = LogsWaitTimeout + api.DefaultTimeout
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented}, res.StatusCode) {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		switch api.DecodeError(res).ErrorDescription {
+		case "project could not be found":
+			return nil, apperror.New(ErrCodeProjectNotFound, nil, "project could not be found", true)
+		case "deployment could not be found":
+			return nil, apperror.New(ErrCodeNotFound, nil, "deployment could not be found", true)
+		}
+		return nil, apperror.New(ErrCodeNotSupported, nil, "deployment logs are not supported", false)
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, apperror.New(ErrCodeNotSupported, nil, "deployment logs are not supported", false)
+	}
+
+	logs = &Logs{}
+	if err := res.Body.FromJsonTo(logs); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,158 @@
+package deployments_test
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Logs", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		server.Close()
+	})
+
+	type expectation struct {
+		wait       bool
+		query      string
+		resCode    int
+		resBody    string
+		errIsNil   bool
+		errCode    string
+		errDesc    string
+		errIsFatal bool
+		result     *deployments.Logs
+	}
+
+	DescribeTable("GetLogs",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/projects/foo-bar-express/deployments/123/logs", e.query),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			logs, appErr := deployments.GetLogs("t0k3n", "foo-bar-express", 123, 7, e.wait)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(logs).To(Equal(e.result))
+			} else {
+				Expect(logs).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			query:      "after=7",
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			query:      "after=7",
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with project not found", expectation{
+			query:      "after=7",
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "project could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectNotFound,
+			errDesc:    "project could not be found",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with deployment not found", expectation{
+			query:      "after=7",
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "deployment could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotFound,
+			errDesc:    "deployment could not be found",
+			errIsFatal: true,
+		}),
+
+		Entry("404 from a server without deployment logs", expectation{
+			query:      "after=7",
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotSupported,
+			errDesc:    "not supported",
+			errIsFatal: false,
+		}),
+
+		Entry("successful", expectation{
+			query:    "after=7",
+			resCode:  http.StatusOK,
+			resBody:  `{"logs": [{"seq": 8, "timestamp": "2016-05-01T10:00:00Z", "state": "pending_build", "message": "Optimizing index.html"}], "state": "pending_build"}`,
+			errIsNil: true,
+			result: &deployments.Logs{
+				Entries: []*deployments.LogEntry{
+					{Seq: 8, Timestamp: time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC), State: "pending_build", Message: "Optimizing index.html"},
+				},
+				State: deployments.DeploymentStateBuilding,
+			},
+		}),
+
+		Entry("successful with wait", expectation{
+			wait:     true,
+			query:    "after=7&wait=30",
+			resCode:  http.StatusOK,
+			resBody:  `{"logs": [], "state": "deploy_failed", "error_message": "index.html is too large"}`,
+			errIsNil: true,
+			result: &deployments.Logs{
+				Entries:      []*deployments.LogEntry{},
+				State:        deployments.DeploymentStateDeployFailed,
+				ErrorMessage: "index.html is too large",
+			},
+		}),
+	)
+
+	It("returns the sequence number of the last entry", func() {
+		logs := &deployments.Logs{}
+		Expect(logs.LastSeq(7)).To(Equal(uint64(7)))
+
+		logs.Entries = []*deployments.LogEntry{{Seq: 8}, {Seq: 9}}
+		Expect(logs.LastSeq(7)).To(Equal(uint64(9)))
+	})
+})
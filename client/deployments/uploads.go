@@ -0,0 +1,393 @@
+package deployments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/progressbar"
+	"github.com/nitrous-io/rise-cli-go/tr"
+	"github.com/nitrous-io/rise-cli-go/tui"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+const (
+	ErrCodeUploadNotFound   = "upload_not_found"
+	ErrCodeChecksumMismatch = "checksum_mismatch"
+	ErrCodeServerError      = "server_error"
+
+	DefaultChunkSize = int64(5 * 1024 * 1024) // 5 MiB
+)
+
+var (
+	// Number of times a chunk is retried before the upload is given up on
+	ChunkRetries = 5
+	// Delay before the first retry of a chunk. It doubles with every retry, up
+	// to MaxRetryDelay.
+	RetryBaseDelay = 1 * time.Second
+	MaxRetryDelay  = 30 * time.Second
+)
+
+// Upload is a bundle being uploaded in chunks.
+type Upload struct {
+	ID             string `json:"id"`
+	ChunkSize      int64  `json:"chunk_size"`
+	ReceivedChunks []int  `json:"received_chunks"`
+}
+
+// uploadState is persisted so that an interrupted upload of the same bundle can
+// be resumed.
+type uploadState struct {
+	UploadID string `json:"upload_id"`
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+}
+
+// Starts a chunked upload of a bundle with the given SHA-256 checksum and size
+func InitiateUpload(token, name, checksum string, size, chunkSize int64) (upload *Upload, appErr *apperror.Error) {
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/projects/" + name + "/uploads",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: url.Values{
+			"checksum":   {checksum},
+			"size":       {strconv.FormatInt(size, 10)},
+			"chunk_size": {strconv.FormatInt(chunkSize, 10)},
+		}.Encode(),
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusCreated, http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented, 423}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusCreated:
+		var j struct {
+			Upload *Upload `json:"upload"`
+		}
+
+		if err := res.Body.FromJsonTo(&j); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+
+		if j.Upload == nil || j.Upload.ID == "" || j.Upload.ChunkSize <= 0 {
+			return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+		}
+
+		return j.Upload, nil
+	case http.StatusBadRequest:
+		if e := api.DecodeError(res); e.ErrorDescription == "request body is too large" {
+			return nil, apperror.New(ErrCodeValidationFailed, nil, "project size is too large", true)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	case http.StatusNotFound:
+		if e := api.DecodeError(res); e.ErrorDescription == "project could not be found" {
+			return nil, apperror.New(ErrCodeProjectNotFound, nil, fmt.Sprintf(tr.T("project_not_found"), name), true)
+		}
+	case 423:
+		return nil, apperror.New(ErrCodeProjectLocked, nil, "project is locked", true)
+	}
+
+	return nil, apperror.New(ErrCodeNotSupported, nil, "chunked uploads are not supported", false)
+}
+
+// Fetches a chunked upload, including which chunks the server has received
+func GetUpload(token, name, uploadID string) (upload *Upload, appErr *apperror.Error) {
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + name + "/uploads/" + uploadID,
+		Token:  token,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, apperror.New(ErrCodeUploadNotFound, nil, "upload could not be found", false)
+	}
+
+	var j struct {
+		Upload *Upload `json:"upload"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if j.Upload == nil || j.Upload.ChunkSize <= 0 {
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return j.Upload, nil
+}
+
+// Uploads the chunk with the given index. Errors with the codes
+// ErrCodeRequestFailed, ErrCodeServerError and ErrCodeChecksumMismatch are
+// worth retrying.
+func UploadChunk(token, name, uploadID string, index int, chunk []byte) (appErr *apperror.Error) {
+	sum := sha256.Sum256(chunk)
+
+	req := api.Request{
+		Method:      "PUT",
+		Path:        fmt.Sprintf("/projects/%s/uploads/%s/chunks/%d", name, uploadID, index),
+		Token:       token,
+		ContentType: "application/octet-stream",
+
+		Body: chunk,
+
+		// Chunks are retried by uploadChunkWithRetry.
+		NoRetry: true,
+	}
+	req.AddHeader("X-Checksum-Sha256", hex.EncodeToString(sum[:]))
+
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", false)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		return apperror.New(ErrCodeServerError, nil, fmt.Sprintf("server responded with %d", res.StatusCode), false)
+	}
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusCreated, http.StatusNotFound, 422, 423}, res.StatusCode) {
+		return unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return apperror.New(ErrCodeUploadNotFound, nil, "upload could not be found", false)
+	case 422:
+		return apperror.New(ErrCodeChecksumMismatch, nil, "chunk checksum did not match", false)
+	case 423:
+		return apperror.New(ErrCodeProjectLocked, nil, "project is locked", true)
+	}
+
+	return nil
+}
+
+// Completes a chunked upload and creates a deployment from the uploaded bundle
+func FinalizeUpload(token, name, uploadID string) (depl *Deployment, appErr *apperror.Error) {
+	req := api.Request{
+		Method: "POST",
+		Path:   "/projects/" + name + "/uploads/" + uploadID + "/finalize",
+		Token:  token,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusAccepted, http.StatusNotFound, 422, 423}, res.StatusCode) {
+		return nil, unexpectedStatus(res, err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusAccepted:
+		var j struct {
+			Deployment Deployment `json:"deployment"`
+		}
+
+		if err := res.Body.FromJsonTo(&j); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+
+		return &j.Deployment, nil
+	case http.StatusNotFound:
+		return nil, apperror.New(ErrCodeUploadNotFound, nil, "upload could not be found", true)
+	case 422:
+		switch api.DecodeError(res).ErrorDescription {
+		case "upload is incomplete":
+			return nil, apperror.New(ErrCodeValidationFailed, nil, "upload is incomplete", true)
+		case "checksum mismatch":
+			return nil, apperror.New(ErrCodeChecksumMismatch, nil, "uploaded bundle does not match its checksum", true)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	case 423:
+		return nil, apperror.New(ErrCodeProjectLocked, nil, "project is locked", true)
+	}
+
+	return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+}
+
+// Uploads a bundle in chunks, retrying failed chunks, and creates a deployment
+// from it. If an earlier upload of the same bundle was interrupted, only the
+// chunks the server has not received are uploaded. Falls back to Create if the
+// server does not support chunked uploads.
+func CreateChunked(token, name, bunPath string, quiet bool) (depl *Deployment, appErr *apperror.Error) {
+	checksum, err := bundle.Sha256Sum(bunPath)
+	if err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	fi, err := os.Stat(bunPath)
+	if err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	statePath := uploadStatePath(name, checksum)
+
+	var upload *Upload
+	if state, err := loadUploadState(statePath); err == nil && state.Size == fi.Size() {
+		upload, appErr = GetUpload(token, name, state.UploadID)
+		if appErr != nil && appErr.Code != ErrCodeUploadNotFound {
+			return nil, appErr
+		}
+	}
+
+	if upload == nil {
+		upload, appErr = InitiateUpload(token, name, checksum, fi.Size(), DefaultChunkSize)
+		if appErr != nil {
+			if appErr.Code == ErrCodeNotSupported {
+				return Create(token, name, bunPath, quiet)
+			}
+			return nil, appErr
+		}
+
+		if err := saveUploadState(statePath, &uploadState{UploadID: upload.ID, Checksum: checksum, Size: fi.Size()}); err != nil {
+			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		}
+	}
+
+	if appErr := uploadChunks(token, name, bunPath, fi.Size(), upload, quiet); appErr != nil {
+		return nil, appErr
+	}
+
+	depl, appErr = FinalizeUpload(token, name, upload.ID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	os.Remove(statePath)
+
+	return depl, nil
+}
+
+func uploadChunks(token, name, bunPath string, size int64, upload *Upload, quiet bool) *apperror.Error {
+	f, err := os.Open(bunPath)
+	if err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+	defer f.Close()
+
+	received := map[int]bool{}
+	for _, i := range upload.ReceivedChunks {
+		received[i] = true
+	}
+
+	chunkCount := int((size + upload.ChunkSize - 1) / upload.ChunkSize)
+
+	var pb *progressbar.Counter
+	if !quiet {
+		pb = progressbar.NewCounter(tui.Out, chunkCount)
+	}
+
+	chunk := make([]byte, upload.ChunkSize)
+	for i := 0; i < chunkCount; i++ {
+		if !received[i] {
+			n, err := f.ReadAt(chunk, int64(i)*upload.ChunkSize)
+			if err != nil && err != io.EOF {
+				return apperror.New(ErrCodeUnexpectedError, err, "", true)
+			}
+
+			if appErr := uploadChunkWithRetry(token, name, upload.ID, i, chunk[:n]); appErr != nil {
+				return appErr
+			}
+		}
+
+		if pb != nil {
+			pb.Next()
+		}
+	}
+
+	return nil
+}
+
+func uploadChunkWithRetry(token, name, uploadID string, index int, chunk []byte) (appErr *apperror.Error) {
+	delay := RetryBaseDelay
+
+	for attempt := 0; ; attempt++ {
+		appErr = UploadChunk(token, name, uploadID, index, chunk)
+		if appErr == nil {
+			return nil
+		}
+
+		switch appErr.Code {
+		case ErrCodeRequestFailed, ErrCodeServerError, ErrCodeChecksumMismatch:
+			if attempt < ChunkRetries {
+				break
+			}
+			fallthrough
+		default:
+			appErr.IsFatal = true
+			return appErr
+		}
+
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > MaxRetryDelay {
+			delay = MaxRetryDelay
+		}
+	}
+}
+
+func uploadStatePath(name, checksum string) string {
+	return filepath.Join(config.DotRisePath, "uploads", name+"-"+checksum+".json")
+}
+
+func loadUploadState(path string) (*uploadState, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state uploadState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func saveUploadState(path string, state *uploadState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0600)
+}
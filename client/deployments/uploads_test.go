@@ -0,0 +1,441 @@
+package deployments_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/client/deployments"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Chunked uploads", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		server.Close()
+	})
+
+	type expectation struct {
+		resCode    int
+		resBody    string
+		errIsNil   bool
+		errCode    string
+		errDesc    string
+		errIsFatal bool
+		result     interface{}
+	}
+
+	DescribeTable("InitiateUpload",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/uploads"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.VerifyForm(url.Values{
+						"checksum":   {"c4ecc5"},
+						"size":       {"12"},
+						"chunk_size": {"4"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			upload, appErr := deployments.InitiateUpload("t0k3n", "foo-bar-express", "c4ecc5", 12, 4)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(upload).To(Equal(e.result))
+			} else {
+				Expect(upload).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusCreated,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with project not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "project could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectNotFound,
+			errDesc:    "could not find a project",
+			errIsFatal: true,
+		}),
+
+		Entry("404 from a server without chunked uploads", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeNotSupported,
+			errDesc:    "not supported",
+			errIsFatal: false,
+		}),
+
+		Entry("400 with request body too large", expectation{
+			resCode:    http.StatusBadRequest,
+			resBody:    `{"error": "invalid_request", "error_description": "request body is too large"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeValidationFailed,
+			errDesc:    "project size is too large",
+			errIsFatal: true,
+		}),
+
+		Entry("423 Locked", expectation{
+			resCode:    423,
+			resBody:    `{"error": "locked", "error_description": "project is locked"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectLocked,
+			errDesc:    "project is locked",
+			errIsFatal: true,
+		}),
+
+		Entry("201 Created", expectation{
+			resCode:  http.StatusCreated,
+			resBody:  `{"upload": {"id": "abc123", "chunk_size": 4, "received_chunks": []}}`,
+			errIsNil: true,
+			result:   &deployments.Upload{ID: "abc123", ChunkSize: 4, ReceivedChunks: []int{}},
+		}),
+	)
+
+	DescribeTable("UploadChunk",
+		func(e expectation) {
+			sum := sha256.Sum256([]byte("body"))
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/projects/foo-bar-express/uploads/abc123/chunks/2"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization":     {"Bearer t0k3n"},
+						"Accept":            {config.ReqAccept},
+						"User-Agent":        {config.UserAgent},
+						"Content-Type":      {"application/octet-stream"},
+						"X-Checksum-Sha256": {hex.EncodeToString(sum[:])},
+					}),
+					func(w http.ResponseWriter, req *http.Request) {
+						b, err := ioutil.ReadAll(req.Body)
+						Expect(err).To(BeNil())
+						Expect(string(b)).To(Equal("body"))
+					},
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			appErr := deployments.UploadChunk("t0k3n", "foo-bar-express", "abc123", 2, []byte("body"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("5xx", expectation{
+			resCode:    http.StatusBadGateway,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeServerError,
+			errDesc:    "502",
+			errIsFatal: false,
+		}),
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusForbidden,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 with upload not found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "upload could not be found"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUploadNotFound,
+			errDesc:    "upload could not be found",
+			errIsFatal: false,
+		}),
+
+		Entry("422 with checksum mismatch", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_request", "error_description": "checksum mismatch"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeChecksumMismatch,
+			errDesc:    "checksum did not match",
+			errIsFatal: false,
+		}),
+
+		Entry("200 OK", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"received": true}`,
+			errIsNil: true,
+		}),
+	)
+
+	DescribeTable("FinalizeUpload",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/uploads/abc123/finalize"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			depl, appErr := deployments.FinalizeUpload("t0k3n", "foo-bar-express", "abc123")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(depl).To(Equal(e.result))
+			} else {
+				Expect(depl).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("422 with incomplete upload", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_request", "error_description": "upload is incomplete"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeValidationFailed,
+			errDesc:    "upload is incomplete",
+			errIsFatal: true,
+		}),
+
+		Entry("422 with checksum mismatch", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_request", "error_description": "checksum mismatch"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeChecksumMismatch,
+			errDesc:    "does not match its checksum",
+			errIsFatal: true,
+		}),
+
+		Entry("423 Locked", expectation{
+			resCode:    423,
+			resBody:    `{"error": "locked", "error_description": "project is locked"}`,
+			errIsNil:   false,
+			errCode:    deployments.ErrCodeProjectLocked,
+			errDesc:    "project is locked",
+			errIsFatal: true,
+		}),
+
+		Entry("202 Accepted", expectation{
+			resCode:  http.StatusAccepted,
+			resBody:  `{"deployment": {"id": 123, "state": "uploaded"}}`,
+			errIsNil: true,
+			result:   &deployments.Deployment{ID: 123, State: "uploaded"},
+		}),
+	)
+
+	Describe("CreateChunked", func() {
+		var (
+			tempDir    string
+			bunPath    string
+			statePath  string
+			checksum   string
+			origDotDir string
+			origDelay  time.Duration
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "rise-test")
+			Expect(err).To(BeNil())
+
+			origDotDir = config.DotRisePath
+			config.DotRisePath = tempDir
+
+			origDelay = deployments.RetryBaseDelay
+			deployments.RetryBaseDelay = time.Millisecond
+
+			bunPath = filepath.Join(tempDir, "bundle.tar.gz")
+			Expect(ioutil.WriteFile(bunPath, []byte("my-bundle-yo"), 0600)).To(Succeed())
+
+			sum := sha256.Sum256([]byte("my-bundle-yo"))
+			checksum = hex.EncodeToString(sum[:])
+			statePath = filepath.Join(tempDir, "uploads", "foo-bar-express-"+checksum+".json")
+		})
+
+		AfterEach(func() {
+			config.DotRisePath = origDotDir
+			deployments.RetryBaseDelay = origDelay
+			os.RemoveAll(tempDir)
+		})
+
+		verifyChunk := func(index, body string) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/projects/foo-bar-express/uploads/abc123/chunks/"+index),
+				func(w http.ResponseWriter, req *http.Request) {
+					b, err := ioutil.ReadAll(req.Body)
+					Expect(err).To(BeNil())
+					Expect(string(b)).To(Equal(body))
+				},
+			)
+		}
+
+		finalize := ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/projects/foo-bar-express/uploads/abc123/finalize"),
+			ghttp.RespondWith(http.StatusAccepted, `{"deployment": {"id": 123, "state": "uploaded"}}`),
+		)
+
+		It("uploads every chunk, retrying failed ones, and finalizes the upload", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/uploads"),
+					ghttp.RespondWith(http.StatusCreated, `{"upload": {"id": "abc123", "chunk_size": 5, "received_chunks": []}}`),
+				),
+				ghttp.CombineHandlers(
+					verifyChunk("0", "my-bu"),
+					func(w http.ResponseWriter, req *http.Request) {
+						// The upload state is saved before any chunk is sent.
+						_, err := os.Stat(statePath)
+						Expect(err).To(BeNil())
+					},
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				),
+				ghttp.CombineHandlers(verifyChunk("1", "ndle-"), ghttp.RespondWith(http.StatusServiceUnavailable, "")),
+				ghttp.CombineHandlers(verifyChunk("1", "ndle-"), ghttp.RespondWith(http.StatusOK, `{}`)),
+				ghttp.CombineHandlers(verifyChunk("2", "yo"), ghttp.RespondWith(http.StatusOK, `{}`)),
+				finalize,
+			)
+
+			depl, appErr := deployments.CreateChunked("t0k3n", "foo-bar-express", bunPath, true)
+			Expect(appErr).To(BeNil())
+			Expect(depl).To(Equal(&deployments.Deployment{ID: 123, State: "uploaded"}))
+			Expect(server.ReceivedRequests()).To(HaveLen(6))
+
+			_, err := os.Stat(statePath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("resumes an interrupted upload of the same bundle", func() {
+			Expect(os.MkdirAll(filepath.Dir(statePath), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(statePath, []byte(`{"upload_id": "abc123", "checksum": "`+checksum+`", "size": 12}`), 0600)).To(Succeed())
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/projects/foo-bar-express/uploads/abc123"),
+					ghttp.RespondWith(http.StatusOK, `{"upload": {"id": "abc123", "chunk_size": 5, "received_chunks": [0, 1]}}`),
+				),
+				ghttp.CombineHandlers(verifyChunk("2", "yo"), ghttp.RespondWith(http.StatusOK, `{}`)),
+				finalize,
+			)
+
+			depl, appErr := deployments.CreateChunked("t0k3n", "foo-bar-express", bunPath, true)
+			Expect(appErr).To(BeNil())
+			Expect(depl).To(Equal(&deployments.Deployment{ID: 123, State: "uploaded"}))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("gives up on a chunk after the maximum number of retries", func() {
+			origRetries := deployments.ChunkRetries
+			deployments.ChunkRetries = 1
+			defer func() { deployments.ChunkRetries = origRetries }()
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/uploads"),
+					ghttp.RespondWith(http.StatusCreated, `{"upload": {"id": "abc123", "chunk_size": 5, "received_chunks": []}}`),
+				),
+				ghttp.CombineHandlers(verifyChunk("0", "my-bu"), ghttp.RespondWith(http.StatusInternalServerError, "")),
+				ghttp.CombineHandlers(verifyChunk("0", "my-bu"), ghttp.RespondWith(http.StatusInternalServerError, "")),
+			)
+
+			depl, appErr := deployments.CreateChunked("t0k3n", "foo-bar-express", bunPath, true)
+			Expect(depl).To(BeNil())
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(deployments.ErrCodeServerError))
+			Expect(appErr.IsFatal).To(BeTrue())
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+
+			// The state is kept so that the upload can be resumed.
+			_, err := os.Stat(statePath)
+			Expect(err).To(BeNil())
+		})
+
+		It("falls back to a single request if chunked uploads are not supported", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/uploads"),
+					ghttp.RespondWith(http.StatusNotFound, `{"error": "not_found"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/projects/foo-bar-express/deployments"),
+					ghttp.RespondWith(http.StatusAccepted, `{"deployment": {"id": 123, "state": "uploaded"}}`),
+				),
+			)
+
+			depl, appErr := deployments.CreateChunked("t0k3n", "foo-bar-express", bunPath, true)
+			Expect(appErr).To(BeNil())
+			Expect(depl).To(Equal(&deployments.Deployment{ID: 123, State: "uploaded"}))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+})
@@ -4,9 +4,8 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -20,13 +19,11 @@ const (
 )
 
 func Index(token, projectName string) (domainNames []string, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + projectName + "/domains",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + projectName + "/domains",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 
 	if err != nil {
@@ -38,16 +35,10 @@ func Index(token, projectName string) (domainNames []string, appErr *apperror.Er
 	}
 
 	if res.StatusCode == http.StatusNotFound {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		if errDesc, ok := j["error_description"].(string); ok {
-			return nil, apperror.New(ErrCodeNotFound, nil, errDesc, true)
-		} else {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+		if e := api.DecodeError(res); e.ErrorDescription != "" {
+			return nil, e.AppError(ErrCodeNotFound, true)
 		}
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
 	var j map[string][]string
@@ -59,19 +50,17 @@ func Index(token, projectName string) (domainNames []string, appErr *apperror.Er
 }
 
 func Create(token, projectName, name string) (appErr *apperror.Error) {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects/" + projectName + "/domains",
+		Path:        "/projects/" + projectName + "/domains",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"name": {name},
 		}.Encode(),
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 
 	if err != nil {
@@ -86,42 +75,38 @@ func Create(token, projectName, name string) (appErr *apperror.Error) {
 		return apperror.New(ErrCodeNotFound, nil, "project could not be found", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" {
-			return apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), false)
+		e := api.DecodeError(res)
+		if e.Err == "invalid_params" {
+			return e.AppError(ErrCodeValidationFailed, false)
 		}
 
-		if j["error"] == "invalid_request" {
-			if j["error_description"] == "project cannot have more domains" {
+		if e.Err == "invalid_request" {
+			if e.ErrorDescription == "project cannot have more domains" {
 				return apperror.New(ErrCodeLimitReached, nil, "", true)
-			} else if errDesc, ok := j["error_description"].(string); ok {
-				return apperror.New(ErrCodeUnexpectedError, nil, errDesc, true)
-			} else {
-				return apperror.New(ErrCodeUnexpectedError, nil, "", true)
 			}
+			return e.AppError(ErrCodeUnexpectedError, true)
 		}
 		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
 	} else if res.StatusCode == 423 {
 		return apperror.New(ErrCodeProjectLocked, nil, "", true)
 	}
 
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	return nil
 }
 
 func Delete(token, projectName, name string) (appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/projects/" + projectName + "/domains/" + name,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/projects/" + projectName + "/domains/" + name,
+		Token:  token,
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 
 	if err != nil {
@@ -132,13 +117,8 @@ func Delete(token, projectName, name string) (appErr *apperror.Error) {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == http.StatusNotFound {
-		return apperror.New(ErrCodeNotFound, nil, j["error_description"].(string), true)
+		return api.DecodeError(res).AppError(ErrCodeNotFound, true)
 	} else if res.StatusCode == 423 {
 		return apperror.New(ErrCodeProjectLocked, nil, "", true)
 	}
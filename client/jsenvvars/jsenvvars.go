@@ -5,10 +5,9 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/client/deployments"
-	"github.com/nitrous-io/rise-cli-go/config"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -20,16 +19,13 @@ const (
 )
 
 func Add(token, projectName string, vars map[string]string) (d *deployments.Deployment, appErr *apperror.Error) {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "PUT",
-		Uri:         config.Host + "/projects/" + projectName + "/jsenvvars/add",
+		Path:        "/projects/" + projectName + "/jsenvvars/add",
+		Token:       token,
 		ContentType: "application/json",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
-
 	b, err := json.Marshal(vars)
 	if err != nil {
 		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -65,20 +61,17 @@ func Add(token, projectName string, vars map[string]string) (d *deployments.Depl
 }
 
 func Delete(token, projectName string, keys []string) (d *deployments.Deployment, appErr *apperror.Error) {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "PUT",
-		Uri:         config.Host + "/projects/" + projectName + "/jsenvvars/delete",
+		Path:        "/projects/" + projectName + "/jsenvvars/delete",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"keys": keys,
 		}.Encode(),
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
-
 	res, err := req.Do()
 	if err != nil {
 		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -107,15 +100,12 @@ func Delete(token, projectName string, keys []string) (d *deployments.Deployment
 }
 
 func List(token, projectName string) (envvars *map[string]string, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + projectName + "/jsenvvars",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + projectName + "/jsenvvars",
+		Token:  token,
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
-
 	res, err := req.Do()
 	if err != nil {
 		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+const (
+	// Poll interval used when the server does not give one
+	DefaultDevicePollInterval = 5 * time.Second
+
+	// Added to the poll interval every time the server asks to slow down
+	slowDownIncrement = 5 * time.Second
+)
+
+// DeviceCode is a pending device authorization (RFC 8628). The user enters
+// UserCode at VerificationURI on another device, while the CLI polls for the
+// token with DeviceCode.
+type DeviceCode struct {
+	DeviceCode      string
+	UserCode        string
+	VerificationURI string
+	// VerificationURIComplete includes the user code, if the server gives it
+	VerificationURIComplete string
+
+	ExpiresAt time.Time
+	// Interval is how long to wait between polls
+	Interval time.Duration
+}
+
+// Starts a device authorization
+func RequestDeviceCode() (*DeviceCode, *apperror.Error) {
+	res, err := api.Request{
+		Method:      "POST",
+		Path:        "/oauth/device/code",
+		ContentType: "application/x-www-form-urlencoded",
+
+		BasicAuthUsername: config.ClientID,
+		BasicAuthPassword: config.ClientSecret,
+
+		Body: url.Values{"client_id": {config.ClientID}}.Encode(),
+	}.Do()
+
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusUnauthorized}, res.StatusCode) {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return nil, apperror.New(ErrCodeOAuthMisconfigured, nil, "OAuth Client ID/Secret misconfigured", true)
+	}
+
+	var j struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if j.DeviceCode == "" || j.UserCode == "" || j.VerificationURI == "" || j.ExpiresIn <= 0 {
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	dc := &DeviceCode{
+		DeviceCode:              j.DeviceCode,
+		UserCode:                j.UserCode,
+		VerificationURI:         j.VerificationURI,
+		VerificationURIComplete: j.VerificationURIComplete,
+		ExpiresAt:               time.Now().Add(time.Duration(j.ExpiresIn) * time.Second),
+		Interval:                time.Duration(j.Interval) * time.Second,
+	}
+	if dc.Interval <= 0 {
+		dc.Interval = DefaultDevicePollInterval
+	}
+
+	return dc, nil
+}
+
+// Asks for the token of a device authorization once. Until the user has
+// approved it, this fails with ErrCodeAuthorizationPending, or ErrCodeSlowDown
+// if polling too often.
+func FetchDeviceToken(deviceCode string) (token *Token, appErr *apperror.Error) {
+	token, errRes, appErr := requestToken(url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+	})
+
+	if errRes != nil {
+		switch errRes.Err {
+		case "authorization_pending":
+			return nil, apperror.New(ErrCodeAuthorizationPending, nil, "authorization is pending", false)
+		case "slow_down":
+			return nil, apperror.New(ErrCodeSlowDown, nil, "polling too often", false)
+		case "access_denied":
+			return nil, apperror.New(ErrCodeAccessDenied, nil, "access was denied", false)
+		case "expired_token":
+			return nil, apperror.New(ErrCodeExpiredToken, nil, "the code has expired", false)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return token, appErr
+}
+
+// Polls for the token of a device authorization until the user approves or
+// denies it, or it expires
+func WaitForDeviceToken(dc *DeviceCode) (token *Token, appErr *apperror.Error) {
+	for {
+		if time.Now().After(dc.ExpiresAt) {
+			return nil, apperror.New(ErrCodeExpiredToken, nil, "the code has expired", false)
+		}
+
+		time.Sleep(dc.Interval)
+
+		token, appErr := FetchDeviceToken(dc.DeviceCode)
+		if appErr == nil {
+			return token, nil
+		}
+
+		switch appErr.Code {
+		case ErrCodeAuthorizationPending:
+		case ErrCodeSlowDown:
+			dc.Interval += slowDownIncrement
+		default:
+			return nil, appErr
+		}
+	}
+}
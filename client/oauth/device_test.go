@@ -0,0 +1,160 @@
+package oauth_test
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/client/oauth"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Device login", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		server.Close()
+	})
+
+	tokenHandler := func(resCode int, resBody string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/oauth/token"),
+			ghttp.VerifyBasicAuth(config.ClientID, config.ClientSecret),
+			ghttp.VerifyForm(url.Values{
+				"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+				"device_code": {"d3v1c3"},
+			}),
+			ghttp.RespondWith(resCode, resBody),
+		)
+	}
+
+	Describe("RequestDeviceCode()", func() {
+		It("returns the codes to show and poll with", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/oauth/device/code"),
+					ghttp.VerifyBasicAuth(config.ClientID, config.ClientSecret),
+					ghttp.RespondWith(http.StatusOK, `{
+						"device_code": "d3v1c3",
+						"user_code": "WDJB-MJHT",
+						"verification_uri": "https://example.com/device",
+						"verification_uri_complete": "https://example.com/device?user_code=WDJB-MJHT",
+						"expires_in": 900
+					}`),
+				),
+			)
+
+			dc, appErr := oauth.RequestDeviceCode()
+			Expect(appErr).To(BeNil())
+			Expect(dc.DeviceCode).To(Equal("d3v1c3"))
+			Expect(dc.UserCode).To(Equal("WDJB-MJHT"))
+			Expect(dc.VerificationURI).To(Equal("https://example.com/device"))
+			Expect(dc.VerificationURIComplete).To(Equal("https://example.com/device?user_code=WDJB-MJHT"))
+			Expect(dc.ExpiresAt).To(BeTemporally("~", time.Now().Add(900*time.Second), time.Minute))
+			Expect(dc.Interval).To(Equal(oauth.DefaultDevicePollInterval))
+		})
+
+		It("returns an error if the response is incomplete", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"device_code": "d3v1c3"}`))
+
+			dc, appErr := oauth.RequestDeviceCode()
+			Expect(dc).To(BeNil())
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(oauth.ErrCodeUnexpectedError))
+		})
+
+		It("returns an error if the client is misconfigured", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, `{"error": "invalid_client"}`))
+
+			_, appErr := oauth.RequestDeviceCode()
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(oauth.ErrCodeOAuthMisconfigured))
+		})
+	})
+
+	DescribeTable("FetchDeviceToken",
+		func(resCode int, resBody, errCode, tokenRecvd string) {
+			server.AppendHandlers(tokenHandler(resCode, resBody))
+
+			token, appErr := oauth.FetchDeviceToken("d3v1c3")
+			if errCode == "" {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(errCode))
+			}
+			if tokenRecvd == "" {
+				Expect(token).To(BeNil())
+			} else {
+				Expect(token.AccessToken).To(Equal(tokenRecvd))
+			}
+		},
+
+		Entry("pending", http.StatusBadRequest, `{"error": "authorization_pending"}`, oauth.ErrCodeAuthorizationPending, ""),
+		Entry("slow down", http.StatusBadRequest, `{"error": "slow_down"}`, oauth.ErrCodeSlowDown, ""),
+		Entry("denied", http.StatusBadRequest, `{"error": "access_denied"}`, oauth.ErrCodeAccessDenied, ""),
+		Entry("expired", http.StatusBadRequest, `{"error": "expired_token"}`, oauth.ErrCodeExpiredToken, ""),
+		Entry("unexpected error", http.StatusBadRequest, `{"error": "invalid_grant"}`, oauth.ErrCodeUnexpectedError, ""),
+		Entry("approved", http.StatusOK, `{"access_token": "t0k3n", "token_type": "bearer"}`, "", "t0k3n"),
+	)
+
+	Describe("WaitForDeviceToken()", func() {
+		var dc *oauth.DeviceCode
+
+		BeforeEach(func() {
+			dc = &oauth.DeviceCode{
+				DeviceCode: "d3v1c3",
+				ExpiresAt:  time.Now().Add(time.Minute),
+				Interval:   time.Millisecond,
+			}
+		})
+
+		It("polls until the user approves", func() {
+			server.AppendHandlers(
+				tokenHandler(http.StatusBadRequest, `{"error": "authorization_pending"}`),
+				tokenHandler(http.StatusBadRequest, `{"error": "authorization_pending"}`),
+				tokenHandler(http.StatusOK, `{"access_token": "t0k3n", "token_type": "bearer"}`),
+			)
+
+			token, appErr := oauth.WaitForDeviceToken(dc)
+			Expect(appErr).To(BeNil())
+			Expect(token.AccessToken).To(Equal("t0k3n"))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("stops polling if the user denies access", func() {
+			server.AppendHandlers(
+				tokenHandler(http.StatusBadRequest, `{"error": "authorization_pending"}`),
+				tokenHandler(http.StatusBadRequest, `{"error": "access_denied"}`),
+			)
+
+			_, appErr := oauth.WaitForDeviceToken(dc)
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(oauth.ErrCodeAccessDenied))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("does not poll once the code has expired", func() {
+			dc.ExpiresAt = time.Now().Add(-time.Second)
+
+			_, appErr := oauth.WaitForDeviceToken(dc)
+			Expect(appErr).NotTo(BeNil())
+			Expect(appErr.Code).To(Equal(oauth.ErrCodeExpiredToken))
+			Expect(server.ReceivedRequests()).To(HaveLen(0))
+		})
+	})
+})
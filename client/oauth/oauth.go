@@ -3,9 +3,10 @@ package oauth
 import (
 	"net/http"
 	"net/url"
+	"time"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/config"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
@@ -17,73 +18,166 @@ const (
 	ErrCodeUnconfirmedEmail     = "unconfirmed_email"
 	ErrCodeInvalidAuthorization = "invalid_authorization"
 	ErrCodeOAuthMisconfigured   = "oauth_misconfigured"
+	ErrCodeAccessDenied         = "access_denied"
+	ErrCodeTimedOut             = "timed_out"
+	ErrCodeListenFailed         = "listen_failed"
+	ErrCodeAuthorizationPending = "authorization_pending"
+	ErrCodeSlowDown             = "slow_down"
+	ErrCodeExpiredToken         = "expired_token"
+	ErrCodeOTPRequired          = "otp_required"
+	ErrCodeInvalidOTP           = "invalid_otp"
 )
 
-func FetchToken(email, password string) (token string, appErr *apperror.Error) {
-	res, err := goreq.Request{
+// Token is a token granted by the token endpoint.
+type Token struct {
+	AccessToken string
+	// RefreshToken renews AccessToken when it expires. It is empty if the
+	// server did not issue one.
+	RefreshToken string
+	// ExpiresAt is zero if the server did not say when AccessToken expires.
+	ExpiresAt time.Time
+}
+
+func FetchToken(email, password string) (token *Token, appErr *apperror.Error) {
+	return FetchTokenWithOTP(email, password, "")
+}
+
+// Fetches a token with a two-factor authentication code, or a recovery code.
+// Without one, accounts with two-factor authentication enabled fail with
+// ErrCodeOTPRequired.
+func FetchTokenWithOTP(email, password, otp string) (token *Token, appErr *apperror.Error) {
+	form := url.Values{
+		"grant_type": {"password"},
+		"username":   {email},
+		"password":   {password},
+	}
+	if otp != "" {
+		form.Set("otp", otp)
+	}
+
+	token, errRes, appErr := requestToken(form)
+
+	if errRes != nil {
+		if errRes.Err == "invalid_grant" {
+			switch errRes.ErrorDescription {
+			case "user credentials are invalid":
+				return nil, apperror.New(ErrCodeInvalidGrant, nil, "invalid email or password", false)
+			case "user has not confirmed email address":
+				return nil, apperror.New(ErrCodeUnconfirmedEmail, nil, "user has not confirmed email address", false)
+			case "two-factor authentication code is required":
+				return nil, apperror.New(ErrCodeOTPRequired, nil, "two-factor authentication code is required", false)
+			case "two-factor authentication code is invalid":
+				return nil, apperror.New(ErrCodeInvalidOTP, nil, "two-factor authentication code is invalid", false)
+			}
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return token, appErr
+}
+
+// Exchanges an authorization code for a token. verifier is the PKCE code
+// verifier whose challenge was sent with the authorization request.
+func ExchangeCode(code, verifier, redirectURI string) (token *Token, appErr *apperror.Error) {
+	token, errRes, appErr := requestToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {redirectURI},
+	})
+
+	if errRes != nil {
+		if errRes.Err == "invalid_grant" {
+			return nil, apperror.New(ErrCodeInvalidGrant, nil, "authorization code is invalid or has expired", false)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return token, appErr
+}
+
+// Renews an access token with a refresh token. The refresh token is kept if
+// the server does not issue a new one.
+func RefreshAccessToken(refreshToken string) (token *Token, appErr *apperror.Error) {
+	token, errRes, appErr := requestToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+
+	if errRes != nil {
+		if errRes.Err == "invalid_grant" {
+			return nil, apperror.New(ErrCodeInvalidGrant, nil, "refresh token is invalid or has been revoked", false)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// Requests a token from the token endpoint with the given grant. The error of
+// a 400 response is returned as errRes, for the caller to interpret.
+func requestToken(form url.Values) (token *Token, errRes *api.ErrorResponse, appErr *apperror.Error) {
+	res, err := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/oauth/token",
+		Path:        "/oauth/token",
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		BasicAuthUsername: config.ClientID,
 		BasicAuthPassword: config.ClientSecret,
 
-		Body: url.Values{
-			"grant_type": {"password"},
-			"username":   {email},
-			"password":   {password},
-		}.Encode(),
+		Body: form.Encode(),
 	}.Do()
 
 	if err != nil {
-		return "", apperror.New(ErrCodeRequestFailed, err, "", true)
+		return nil, nil, apperror.New(ErrCodeRequestFailed, err, "", true)
 	}
+	defer res.Body.Close()
 
 	if !util.ContainsInt([]int{http.StatusOK, http.StatusBadRequest, http.StatusUnauthorized}, res.StatusCode) {
-		return "", apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return "", apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
 	switch res.StatusCode {
 	case http.StatusBadRequest:
-		if j["error"] == "invalid_grant" {
-			switch j["error_description"] {
-			case "user credentials are invalid":
-				return "", apperror.New(ErrCodeInvalidGrant, nil, "invalid email or password", false)
-			case "user has not confirmed email address":
-				return "", apperror.New(ErrCodeUnconfirmedEmail, nil, "user has not confirmed email address", false)
-			}
-		}
-		return "", apperror.New(ErrCodeUnexpectedError, err, "", true)
+		return nil, api.DecodeError(res), nil
 	case http.StatusUnauthorized:
 		// The configured OAuth client ID and/or secret are invalid - this is a
 		// build time error.
-		return "", apperror.New(ErrCodeOAuthMisconfigured, nil, "OAuth Client ID/Secret misconfigured", true)
+		return nil, nil, apperror.New(ErrCodeOAuthMisconfigured, nil, "OAuth Client ID/Secret misconfigured", true)
 	}
 
-	token, ok := j["access_token"].(string)
-	if j["token_type"] != "bearer" || token == "" || !ok {
-		return "", apperror.New(ErrCodeUnexpectedError, err, "", true)
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	return token, nil
+	accessToken, ok := j["access_token"].(string)
+	if j["token_type"] != "bearer" || accessToken == "" || !ok {
+		return nil, nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	token = &Token{AccessToken: accessToken}
+	token.RefreshToken, _ = j["refresh_token"].(string)
+	if expiresIn, ok := j["expires_in"].(float64); ok && expiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+
+	return token, nil, nil
 }
 
 func InvalidateToken(token string) (appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/oauth/token",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/oauth/token",
+		Token:  token,
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -94,18 +188,15 @@ func InvalidateToken(token string) (appErr *apperror.Error) {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
+	if res.StatusCode == http.StatusUnauthorized {
+		if e := api.DecodeError(res); e.Err == "invalid_token" && e.ErrorDescription == "access token is invalid" {
+			return apperror.New(ErrCodeInvalidAuthorization, nil, "invalid access token", false)
+		}
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	if res.StatusCode == http.StatusUnauthorized {
-		if j["error"] == "invalid_token" {
-			switch j["error_description"] {
-			case "access token is invalid":
-				return apperror.New(ErrCodeInvalidAuthorization, nil, "invalid access token", false)
-			}
-		}
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
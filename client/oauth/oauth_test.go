@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/nitrous-io/rise-cli-go/client/oauth"
 	"github.com/nitrous-io/rise-cli-go/config"
@@ -77,7 +78,11 @@ var _ = Describe("OAuth", func() {
 				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
 			}
 
-			Expect(token).To(Equal(e.tokenRecvd))
+			if e.tokenRecvd == "" {
+				Expect(token).To(BeNil())
+			} else {
+				Expect(token.AccessToken).To(Equal(e.tokenRecvd))
+			}
 		},
 
 		Entry("unexpected response code", expectation{
@@ -116,6 +121,15 @@ var _ = Describe("OAuth", func() {
 			errIsFatal: false,
 		}),
 
+		Entry("400 with two-factor code required", expectation{
+			resCode:    http.StatusBadRequest,
+			resBody:    `{"error": "invalid_grant", "error_description": "two-factor authentication code is required"}`,
+			errIsNil:   false,
+			errCode:    oauth.ErrCodeOTPRequired,
+			errDesc:    "two-factor authentication code is required",
+			errIsFatal: false,
+		}),
+
 		Entry("400 with unexpected error", expectation{
 			resCode:    400,
 			resBody:    `{"error": "something_weng_wrong"}`,
@@ -160,6 +174,125 @@ var _ = Describe("OAuth", func() {
 		}),
 	)
 
+	DescribeTable("FetchTokenWithOTP",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/oauth/token"),
+					ghttp.VerifyBasicAuth(config.ClientID, config.ClientSecret),
+					ghttp.VerifyForm(url.Values{
+						"grant_type": {"password"},
+						"username":   {"foo@example.com"},
+						"password":   {"p@55w0rd"},
+						"otp":        {"123456"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			token, appErr := oauth.FetchTokenWithOTP("foo@example.com", "p@55w0rd", "123456")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(token.AccessToken).To(Equal(e.tokenRecvd))
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+				Expect(token).To(BeNil())
+			}
+		},
+
+		Entry("400 with invalid two-factor code", expectation{
+			resCode:    http.StatusBadRequest,
+			resBody:    `{"error": "invalid_grant", "error_description": "two-factor authentication code is invalid"}`,
+			errCode:    oauth.ErrCodeInvalidOTP,
+			errIsFatal: false,
+		}),
+
+		Entry("bearer token granted", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"access_token": "myawes0met0ken", "token_type": "bearer"}`,
+			errIsNil:   true,
+			tokenRecvd: "myawes0met0ken",
+		}),
+	)
+
+	Describe("FetchToken", func() {
+		It("returns the refresh token and expiry time if there are any", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{
+				"access_token": "myawes0met0ken",
+				"token_type": "bearer",
+				"refresh_token": "r3fr35h",
+				"expires_in": 3600
+			}`))
+
+			token, appErr := oauth.FetchToken("foo@example.com", "p@55w0rd")
+			Expect(appErr).To(BeNil())
+			Expect(token.AccessToken).To(Equal("myawes0met0ken"))
+			Expect(token.RefreshToken).To(Equal("r3fr35h"))
+			Expect(token.ExpiresAt).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+		})
+	})
+
+	DescribeTable("RefreshAccessToken",
+		func(e expectation, refreshTokenRecvd string) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/oauth/token"),
+					ghttp.VerifyBasicAuth(config.ClientID, config.ClientSecret),
+					ghttp.VerifyForm(url.Values{
+						"grant_type":    {"refresh_token"},
+						"refresh_token": {"r3fr35h"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			token, appErr := oauth.RefreshAccessToken("r3fr35h")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(token.AccessToken).To(Equal(e.tokenRecvd))
+				Expect(token.RefreshToken).To(Equal(refreshTokenRecvd))
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+				Expect(token).To(BeNil())
+			}
+		},
+
+		Entry("revoked refresh token", expectation{
+			resCode:    http.StatusBadRequest,
+			resBody:    `{"error": "invalid_grant"}`,
+			errCode:    oauth.ErrCodeInvalidGrant,
+			errIsFatal: false,
+		}, ""),
+
+		Entry("unexpected error", expectation{
+			resCode:    http.StatusInternalServerError,
+			errCode:    oauth.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}, ""),
+
+		Entry("new refresh token issued", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"access_token": "n3w", "token_type": "bearer", "refresh_token": "n3wr3fr35h"}`,
+			errIsNil:   true,
+			tokenRecvd: "n3w",
+		}, "n3wr3fr35h"),
+
+		Entry("refresh token kept", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"access_token": "n3w", "token_type": "bearer"}`,
+			errIsNil:   true,
+			tokenRecvd: "n3w",
+		}, "r3fr35h"),
+	)
+
 	DescribeTable("InvalidateToken",
 		func(e expectation) {
 			server.AppendHandlers(
@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/tr"
+)
+
+// WebLogin is a login in the browser with the authorization code grant and
+// PKCE (RFC 7636). The browser is redirected back to a listener on the
+// loopback interface, so that the code never has to be copied by hand.
+type WebLogin struct {
+	// URL is the page to open in the browser
+	URL string
+
+	redirectURI string
+	state       string
+	verifier    string
+	listener    net.Listener
+	callback    chan url.Values
+}
+
+// Starts listening for the redirect on a random port of 127.0.0.1, and
+// returns the login with the URL to open
+func StartWebLogin() (*WebLogin, *apperror.Error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, apperror.New(ErrCodeListenFailed, err, "", true)
+	}
+
+	w := &WebLogin{
+		redirectURI: "http://" + l.Addr().String() + "/callback",
+		state:       state,
+		verifier:    verifier,
+		listener:    l,
+		callback:    make(chan url.Values, 1),
+	}
+
+	w.URL = config.Host + "/oauth/authorize?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {w.redirectURI},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", w.handleCallback)
+	go http.Serve(l, mux)
+
+	return w, nil
+}
+
+// Waits for the browser to be redirected back, and exchanges the code for a
+// token. The listener is closed when it returns.
+func (w *WebLogin) Wait(timeout time.Duration) (token *Token, appErr *apperror.Error) {
+	defer w.Close()
+
+	select {
+	case q := <-w.callback:
+		switch q.Get("error") {
+		case "":
+		case "access_denied":
+			return nil, apperror.New(ErrCodeAccessDenied, nil, "access was denied", false)
+		default:
+			return nil, apperror.New(ErrCodeUnexpectedError, nil, q.Get("error_description"), true)
+		}
+
+		return ExchangeCode(q.Get("code"), w.verifier, w.redirectURI)
+	case <-time.After(timeout):
+		return nil, apperror.New(ErrCodeTimedOut, nil, "timed out waiting for the login in the browser", false)
+	}
+}
+
+// Stops listening for the redirect
+func (w *WebLogin) Close() {
+	w.listener.Close()
+}
+
+func (w *WebLogin) handleCallback(rw http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Requests that don't carry the state of this login did not come from the
+	// authorization server, so they are turned away and the login goes on.
+	if q.Get("state") != w.state {
+		http.Error(rw, tr.T("login_web_invalid_state"), http.StatusBadRequest)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if q.Get("error") != "" {
+		fmt.Fprintln(rw, tr.T("login_web_failed_page"))
+	} else {
+		fmt.Fprintln(rw, tr.T("login_web_done_page"))
+	}
+
+	select {
+	case w.callback <- q:
+	default:
+	}
+}
+
+// Returns the S256 code challenge of a code verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Returns n random bytes, URL-safe base64 encoded
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
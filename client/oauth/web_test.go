@@ -0,0 +1,128 @@
+package oauth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/client/oauth"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Web login", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+		login    *oauth.WebLogin
+		query    url.Values
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+
+		l, appErr := oauth.StartWebLogin()
+		Expect(appErr).To(BeNil())
+		login = l
+
+		u, err := url.Parse(login.URL)
+		Expect(err).To(BeNil())
+		Expect(u.Path).To(Equal("/oauth/authorize"))
+		query = u.Query()
+	})
+
+	AfterEach(func() {
+		login.Close()
+		config.Host = origHost
+		server.Close()
+	})
+
+	redirect := func(params url.Values) (int, string) {
+		res, err := http.Get(query.Get("redirect_uri") + "?" + params.Encode())
+		Expect(err).To(BeNil())
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).To(BeNil())
+		return res.StatusCode, string(body)
+	}
+
+	It("asks for an authorization code with a PKCE challenge", func() {
+		Expect(query.Get("response_type")).To(Equal("code"))
+		Expect(query.Get("client_id")).To(Equal(config.ClientID))
+		Expect(query.Get("redirect_uri")).To(MatchRegexp(`^http://127\.0\.0\.1:\d+/callback$`))
+		Expect(query.Get("state")).NotTo(BeEmpty())
+		Expect(query.Get("code_challenge")).NotTo(BeEmpty())
+		Expect(query.Get("code_challenge_method")).To(Equal("S256"))
+	})
+
+	It("exchanges the code it is redirected back with for a token", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/oauth/token"),
+				ghttp.VerifyBasicAuth(config.ClientID, config.ClientSecret),
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.ParseForm()).To(Succeed())
+					Expect(r.PostForm.Get("grant_type")).To(Equal("authorization_code"))
+					Expect(r.PostForm.Get("code")).To(Equal("c0d3"))
+					Expect(r.PostForm.Get("redirect_uri")).To(Equal(query.Get("redirect_uri")))
+
+					sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+					Expect(base64.RawURLEncoding.EncodeToString(sum[:])).To(Equal(query.Get("code_challenge")))
+				},
+				ghttp.RespondWith(http.StatusOK, `{"access_token": "t0k3n", "token_type": "bearer"}`),
+			),
+		)
+
+		code, _ := redirect(url.Values{"code": {"c0d3"}, "state": {query.Get("state")}})
+		Expect(code).To(Equal(http.StatusOK))
+
+		token, appErr := login.Wait(time.Second)
+		Expect(appErr).To(BeNil())
+		Expect(token.AccessToken).To(Equal("t0k3n"))
+	})
+
+	It("ignores redirects with the wrong state", func() {
+		code, _ := redirect(url.Values{"code": {"c0d3"}, "state": {"forged"}})
+		Expect(code).To(Equal(http.StatusBadRequest))
+
+		token, appErr := login.Wait(50 * time.Millisecond)
+		Expect(appErr).NotTo(BeNil())
+		Expect(appErr.Code).To(Equal(oauth.ErrCodeTimedOut))
+		Expect(token).To(BeNil())
+		Expect(server.ReceivedRequests()).To(HaveLen(0))
+	})
+
+	It("returns an error if access was denied", func() {
+		code, _ := redirect(url.Values{"error": {"access_denied"}, "state": {query.Get("state")}})
+		Expect(code).To(Equal(http.StatusOK))
+
+		_, appErr := login.Wait(time.Second)
+		Expect(appErr).NotTo(BeNil())
+		Expect(appErr.Code).To(Equal(oauth.ErrCodeAccessDenied))
+		Expect(appErr.IsFatal).To(BeFalse())
+		Expect(server.ReceivedRequests()).To(HaveLen(0))
+	})
+
+	It("returns an error if the code is rejected", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/oauth/token"),
+				ghttp.RespondWith(http.StatusBadRequest, `{"error": "invalid_grant"}`),
+			),
+		)
+
+		redirect(url.Values{"code": {"c0d3"}, "state": {query.Get("state")}})
+
+		_, appErr := login.Wait(time.Second)
+		Expect(appErr).NotTo(BeNil())
+		Expect(appErr.Code).To(Equal(oauth.ErrCodeInvalidGrant))
+	})
+})
@@ -4,9 +4,8 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -17,12 +16,10 @@ const (
 )
 
 func Forgot(email string) *apperror.Error {
-	res, err := goreq.Request{
+	res, err := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/user/password/forgot",
+		Path:        "/user/password/forgot",
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"email": {email},
@@ -38,15 +35,15 @@ func Forgot(email string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
+	if res.StatusCode == 422 {
+		if e := api.DecodeError(res); e.Err == "invalid_params" && e.Errors != nil {
+			return e.AppError(ErrCodeValidationFailed, false)
+		}
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" && j["errors"] != nil {
-			return apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), false)
-		}
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
@@ -58,12 +55,10 @@ func Forgot(email string) *apperror.Error {
 }
 
 func Reset(email, resetToken, newPassword string) *apperror.Error {
-	res, err := goreq.Request{
+	res, err := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/user/password/reset",
+		Path:        "/user/password/reset",
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"email":       {email},
@@ -81,17 +76,13 @@ func Reset(email, resetToken, newPassword string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" && j["errors"] != nil {
-			return apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), false)
+		e := api.DecodeError(res)
+		if e.Err == "invalid_params" && e.Errors != nil {
+			return e.AppError(ErrCodeValidationFailed, false)
 		}
-		if j["error"] == "invalid_params" && j["error_description"] != nil {
-			switch j["error_description"] {
+		if e.Err == "invalid_params" {
+			switch e.ErrorDescription {
 			case "email is not found":
 				return apperror.New(ErrCodeValidationFailed, nil, "You've entered an invalid email address. Please try again.", false)
 			case "invalid email or reset_token":
@@ -102,9 +93,15 @@ func Reset(email, resetToken, newPassword string) *apperror.Error {
 	}
 
 	if res.StatusCode == http.StatusForbidden {
-		if j["error"] == "invalid_params" && j["error_description"] == "invalid email or reset_token" {
+		if e := api.DecodeError(res); e.Err == "invalid_params" && e.ErrorDescription == "invalid email or reset_token" {
 			return apperror.New(ErrCodeValidationFailed, nil, "You've entered an invalid email address or password reset code. Please check your email inbox for the password reset instructions.", false)
 		}
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
 	if v, ok := j["reset"].(bool); !v || !ok {
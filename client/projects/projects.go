@@ -6,9 +6,8 @@ import (
 	"net/url"
 	"strconv"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/project"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/util"
@@ -23,18 +22,16 @@ const (
 )
 
 func Create(token, name string) *apperror.Error {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects",
+		Path:        "/projects",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"name": {name},
 		}.Encode(),
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -45,34 +42,31 @@ func Create(token, name string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == http.StatusForbidden {
 		return apperror.New(ErrCodeLimitReached, nil, tr.T("project_limit_reached"), true)
 	}
 
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" {
-			return apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), false)
+		if e := api.DecodeError(res); e.Err == "invalid_params" {
+			return e.AppError(ErrCodeValidationFailed, false)
 		}
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	return nil
 }
 
 func Get(token, name string) (*project.Project, *apperror.Error) {
-	uri := fmt.Sprintf("%s/projects/%s", config.Host, name)
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       uri,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + name,
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -99,13 +93,11 @@ func Get(token, name string) (*project.Project, *apperror.Error) {
 }
 
 func Index(token string) (projects []*project.Project, sharedProjects []*project.Project, appErr *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -129,12 +121,11 @@ func Index(token string) (projects []*project.Project, sharedProjects []*project
 }
 
 func Update(token string, proj *project.Project) (*project.Project, *apperror.Error) {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "PUT",
-		Uri:         config.Host + "/projects/" + proj.Name,
+		Path:        "/projects/" + proj.Name,
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"default_domain_enabled": {strconv.FormatBool(proj.DefaultDomainEnabled)},
@@ -142,7 +133,6 @@ func Update(token string, proj *project.Project) (*project.Project, *apperror.Er
 			"skip_build":             {strconv.FormatBool(proj.SkipBuild)},
 		}.Encode(),
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -168,13 +158,11 @@ func Update(token string, proj *project.Project) (*project.Project, *apperror.Er
 }
 
 func Delete(token, name string) *apperror.Error {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/projects/" + name,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/projects/" + name,
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -198,12 +186,11 @@ func Delete(token, name string) *apperror.Error {
 }
 
 func Protect(token, name, username, password string) *apperror.Error {
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects/" + name + "/auth",
+		Path:        "/projects/" + name + "/auth",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"basic_auth_username": {username},
@@ -211,7 +198,6 @@ func Protect(token, name, username, password string) *apperror.Error {
 		}.Encode(),
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -235,14 +221,12 @@ func Protect(token, name, username, password string) *apperror.Error {
 }
 
 func Unprotect(token, name string) *apperror.Error {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/projects/" + name + "/auth",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/projects/" + name + "/auth",
+		Token:  token,
 	}
 
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -3,9 +3,8 @@ package rawbundles
 import (
 	"net/http"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -23,13 +22,11 @@ type RawBundle struct {
 }
 
 func Get(token, name, checksum string) (*RawBundle, *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + name + "/raw_bundles/" + checksum,
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + name + "/raw_bundles/" + checksum,
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -42,12 +39,7 @@ func Get(token, name, checksum string) (*RawBundle, *apperror.Error) {
 	}
 
 	if res.StatusCode == http.StatusNotFound {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "project could not be found":
 			return nil, apperror.New(ErrCodeProjectNotFound, err, "", true)
 		case "raw bundle could not be found":
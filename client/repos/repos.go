@@ -9,9 +9,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
 
@@ -80,12 +79,11 @@ func Link(token, projectName, repoURL, branch, secret string) (*Repo, *apperror.
 		// (e.g. user doesn't have git installed, or is offline).
 	}
 
-	req := goreq.Request{
+	req := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/projects/" + projectName + "/repos",
+		Path:        "/projects/" + projectName + "/repos",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"uri":    {repoURL},
@@ -93,7 +91,6 @@ func Link(token, projectName, repoURL, branch, secret string) (*Repo, *apperror.
 			"secret": {secret},
 		}.Encode(),
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -110,12 +107,7 @@ func Link(token, projectName, repoURL, branch, secret string) (*Repo, *apperror.
 	}
 
 	if res.StatusCode == http.StatusNotFound {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "project could not be found":
 			return nil, apperror.New(ErrCodeProjectNotFound, nil, "project could not be found", true)
 		}
@@ -124,13 +116,8 @@ func Link(token, projectName, repoURL, branch, secret string) (*Repo, *apperror.
 	}
 
 	if res.StatusCode == 422 {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		if j["error"] == "invalid_params" {
-			return nil, apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), true)
+		if e := api.DecodeError(res); e.Err == "invalid_params" {
+			return nil, apperror.New(ErrCodeValidationFailed, nil, e.ValidationErrors(), true)
 		}
 
 		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
@@ -147,13 +134,11 @@ func Link(token, projectName, repoURL, branch, secret string) (*Repo, *apperror.
 }
 
 func Unlink(token, projectName string) *apperror.Error {
-	req := goreq.Request{
-		Method:    "DELETE",
-		Uri:       config.Host + "/projects/" + projectName + "/repos",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "DELETE",
+		Path:   "/projects/" + projectName + "/repos",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -166,12 +151,7 @@ func Unlink(token, projectName string) *apperror.Error {
 	}
 
 	if res.StatusCode == http.StatusNotFound {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "project not linked to any repository":
 			return apperror.New(ErrCodeNotLinked, err, "project not linked to any repository", false)
 		case "project could not be found":
@@ -185,13 +165,11 @@ func Unlink(token, projectName string) *apperror.Error {
 }
 
 func Info(token, projectName string) (*Repo, *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/projects/" + projectName + "/repos",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/projects/" + projectName + "/repos",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 
 	res, err := req.Do()
 	if err != nil {
@@ -204,12 +182,7 @@ func Info(token, projectName string) (*Repo, *apperror.Error) {
 	}
 
 	if res.StatusCode == http.StatusNotFound {
-		var j map[string]interface{}
-		if err := res.Body.FromJsonTo(&j); err != nil {
-			return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
-		}
-
-		switch j["error_description"] {
+		switch api.DecodeError(res).ErrorDescription {
 		case "project not linked to any repository":
 			return nil, apperror.New(ErrCodeNotLinked, err, "project not linked to any repository", false)
 		case "project could not be found":
@@ -0,0 +1,134 @@
+package tokens
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+const (
+	ErrCodeRequestFailed    = "request_failed"
+	ErrCodeUnexpectedError  = "unexpected_error"
+	ErrCodeValidationFailed = "validation_failed"
+	ErrCodeNotFound         = "not_found"
+)
+
+// Token is a long-lived, named access token that can be revoked without
+// affecting the user's other sessions.
+type Token struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	// Token is only returned when the token is created.
+	Token string `json:"token,omitempty"`
+}
+
+func Create(token, name string) (*Token, *apperror.Error) {
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/tokens",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: url.Values{
+			"name": {name},
+		}.Encode(),
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusCreated, 422}, res.StatusCode) {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode == 422 {
+		if e := api.DecodeError(res); e.Err == "invalid_params" {
+			return nil, e.AppError(ErrCodeValidationFailed, false)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	var j struct {
+		Token *Token `json:"token"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if j.Token == nil || j.Token.Token == "" {
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return j.Token, nil
+}
+
+func List(token string) ([]*Token, *apperror.Error) {
+	req := api.Request{
+		Method: "GET",
+		Path:   "/tokens",
+		Token:  token,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	var j struct {
+		Tokens []*Token `json:"tokens"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	return j.Tokens, nil
+}
+
+func Revoke(token string, id uint) *apperror.Error {
+	req := api.Request{
+		Method: "DELETE",
+		Path:   fmt.Sprintf("/tokens/%d", id),
+		Token:  token,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusNotFound}, res.StatusCode) {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return apperror.New(ErrCodeNotFound, nil, "token could not be found", false)
+	}
+
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if v, ok := j["revoked"].(bool); !v || !ok {
+		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package tokens_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/client/tokens"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "tokens")
+}
+
+var _ = Describe("Tokens", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		server.Close()
+	})
+
+	type expectation struct {
+		resCode int
+		resBody string
+
+		errIsNil   bool
+		errCode    string
+		errDesc    string
+		errIsFatal bool
+		result     interface{}
+	}
+
+	createdAt := time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC)
+	lastUsedAt := time.Date(2016, 5, 2, 10, 0, 0, 0, time.UTC)
+
+	DescribeTable("Create",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/tokens"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Content-Type":  {"application/x-www-form-urlencoded"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.VerifyForm(url.Values{
+						"name": {"ci"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			tok, appErr := tokens.Create("t0k3n", "ci")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(tok).To(Equal(e.result))
+			} else {
+				Expect(tok).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusCreated,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("201 without the token value", expectation{
+			resCode:    http.StatusCreated,
+			resBody:    `{"token": {"id": 1, "name": "ci"}}`,
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("422 with invalid params", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"name": "is taken"}}`,
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeValidationFailed,
+			errDesc:    "name is taken",
+			errIsFatal: false,
+		}),
+
+		Entry("201 Created", expectation{
+			resCode:  http.StatusCreated,
+			resBody:  `{"token": {"id": 1, "name": "ci", "created_at": "2016-05-01T10:00:00Z", "last_used_at": null, "token": "s3cr3t"}}`,
+			errIsNil: true,
+			result: &tokens.Token{
+				ID:        1,
+				Name:      "ci",
+				CreatedAt: createdAt,
+				Token:     "s3cr3t",
+			},
+		}),
+	)
+
+	DescribeTable("List",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/tokens"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			toks, appErr := tokens.List("t0k3n")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(toks).To(Equal(e.result))
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("200 OK with token list", expectation{
+			resCode: http.StatusOK,
+			resBody: `{"tokens": [
+				{"id": 1, "name": "ci", "created_at": "2016-05-01T10:00:00Z", "last_used_at": "2016-05-02T10:00:00Z"},
+				{"id": 2, "name": "laptop", "created_at": "2016-05-01T10:00:00Z", "last_used_at": null}
+			]}`,
+			errIsNil: true,
+			result: []*tokens.Token{
+				{ID: 1, Name: "ci", CreatedAt: createdAt, LastUsedAt: &lastUsedAt},
+				{ID: 2, Name: "laptop", CreatedAt: createdAt},
+			},
+		}),
+	)
+
+	DescribeTable("Revoke",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/tokens/42"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			appErr := tokens.Revoke("t0k3n", 42)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(strings.ToLower(appErr.Description)).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("404 Not Found", expectation{
+			resCode:    http.StatusNotFound,
+			resBody:    `{"error": "not_found", "error_description": "token could not be found"}`,
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeNotFound,
+			errDesc:    "token could not be found",
+			errIsFatal: false,
+		}),
+
+		Entry("200 OK without revoked", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"revoked": false}`,
+			errIsNil:   false,
+			errCode:    tokens.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("200 OK", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"revoked": true}`,
+			errIsNil: true,
+		}),
+	)
+})
@@ -0,0 +1,169 @@
+package twofactor
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nitrous-io/rise-cli-go/apperror"
+	"github.com/nitrous-io/rise-cli-go/client/api"
+	"github.com/nitrous-io/rise-cli-go/util"
+)
+
+const (
+	ErrCodeRequestFailed   = "request_failed"
+	ErrCodeUnexpectedError = "unexpected_error"
+	ErrCodeAlreadyEnabled  = "already_enabled"
+	ErrCodeNotEnabled      = "not_enabled"
+	ErrCodeInvalidCode     = "invalid_code"
+)
+
+// Setup is the TOTP secret of two-factor authentication that is being
+// enabled, to be added to an authenticator app.
+type Setup struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI is the otpauth:// URI of the secret, which
+	// authenticator apps can scan as a QR code
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Generates a new secret for two-factor authentication. It is only enabled
+// once a code generated with the secret is verified with Enable.
+func Begin(token string) (*Setup, *apperror.Error) {
+	req := api.Request{
+		Method: "POST",
+		Path:   "/user/2fa",
+		Token:  token,
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusCreated, http.StatusConflict}, res.StatusCode) {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode == http.StatusConflict {
+		if e := api.DecodeError(res); e.Err == "already_enabled" {
+			return nil, apperror.New(ErrCodeAlreadyEnabled, nil, "two-factor authentication is already enabled", false)
+		}
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	var j struct {
+		TwoFactor *Setup `json:"two_factor"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if j.TwoFactor == nil || j.TwoFactor.Secret == "" || !strings.HasPrefix(j.TwoFactor.ProvisioningURI, "otpauth://") {
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return j.TwoFactor, nil
+}
+
+// Enables two-factor authentication once the user has shown that their
+// authenticator app generates valid codes. Returns the one-time recovery
+// codes that can be used instead of codes from the app.
+func Enable(token, code string) (recoveryCodes []string, appErr *apperror.Error) {
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/user/2fa/enable",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: url.Values{
+			"otp": {code},
+		}.Encode(),
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusConflict, 422}, res.StatusCode) {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, decodeError(res.StatusCode, api.DecodeError(res))
+	}
+
+	var j struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if len(j.RecoveryCodes) == 0 {
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return j.RecoveryCodes, nil
+}
+
+// Disables two-factor authentication. code is a code from the authenticator
+// app or a recovery code.
+func Disable(token, code string) *apperror.Error {
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/user/2fa/disable",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: url.Values{
+			"otp": {code},
+		}.Encode(),
+	}
+
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, http.StatusConflict, 422}, res.StatusCode) {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return decodeError(res.StatusCode, api.DecodeError(res))
+	}
+
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if v, ok := j["disabled"].(bool); !v || !ok {
+		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return nil
+}
+
+// Converts an error response of Enable or Disable
+func decodeError(statusCode int, e *api.ErrorResponse) *apperror.Error {
+	switch statusCode {
+	case http.StatusConflict:
+		switch e.Err {
+		case "already_enabled":
+			return apperror.New(ErrCodeAlreadyEnabled, nil, "two-factor authentication is already enabled", false)
+		case "not_enabled":
+			return apperror.New(ErrCodeNotEnabled, nil, "two-factor authentication is not enabled", false)
+		}
+	case 422:
+		if _, ok := e.Errors["otp"]; e.Err == "invalid_params" && ok {
+			return apperror.New(ErrCodeInvalidCode, nil, "two-factor authentication code is invalid", false)
+		}
+	}
+	return apperror.New(ErrCodeUnexpectedError, nil, "", true)
+}
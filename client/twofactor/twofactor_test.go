@@ -0,0 +1,240 @@
+package twofactor_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/nitrous-io/rise-cli-go/client/twofactor"
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "twofactor")
+}
+
+var _ = Describe("TwoFactor", func() {
+	var (
+		origHost string
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		origHost = config.Host
+		config.Host = server.URL()
+	})
+
+	AfterEach(func() {
+		config.Host = origHost
+		server.Close()
+	})
+
+	type expectation struct {
+		resCode int
+		resBody string
+
+		errIsNil   bool
+		errCode    string
+		errIsFatal bool
+		result     interface{}
+	}
+
+	DescribeTable("Begin",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/user/2fa"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Accept":        {config.ReqAccept},
+						"User-Agent":    {config.UserAgent},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			setup, appErr := twofactor.Begin("t0k3n")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(setup).To(Equal(e.result))
+			} else {
+				Expect(setup).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusCreated,
+			resBody:    `{"two_factor": }`,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("201 without a provisioning URI", expectation{
+			resCode:    http.StatusCreated,
+			resBody:    `{"two_factor": {"secret": "JBSWY3DPEHPK3PXP"}}`,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("409 already enabled", expectation{
+			resCode:    http.StatusConflict,
+			resBody:    `{"error": "already_enabled"}`,
+			errCode:    twofactor.ErrCodeAlreadyEnabled,
+			errIsFatal: false,
+		}),
+
+		Entry("201 Created", expectation{
+			resCode:  http.StatusCreated,
+			resBody:  `{"two_factor": {"secret": "JBSWY3DPEHPK3PXP", "provisioning_uri": "otpauth://totp/PubStorm:foo%40example.com?secret=JBSWY3DPEHPK3PXP&issuer=PubStorm"}}`,
+			errIsNil: true,
+			result: &twofactor.Setup{
+				Secret:          "JBSWY3DPEHPK3PXP",
+				ProvisioningURI: "otpauth://totp/PubStorm:foo%40example.com?secret=JBSWY3DPEHPK3PXP&issuer=PubStorm",
+			},
+		}),
+	)
+
+	DescribeTable("Enable",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/user/2fa/enable"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Content-Type":  {"application/x-www-form-urlencoded"},
+					}),
+					ghttp.VerifyForm(url.Values{
+						"otp": {"123456"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			codes, appErr := twofactor.Enable("t0k3n", "123456")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(codes).To(Equal(e.result))
+			} else {
+				Expect(codes).To(BeNil())
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("200 without recovery codes", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"recovery_codes": []}`,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("422 with invalid code", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is invalid"}}`,
+			errCode:    twofactor.ErrCodeInvalidCode,
+			errIsFatal: false,
+		}),
+
+		Entry("409 already enabled", expectation{
+			resCode:    http.StatusConflict,
+			resBody:    `{"error": "already_enabled"}`,
+			errCode:    twofactor.ErrCodeAlreadyEnabled,
+			errIsFatal: false,
+		}),
+
+		Entry("200 OK", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"recovery_codes": ["a1b2-c3d4", "e5f6-a7b8"]}`,
+			errIsNil: true,
+			result:   []string{"a1b2-c3d4", "e5f6-a7b8"},
+		}),
+	)
+
+	DescribeTable("Disable",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/user/2fa/disable"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": {"Bearer t0k3n"},
+						"Content-Type":  {"application/x-www-form-urlencoded"},
+					}),
+					ghttp.VerifyForm(url.Values{
+						"otp": {"a1b2-c3d4"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			appErr := twofactor.Disable("t0k3n", "a1b2-c3d4")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("409 not enabled", expectation{
+			resCode:    http.StatusConflict,
+			resBody:    `{"error": "not_enabled"}`,
+			errCode:    twofactor.ErrCodeNotEnabled,
+			errIsFatal: false,
+		}),
+
+		Entry("422 with invalid code", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is invalid"}}`,
+			errCode:    twofactor.ErrCodeInvalidCode,
+			errIsFatal: false,
+		}),
+
+		Entry("200 OK without disabled", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{}`,
+			errCode:    twofactor.ErrCodeUnexpectedError,
+			errIsFatal: true,
+		}),
+
+		Entry("200 OK", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"disabled": true}`,
+			errIsNil: true,
+		}),
+	)
+})
@@ -5,9 +5,8 @@ import (
 	"net/url"
 	"strings"
 
-	"github.com/franela/goreq"
 	"github.com/nitrous-io/rise-cli-go/apperror"
-	"github.com/nitrous-io/rise-cli-go/config"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/util"
 )
@@ -17,21 +16,22 @@ const (
 	ErrCodeUnexpectedError  = "unexpected_error"
 	ErrCodeValidationFailed = "validation_failed"
 	ErrCodeAuthFailed       = "auth_failed"
+	ErrCodeOTPRequired      = "otp_required"
+	ErrCodeInvalidOTP       = "invalid_otp"
 )
 
 type User struct {
-	Email        string `json:"email"`
-	Name         string `json:"name"`
-	Organization string `json:"organization"`
+	Email            string `json:"email"`
+	Name             string `json:"name"`
+	Organization     string `json:"organization"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
 }
 
 func Create(email, password string) *apperror.Error {
-	res, err := goreq.Request{
+	res, err := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/users",
+		Path:        "/users",
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"email":    {email},
@@ -47,15 +47,15 @@ func Create(email, password string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
+	if res.StatusCode == 422 {
+		if e := api.DecodeError(res); e.Err == "invalid_params" {
+			return e.AppError(ErrCodeValidationFailed, false)
+		}
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" {
-			return apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), false)
-		}
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
@@ -63,12 +63,10 @@ func Create(email, password string) *apperror.Error {
 }
 
 func Confirm(email, confirmationCode string) *apperror.Error {
-	res, err := goreq.Request{
+	res, err := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/user/confirm",
+		Path:        "/user/confirm",
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"email":             {email},
@@ -84,18 +82,18 @@ func Confirm(email, confirmationCode string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" && j["error_description"] == "invalid email or confirmation_code" {
+		if e := api.DecodeError(res); e.Err == "invalid_params" && e.ErrorDescription == "invalid email or confirmation_code" {
 			return apperror.New(ErrCodeValidationFailed, nil, "You've entered an incorrect confirmation code. Please try again.", false)
 		}
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	if v, ok := j["confirmed"].(bool); !v || !ok {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
@@ -104,12 +102,10 @@ func Confirm(email, confirmationCode string) *apperror.Error {
 }
 
 func ResendConfirmationCode(email string) *apperror.Error {
-	res, err := goreq.Request{
+	res, err := api.Request{
 		Method:      "POST",
-		Uri:         config.Host + "/user/confirm/resend",
+		Path:        "/user/confirm/resend",
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
 		Body: url.Values{
 			"email": {email},
@@ -124,18 +120,18 @@ func ResendConfirmationCode(email string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
-	var j map[string]interface{}
-	if err := res.Body.FromJsonTo(&j); err != nil {
-		return apperror.New(ErrCodeUnexpectedError, err, "", true)
-	}
-
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" && j["error_description"] == "email is not found or already confirmed" {
+		if e := api.DecodeError(res); e.Err == "invalid_params" && e.ErrorDescription == "email is not found or already confirmed" {
 			return apperror.New(ErrCodeValidationFailed, nil, "Could not request confirmation code to be resent. (Is it already confirmed?)", true)
 		}
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	if v, ok := j["sent"].(bool); !v || !ok {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
@@ -144,13 +140,11 @@ func ResendConfirmationCode(email string) *apperror.Error {
 }
 
 func Show(token string) (*User, *apperror.Error) {
-	req := goreq.Request{
-		Method:    "GET",
-		Uri:       config.Host + "/user",
-		Accept:    config.ReqAccept,
-		UserAgent: config.UserAgent,
+	req := api.Request{
+		Method: "GET",
+		Path:   "/user",
+		Token:  token,
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -176,19 +170,28 @@ func Show(token string) (*User, *apperror.Error) {
 }
 
 func ChangePassword(token, existingPassword, password string) *apperror.Error {
-	req := goreq.Request{
+	return ChangePasswordWithOTP(token, existingPassword, password, "")
+}
+
+// Changes the password with a two-factor authentication code, which accounts
+// with two-factor authentication enabled require
+func ChangePasswordWithOTP(token, existingPassword, password, otp string) *apperror.Error {
+	form := url.Values{
+		"existing_password": {existingPassword},
+		"password":          {password},
+	}
+	if otp != "" {
+		form.Set("otp", otp)
+	}
+
+	req := api.Request{
 		Method:      "PUT",
-		Uri:         config.Host + "/user",
+		Path:        "/user",
+		Token:       token,
 		ContentType: "application/x-www-form-urlencoded",
-		Accept:      config.ReqAccept,
-		UserAgent:   config.UserAgent,
 
-		Body: url.Values{
-			"existing_password": {existingPassword},
-			"password":          {password},
-		}.Encode(),
+		Body: form.Encode(),
 	}
-	req.AddHeader("Authorization", "Bearer "+token)
 	res, err := req.Do()
 	if err != nil {
 		return apperror.New(ErrCodeRequestFailed, err, "", true)
@@ -199,24 +202,188 @@ func ChangePassword(token, existingPassword, password string) *apperror.Error {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
+	if res.StatusCode == 422 {
+		if e := api.DecodeError(res); e.Err == "invalid_params" && e.Errors != nil {
+			if msg, ok := e.Errors["existing_password"].(string); ok && strings.Contains(msg, "incorrect") {
+				return apperror.New(ErrCodeValidationFailed, nil, tr.T("existing_password_incorrect"), false)
+			} else if msg, ok := e.Errors["password"].(string); ok && strings.Contains(msg, "existing password") {
+				return apperror.New(ErrCodeValidationFailed, nil, tr.T("new_password_same"), false)
+			} else if appErr := otpError(e); appErr != nil {
+				return appErr
+			}
+			return e.AppError(ErrCodeValidationFailed, false)
+		}
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	var j map[string]interface{}
 	if err := res.Body.FromJsonTo(&j); err != nil {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
+	return nil
+}
+
+// Updates the profile of the user. Only the attributes given are changed, so
+// that e.g. {"organization": ""} clears the organization and leaves the name
+// as it is.
+func Update(token string, attrs map[string]string) (*User, *apperror.Error) {
+	form := url.Values{}
+	for k, v := range attrs {
+		form.Set(k, v)
+	}
+
+	req := api.Request{
+		Method:      "PUT",
+		Path:        "/user",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: form.Encode(),
+	}
+	res, err := req.Do()
+	if err != nil {
+		return nil, apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, 422}, res.StatusCode) {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
 	if res.StatusCode == 422 {
-		if j["error"] == "invalid_params" {
-			if errors, ok := j["errors"].(map[string]interface{}); ok {
-				if msg, ok := errors["existing_password"].(string); ok && strings.Contains(msg, "incorrect") {
-					return apperror.New(ErrCodeValidationFailed, nil, tr.T("existing_password_incorrect"), false)
-				} else if msg, ok := errors["password"].(string); ok && strings.Contains(msg, "existing password") {
-					return apperror.New(ErrCodeValidationFailed, nil, tr.T("new_password_same"), false)
-				}
-				return apperror.New(ErrCodeValidationFailed, nil, util.ValidationErrorsToString(j), false)
-			}
+		if e := api.DecodeError(res); e.Err == "invalid_params" {
+			return nil, e.AppError(ErrCodeValidationFailed, false)
 		}
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	var j struct {
+		User *User `json:"user"`
+	}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if j.User == nil {
+		return nil, apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	return j.User, nil
+}
+
+// Changes the email address of the user, which has to be confirmed again with
+// Confirm before the account can be logged in to with it. The password, and a
+// two-factor authentication code if enabled, are required.
+func ChangeEmail(token, email, password, otp string) *apperror.Error {
+	form := url.Values{
+		"email":    {email},
+		"password": {password},
+	}
+	if otp != "" {
+		form.Set("otp", otp)
+	}
+
+	req := api.Request{
+		Method:      "PUT",
+		Path:        "/user/email",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: form.Encode(),
+	}
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, 422}, res.StatusCode) {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode == 422 {
+		return credentialsError(api.DecodeError(res))
+	}
+
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	return nil
+}
+
+// Deletes the account of the user, along with all of its projects. The
+// password, and a two-factor authentication code if enabled, are required.
+func Delete(token, password, otp string) *apperror.Error {
+	form := url.Values{
+		"password": {password},
+	}
+	if otp != "" {
+		form.Set("otp", otp)
+	}
+
+	req := api.Request{
+		Method:      "POST",
+		Path:        "/user/delete",
+		Token:       token,
+		ContentType: "application/x-www-form-urlencoded",
+
+		Body: form.Encode(),
+	}
+	res, err := req.Do()
+	if err != nil {
+		return apperror.New(ErrCodeRequestFailed, err, "", true)
+	}
+	defer res.Body.Close()
+
+	if !util.ContainsInt([]int{http.StatusOK, 422}, res.StatusCode) {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if res.StatusCode == 422 {
+		return credentialsError(api.DecodeError(res))
+	}
+
+	var j map[string]interface{}
+	if err := res.Body.FromJsonTo(&j); err != nil {
+		return apperror.New(ErrCodeUnexpectedError, err, "", true)
+	}
+
+	if v, ok := j["deleted"].(bool); !v || !ok {
 		return apperror.New(ErrCodeUnexpectedError, err, "", true)
 	}
 
 	return nil
 }
+
+// Converts a 422 response to a request that is authorized with the password
+// of the user
+func credentialsError(e *api.ErrorResponse) *apperror.Error {
+	if e.Err != "invalid_params" || e.Errors == nil {
+		return apperror.New(ErrCodeUnexpectedError, nil, "", true)
+	}
+
+	if msg, ok := e.Errors["password"].(string); ok && strings.Contains(msg, "incorrect") {
+		return apperror.New(ErrCodeValidationFailed, nil, tr.T("password_incorrect"), false)
+	}
+	if appErr := otpError(e); appErr != nil {
+		return appErr
+	}
+	return e.AppError(ErrCodeValidationFailed, false)
+}
+
+// Returns the error for a missing or invalid two-factor authentication code,
+// if that is what the response is about
+func otpError(e *api.ErrorResponse) *apperror.Error {
+	msg, ok := e.Errors["otp"].(string)
+	if !ok {
+		return nil
+	}
+
+	if strings.Contains(msg, "required") {
+		return apperror.New(ErrCodeOTPRequired, nil, tr.T("otp_required"), false)
+	}
+	return apperror.New(ErrCodeInvalidOTP, nil, tr.T("otp_invalid"), false)
+}
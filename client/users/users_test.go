@@ -432,10 +432,299 @@ var _ = Describe("Users", func() {
 			errIsFatal: false,
 		}),
 
+		Entry("two-factor code required", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is required"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeOTPRequired,
+			errDesc:    "",
+			errIsFatal: false,
+		}),
+
+		Entry("successful password update", expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"user": { "email": "foo@example.com", "name": "", "organization": ""}}`,
+			errIsNil: true,
+		}),
+	)
+
+	DescribeTable("ChangePasswordWithOTP",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/user"),
+					ghttp.VerifyForm(url.Values{
+						"existing_password": {"old-pass"},
+						"password":          {"new-pass"},
+						"otp":               {"123456"},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			appErr := users.ChangePasswordWithOTP("t0k3n", "old-pass", "new-pass", "123456")
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("invalid two-factor code", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is invalid"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeInvalidOTP,
+			errIsFatal: false,
+		}),
+
 		Entry("successful password update", expectation{
 			resCode:  http.StatusOK,
 			resBody:  `{"user": { "email": "foo@example.com", "name": "", "organization": ""}}`,
 			errIsNil: true,
 		}),
 	)
+
+	DescribeTable("Update",
+		func(e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/user"),
+					ghttp.VerifyHeader(http.Header{
+						"Content-Type": {"application/x-www-form-urlencoded"},
+						"Accept":       {config.ReqAccept},
+						"User-Agent":   {config.UserAgent},
+					}),
+					ghttp.VerifyForm(url.Values{
+						"name":         {"Foo Boss"},
+						"organization": {""},
+					}),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			u, appErr := users.Update("t0k3n", map[string]string{
+				"name":         "Foo Boss",
+				"organization": "",
+			})
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+				Expect(u).To(Equal(e.user))
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.Description).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    users.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("invalid params", expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"name": "is too long (max. 100 characters)"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeValidationFailed,
+			errDesc:    "Name is too long (max. 100 characters)",
+			errIsFatal: false,
+		}),
+
+		Entry("200 OK", expectation{
+			resCode: http.StatusOK,
+			resBody: `{"user": {
+				"email": "foo@example.com",
+				"name": "Foo Boss",
+				"organization": "",
+				"two_factor_enabled": true
+			}}`,
+			user: &users.User{
+				Email:            "foo@example.com",
+				Name:             "Foo Boss",
+				TwoFactorEnabled: true,
+			},
+			errIsNil: true,
+		}),
+	)
+
+	DescribeTable("ChangeEmail",
+		func(otp string, form url.Values, e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/user/email"),
+					ghttp.VerifyHeader(http.Header{
+						"Content-Type": {"application/x-www-form-urlencoded"},
+						"Accept":       {config.ReqAccept},
+						"User-Agent":   {config.UserAgent},
+					}),
+					ghttp.VerifyForm(form),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			appErr := users.ChangeEmail("t0k3n", "bar@example.com", "pass", otp)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.Description).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", "", url.Values{"email": {"bar@example.com"}, "password": {"pass"}}, expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    users.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("invalid params with email", "", url.Values{"email": {"bar@example.com"}, "password": {"pass"}}, expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"email": "is taken"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeValidationFailed,
+			errDesc:    "Email is taken",
+			errIsFatal: false,
+		}),
+
+		Entry("incorrect password", "", url.Values{"email": {"bar@example.com"}, "password": {"pass"}}, expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"password": "is incorrect"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeValidationFailed,
+			errDesc:    "The password you've entered is incorrect.",
+			errIsFatal: false,
+		}),
+
+		Entry("two-factor code required", "", url.Values{"email": {"bar@example.com"}, "password": {"pass"}}, expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is required"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeOTPRequired,
+			errDesc:    "",
+			errIsFatal: false,
+		}),
+
+		Entry("invalid two-factor code", "123456", url.Values{"email": {"bar@example.com"}, "password": {"pass"}, "otp": {"123456"}}, expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is invalid"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeInvalidOTP,
+			errDesc:    "",
+			errIsFatal: false,
+		}),
+
+		Entry("successful email change", "", url.Values{"email": {"bar@example.com"}, "password": {"pass"}}, expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"user": {"email": "bar@example.com", "name": "", "organization": ""}}`,
+			errIsNil: true,
+		}),
+	)
+
+	DescribeTable("Delete",
+		func(otp string, form url.Values, e expectation) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/user/delete"),
+					ghttp.VerifyHeader(http.Header{
+						"Content-Type": {"application/x-www-form-urlencoded"},
+						"Accept":       {config.ReqAccept},
+						"User-Agent":   {config.UserAgent},
+					}),
+					ghttp.VerifyForm(form),
+					ghttp.RespondWith(e.resCode, e.resBody),
+				),
+			)
+
+			appErr := users.Delete("t0k3n", "pass", otp)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			if e.errIsNil {
+				Expect(appErr).To(BeNil())
+			} else {
+				Expect(appErr).NotTo(BeNil())
+				Expect(appErr.Code).To(Equal(e.errCode))
+				Expect(appErr.Description).To(ContainSubstring(e.errDesc))
+				Expect(appErr.IsFatal).To(Equal(e.errIsFatal))
+			}
+		},
+
+		Entry("unexpected response code", "", url.Values{"password": {"pass"}}, expectation{
+			resCode:    http.StatusInternalServerError,
+			resBody:    "",
+			errIsNil:   false,
+			errCode:    users.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("malformed json", "", url.Values{"password": {"pass"}}, expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"foo": }`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("incorrect password", "", url.Values{"password": {"pass"}}, expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"password": "is incorrect"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeValidationFailed,
+			errDesc:    "The password you've entered is incorrect.",
+			errIsFatal: false,
+		}),
+
+		Entry("invalid two-factor code", "123456", url.Values{"password": {"pass"}, "otp": {"123456"}}, expectation{
+			resCode:    422,
+			resBody:    `{"error": "invalid_params", "errors": {"otp": "is invalid"}}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeInvalidOTP,
+			errDesc:    "",
+			errIsFatal: false,
+		}),
+
+		Entry("unexpected response", "", url.Values{"password": {"pass"}}, expectation{
+			resCode:    http.StatusOK,
+			resBody:    `{"deleted": false}`,
+			errIsNil:   false,
+			errCode:    users.ErrCodeUnexpectedError,
+			errDesc:    "",
+			errIsFatal: true,
+		}),
+
+		Entry("successful deletion", "", url.Values{"password": {"pass"}}, expectation{
+			resCode:  http.StatusOK,
+			resBody:  `{"deleted": true}`,
+			errIsNil: true,
+		}),
+	)
 })
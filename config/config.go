@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 var (
@@ -33,9 +34,21 @@ var (
 	ProjectJSON            = "pubstorm.json"
 	DefaultProjectJSONPath = "pubstorm.default.json"
 
-	DotRisePath string
-	AccessToken string
-	Email       string
+	DotRisePath  string
+	AccessToken  string
+	RefreshToken string
+	Email        string
+
+	// When AccessToken expires, if the server said, and when it was last
+	// found to be valid
+	TokenExpiresAt   time.Time
+	TokenValidatedAt time.Time
+
+	// Environment variables that, when set, override the stored access token,
+	// in order of precedence
+	AccessTokenEnvVars = []string{"PUBSTORM_TOKEN", "RISE_ACCESS_TOKEN"}
+	// Name of the environment variable AccessToken was read from, if any
+	AccessTokenEnv string
 
 	MaxProjectSize = int64(1024 * 1024 * 1000) // 1 GiB
 	MaxBundleSize  = int64(1024 * 1024 * 1000) // 1 GiB
@@ -45,10 +58,18 @@ const (
 	configJSONPath = "config.json"
 )
 
+type configJSON struct {
+	Email            string              `json:"email"`
+	AccessToken      string              `json:"access_token,omitempty"`
+	TokenExpiresAt   int64               `json:"token_expires_at,omitempty"`
+	TokenValidatedAt int64               `json:"token_validated_at,omitempty"`
+	Profile          string              `json:"profile,omitempty"`
+	Profiles         map[string]*Profile `json:"profiles,omitempty"`
+}
+
 func init() {
-	if envRiseHost := os.Getenv("RISE_HOST"); envRiseHost != "" {
-		Host = envRiseHost
-	}
+	buildHost = Host
+	buildDefaultDomain = DefaultDomain
 
 	if runtime.GOOS == "windows" {
 		DotRisePath = filepath.Join(os.Getenv("APPDATA"), "PubStorm")
@@ -65,15 +86,45 @@ func init() {
 		log.Fatalln("Failed to make data directory!")
 	}
 
+	var err error
+	if Credentials, err = DefaultCredentialStore(); err != nil {
+		log.Fatalln(err)
+	}
+
 	if err := Load(); err != nil {
 		if !os.IsNotExist(err) {
 			log.Fatalln("Failed to load PubStorm config file!")
 		}
+		UseProfile(DefaultProfileName)
+	}
+
+	for _, name := range AccessTokenEnvVars {
+		if token := os.Getenv(name); token != "" {
+			AccessToken = token
+			AccessTokenEnv = name
+			break
+		}
 	}
 }
 
-// Saves config to a json file
+// Saves config to a json file, and access tokens to Credentials
 func Save() error {
+	if p, ok := Profiles[ProfileName]; ok {
+		p.Email = Email
+		// Never persist a token that was given through the environment, and
+		// leave stored tokens alone unless they have been read or replaced.
+		if AccessTokenEnv == "" && p.tokenLoaded {
+			p.AccessToken = AccessToken
+			p.RefreshToken = RefreshToken
+			p.TokenExpiresAt = unixSeconds(TokenExpiresAt)
+			p.TokenValidatedAt = unixSeconds(TokenValidatedAt)
+		}
+	}
+
+	if err := saveTokens(); err != nil {
+		return err
+	}
+
 	configPath := filepath.Join(DotRisePath, configJSONPath)
 	f, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
@@ -81,9 +132,29 @@ func Save() error {
 	}
 	defer f.Close()
 
-	return json.NewEncoder(f).Encode(map[string]interface{}{
-		"email":        Email,
-		"access_token": AccessToken,
+	// The default profile is stored at the top level, where it was before
+	// profiles existed. Tokens are only kept in Credentials.
+	named := map[string]*Profile{}
+	for name, p := range Profiles {
+		if name != DefaultProfileName {
+			withoutToken := *p
+			withoutToken.AccessToken = ""
+			named[name] = &withoutToken
+		}
+	}
+
+	selected := SelectedProfile
+	if selected == DefaultProfileName {
+		selected = ""
+	}
+
+	def := Profiles[DefaultProfileName]
+	return json.NewEncoder(f).Encode(configJSON{
+		Email:            def.Email,
+		TokenExpiresAt:   def.TokenExpiresAt,
+		TokenValidatedAt: def.TokenValidatedAt,
+		Profile:          selected,
+		Profiles:         named,
 	})
 }
 
@@ -97,17 +168,55 @@ func Load() error {
 	}
 	defer f.Close()
 
-	var j struct {
-		Email       string `json:"email"`
-		AccessToken string `json:"access_token"`
-	}
-
+	var j configJSON
 	if err = json.NewDecoder(f).Decode(&j); err != nil {
 		return err
 	}
 
-	Email = j.Email
-	AccessToken = j.AccessToken
+	Profiles = map[string]*Profile{}
+	for name, p := range j.Profiles {
+		if p != nil && name != DefaultProfileName {
+			Profiles[name] = p
+		}
+	}
+	Profiles[DefaultProfileName] = &Profile{
+		Email:            j.Email,
+		AccessToken:      j.AccessToken,
+		TokenExpiresAt:   j.TokenExpiresAt,
+		TokenValidatedAt: j.TokenValidatedAt,
+	}
+
+	// Tokens are read from Credentials when they are needed, unless they are
+	// still in config.json from before it existed.
+	storedTokens = map[string]string{}
+	plaintextTokens = false
+	for _, p := range Profiles {
+		if p.AccessToken != "" {
+			p.tokenLoaded = true
+			plaintextTokens = true
+		}
+	}
+
+	SelectedProfile = j.Profile
+	if _, ok := Profiles[SelectedProfile]; !ok {
+		SelectedProfile = DefaultProfileName
+	}
+
+	return UseProfile(SelectedProfile)
+}
 
-	return nil
+// Converts a Unix time from config.json, where 0 means unset
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// Converts a time to a Unix time for config.json, where 0 means unset
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
 }
@@ -0,0 +1,323 @@
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "config")
+}
+
+var _ = Describe("Config", func() {
+	var (
+		tempDir string
+		err     error
+
+		origDotRisePath     string
+		origHost            string
+		origDefaultDomain   string
+		origProfiles        map[string]*config.Profile
+		origSelectedProfile string
+		origCredentials     config.CredentialStore
+
+		store *config.MemoryStore
+	)
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "config-test")
+		Expect(err).To(BeNil())
+
+		origDotRisePath = config.DotRisePath
+		origHost = config.Host
+		origDefaultDomain = config.DefaultDomain
+		origProfiles = config.Profiles
+		origSelectedProfile = config.SelectedProfile
+		origCredentials = config.Credentials
+
+		config.DotRisePath = tempDir
+		store = config.NewMemoryStore()
+		config.Credentials = store
+	})
+
+	AfterEach(func() {
+		config.DotRisePath = origDotRisePath
+		config.Profiles = origProfiles
+		config.SelectedProfile = origSelectedProfile
+		config.Credentials = origCredentials
+		config.UseProfile(config.DefaultProfileName)
+		config.Host = origHost
+		config.DefaultDomain = origDefaultDomain
+		os.RemoveAll(tempDir)
+	})
+
+	writeConfig := func(s string) {
+		err := ioutil.WriteFile(filepath.Join(tempDir, "config.json"), []byte(s), 0600)
+		Expect(err).To(BeNil())
+	}
+
+	readConfig := func() map[string]interface{} {
+		b, err := ioutil.ReadFile(filepath.Join(tempDir, "config.json"))
+		Expect(err).To(BeNil())
+
+		var j map[string]interface{}
+		Expect(json.Unmarshal(b, &j)).To(Succeed())
+		return j
+	}
+
+	Describe("Load()", func() {
+		It("loads a config file written before profiles existed into the default profile", func() {
+			writeConfig(`{"email": "foo@example.com", "access_token": "t0k3n"}`)
+
+			Expect(config.Load()).To(Succeed())
+			Expect(config.ProfileName).To(Equal(config.DefaultProfileName))
+			Expect(config.Email).To(Equal("foo@example.com"))
+			Expect(config.AccessToken).To(Equal("t0k3n"))
+		})
+
+		It("switches to the selected profile", func() {
+			writeConfig(`{
+				"email": "foo@example.com",
+				"access_token": "t0k3n",
+				"profile": "staging",
+				"profiles": {
+					"staging": {
+						"host": "https://api.staging.example.com",
+						"email": "bar@example.com",
+						"access_token": "s3cr3t",
+						"default_domain": "staging.example.com"
+					}
+				}
+			}`)
+
+			Expect(config.Load()).To(Succeed())
+			Expect(config.ProfileName).To(Equal("staging"))
+			Expect(config.Host).To(Equal("https://api.staging.example.com"))
+			Expect(config.DefaultDomain).To(Equal("staging.example.com"))
+			Expect(config.Email).To(Equal("bar@example.com"))
+			Expect(config.AccessToken).To(Equal("s3cr3t"))
+		})
+
+		It("falls back to the default profile if the selected profile does not exist", func() {
+			writeConfig(`{"email": "foo@example.com", "access_token": "t0k3n", "profile": "nope"}`)
+
+			Expect(config.Load()).To(Succeed())
+			Expect(config.SelectedProfile).To(Equal(config.DefaultProfileName))
+			Expect(config.Email).To(Equal("foo@example.com"))
+		})
+	})
+
+	Describe("Save()", func() {
+		It("writes the credentials in use back to their profile, keeping tokens in the credential store", func() {
+			writeConfig(`{
+				"email": "foo@example.com",
+				"profiles": {"staging": {"host": "https://api.staging.example.com"}}
+			}`)
+			Expect(config.Load()).To(Succeed())
+			store.Set(config.DefaultProfileName, "t0k3n")
+
+			Expect(config.UseProfile("staging")).To(Succeed())
+			config.Email = "bar@example.com"
+			config.SetTokens("s3cr3t", "", time.Time{})
+			Expect(config.Save()).To(Succeed())
+
+			Expect(readConfig()).To(Equal(map[string]interface{}{
+				"email": "foo@example.com",
+				"profiles": map[string]interface{}{
+					"staging": map[string]interface{}{
+						"host":               "https://api.staging.example.com",
+						"email":              "bar@example.com",
+						"token_validated_at": float64(config.TokenValidatedAt.Unix()),
+					},
+				},
+			}))
+
+			token, err := store.Get("staging")
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("s3cr3t"))
+
+			token, err = store.Get(config.DefaultProfileName)
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("t0k3n"))
+		})
+
+		It("removes the token of a profile that has logged out", func() {
+			writeConfig(`{"email": "foo@example.com"}`)
+			Expect(config.Load()).To(Succeed())
+			store.Set(config.DefaultProfileName, "t0k3n")
+			Expect(config.LoadAccessToken()).To(Succeed())
+
+			config.Email = ""
+			config.ClearTokens()
+			Expect(config.Save()).To(Succeed())
+
+			_, err := store.Get(config.DefaultProfileName)
+			Expect(err).To(Equal(config.ErrCredentialNotFound))
+		})
+
+		It("keeps the stored tokens if they have not been read", func() {
+			writeConfig(`{"email": "foo@example.com"}`)
+			Expect(config.Load()).To(Succeed())
+			store.Set(config.DefaultProfileName, "t0k3n")
+
+			Expect(config.Save()).To(Succeed())
+
+			token, err := store.Get(config.DefaultProfileName)
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("t0k3n"))
+		})
+
+		It("keeps the refresh token in the credential store and when tokens expire in config.json", func() {
+			writeConfig(`{"email": "foo@example.com"}`)
+			Expect(config.Load()).To(Succeed())
+
+			expiresAt := time.Unix(2000000000, 0)
+			config.SetTokens("t0k3n", "r3fr35h", expiresAt)
+			Expect(config.Save()).To(Succeed())
+
+			Expect(readConfig()).To(Equal(map[string]interface{}{
+				"email":              "foo@example.com",
+				"token_expires_at":   float64(2000000000),
+				"token_validated_at": float64(config.TokenValidatedAt.Unix()),
+			}))
+
+			token, err := store.Get(config.DefaultProfileName + ".refresh")
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("r3fr35h"))
+
+			Expect(config.Load()).To(Succeed())
+			Expect(config.TokenExpiresAt).To(Equal(expiresAt))
+			Expect(config.RefreshToken).To(BeEmpty())
+
+			Expect(config.LoadAccessToken()).To(Succeed())
+			Expect(config.AccessToken).To(Equal("t0k3n"))
+			Expect(config.RefreshToken).To(Equal("r3fr35h"))
+		})
+	})
+
+	Describe("LoadAccessToken()", func() {
+		It("reads the token of the profile in use from the credential store", func() {
+			writeConfig(`{"email": "foo@example.com", "profiles": {"staging": {"email": "bar@example.com"}}}`)
+			Expect(config.Load()).To(Succeed())
+			store.Set("staging", "s3cr3t")
+
+			Expect(config.AccessToken).To(BeEmpty())
+			Expect(config.LoadAccessToken()).To(Succeed())
+			Expect(config.AccessToken).To(BeEmpty())
+
+			Expect(config.UseProfile("staging")).To(Succeed())
+			Expect(config.LoadAccessToken()).To(Succeed())
+			Expect(config.AccessToken).To(Equal("s3cr3t"))
+		})
+	})
+
+	Describe("MigrateCredentials()", func() {
+		It("moves tokens out of config.json into the credential store", func() {
+			writeConfig(`{
+				"email": "foo@example.com",
+				"access_token": "t0k3n",
+				"profiles": {"staging": {"email": "bar@example.com", "access_token": "s3cr3t"}}
+			}`)
+			Expect(config.Load()).To(Succeed())
+			Expect(config.AccessToken).To(Equal("t0k3n"))
+
+			Expect(config.MigrateCredentials()).To(Succeed())
+
+			Expect(readConfig()).To(Equal(map[string]interface{}{
+				"email": "foo@example.com",
+				"profiles": map[string]interface{}{
+					"staging": map[string]interface{}{
+						"email": "bar@example.com",
+					},
+				},
+			}))
+
+			token, err := store.Get(config.DefaultProfileName)
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("t0k3n"))
+
+			token, err = store.Get("staging")
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("s3cr3t"))
+		})
+
+		It("leaves config.json alone if the tokens can't be stored", func() {
+			config.Credentials = config.NewFileStore(filepath.Join(tempDir, "credentials.enc"))
+			config.PassphrasePrompt = nil
+			os.Unsetenv("PUBSTORM_PASSPHRASE")
+
+			writeConfig(`{"email": "foo@example.com", "access_token": "t0k3n"}`)
+			Expect(config.Load()).To(Succeed())
+
+			Expect(config.MigrateCredentials()).To(Equal(config.ErrPassphraseRequired))
+			Expect(readConfig()["access_token"]).To(Equal("t0k3n"))
+			Expect(config.AccessToken).To(Equal("t0k3n"))
+		})
+	})
+
+	Describe("FileStore", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(tempDir, "credentials.enc")
+			os.Setenv("PUBSTORM_PASSPHRASE", "correct horse")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("PUBSTORM_PASSPHRASE")
+		})
+
+		It("stores tokens encrypted", func() {
+			s := config.NewFileStore(path)
+			_, err := s.Get("default")
+			Expect(err).To(Equal(config.ErrCredentialNotFound))
+
+			Expect(s.Set("default", "t0k3n")).To(Succeed())
+			Expect(s.Set("staging", "s3cr3t")).To(Succeed())
+
+			b, err := ioutil.ReadFile(path)
+			Expect(err).To(BeNil())
+			Expect(string(b)).NotTo(ContainSubstring("t0k3n"))
+
+			fi, err := os.Stat(path)
+			Expect(err).To(BeNil())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			s = config.NewFileStore(path)
+			token, err := s.Get("default")
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("t0k3n"))
+
+			Expect(s.Delete("default")).To(Succeed())
+			_, err = s.Get("default")
+			Expect(err).To(Equal(config.ErrCredentialNotFound))
+
+			token, err = s.Get("staging")
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("s3cr3t"))
+		})
+
+		It("returns an error if the passphrase is wrong", func() {
+			Expect(config.NewFileStore(path).Set("default", "t0k3n")).To(Succeed())
+
+			os.Setenv("PUBSTORM_PASSPHRASE", "wrong")
+			_, err := config.NewFileStore(path).Get("default")
+			Expect(err).To(Equal(config.ErrPassphraseWrong))
+		})
+	})
+
+	Describe("UseProfile()", func() {
+		It("returns an error if the profile does not exist", func() {
+			Expect(config.UseProfile("nope")).To(Equal(config.ErrProfileNotFound))
+		})
+	})
+})
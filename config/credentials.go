@@ -0,0 +1,255 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// CredentialStore keeps the access tokens of profiles out of config.json.
+type CredentialStore interface {
+	// Name describes the store to the user.
+	Name() string
+
+	// Get returns the access token of a profile, or ErrCredentialNotFound.
+	Get(profile string) (string, error)
+
+	// Set stores the access token of a profile, replacing any previous one.
+	Set(profile, token string) error
+
+	// Delete removes the access token of a profile. Deleting a token that is
+	// not there is not an error.
+	Delete(profile string) error
+}
+
+// Names of the credential stores, which PUBSTORM_CREDENTIAL_STORE can be set
+// to
+const (
+	CredentialStoreKeyring = "keyring"
+	CredentialStoreFile    = "file"
+)
+
+var (
+	// Credentials is where access tokens are stored. It is the OS keyring if
+	// there is one, and an encrypted file otherwise.
+	Credentials CredentialStore
+
+	// PassphrasePrompt asks the user for the passphrase of the encrypted
+	// credentials file, twice if confirm is set since it is a new one. It is
+	// only used if PUBSTORM_PASSPHRASE is not set.
+	PassphrasePrompt func(confirm bool) (string, error)
+
+	ErrCredentialNotFound     = errors.New("credential not found")
+	ErrCredentialStoreInvalid = errors.New("PUBSTORM_CREDENTIAL_STORE must be either \"keyring\" or \"file\"")
+
+	// Tokens that have been read from or written to Credentials, keyed by
+	// profile name, or refreshTokenKey for refresh tokens
+	storedTokens = map[string]string{}
+	// Set if config.json had tokens in it, which are moved to Credentials by
+	// MigrateCredentials
+	plaintextTokens bool
+)
+
+// Returns the credential store named by PUBSTORM_CREDENTIAL_STORE, or the OS
+// keyring if it is available and the encrypted file otherwise
+func DefaultCredentialStore() (CredentialStore, error) {
+	file := NewFileStore(credentialsFilePath())
+
+	switch os.Getenv("PUBSTORM_CREDENTIAL_STORE") {
+	case "":
+		if keyringAvailable() {
+			return NewKeyringStore(), nil
+		}
+		return file, nil
+	case CredentialStoreKeyring:
+		return NewKeyringStore(), nil
+	case CredentialStoreFile:
+		return file, nil
+	}
+
+	return nil, ErrCredentialStoreInvalid
+}
+
+// Reads the access and refresh tokens of the profile in use from Credentials,
+// unless they have been read already or a token was given through the
+// environment
+func LoadAccessToken() error {
+	if AccessTokenEnv != "" {
+		return nil
+	}
+
+	p := Profiles[ProfileName]
+	if p == nil || p.tokenLoaded {
+		return nil
+	}
+
+	token, err := loadToken(ProfileName)
+	if err != nil {
+		return err
+	}
+	refreshToken, err := loadToken(refreshTokenKey(ProfileName))
+	if err != nil {
+		return err
+	}
+
+	p.AccessToken = token
+	p.RefreshToken = refreshToken
+	p.tokenLoaded = true
+	AccessToken = token
+	RefreshToken = refreshToken
+
+	return nil
+}
+
+// Replaces the tokens of the profile in use with ones that have just been
+// granted, and so are known to be valid
+func SetTokens(accessToken, refreshToken string, expiresAt time.Time) {
+	AccessToken = accessToken
+	RefreshToken = refreshToken
+	TokenExpiresAt = expiresAt
+	TokenValidatedAt = time.Now()
+
+	if p := Profiles[ProfileName]; p != nil {
+		p.tokenLoaded = true
+	}
+}
+
+// Forgets the tokens of the profile in use, e.g. when logging out
+func ClearTokens() {
+	AccessToken = ""
+	RefreshToken = ""
+	TokenExpiresAt = time.Time{}
+	TokenValidatedAt = time.Time{}
+
+	if p := Profiles[ProfileName]; p != nil {
+		p.tokenLoaded = true
+	}
+}
+
+// Moves access tokens that config.json has in plaintext to Credentials, and
+// rewrites config.json without them. Until it succeeds, the tokens keep being
+// read from config.json.
+func MigrateCredentials() error {
+	if !plaintextTokens {
+		return nil
+	}
+
+	if err := Save(); err != nil {
+		return err
+	}
+	plaintextTokens = false
+
+	return nil
+}
+
+// Writes the tokens of the profiles that have changed since they were read to
+// Credentials
+func saveTokens() error {
+	for name, p := range Profiles {
+		if !p.tokenLoaded {
+			continue
+		}
+
+		if err := saveToken(name, p.AccessToken); err != nil {
+			return err
+		}
+		if err := saveToken(refreshTokenKey(name), p.RefreshToken); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads a token from Credentials, remembering it so that it is only written
+// back if it changes. A token that is not there is empty.
+func loadToken(key string) (string, error) {
+	token, err := Credentials.Get(key)
+	if err != nil && err != ErrCredentialNotFound {
+		return "", err
+	}
+
+	storedTokens[key] = token
+	return token, nil
+}
+
+// Writes a token to Credentials, or deletes it if it is empty, unless it is
+// unchanged
+func saveToken(key, token string) error {
+	if stored, ok := storedTokens[key]; ok && stored == token {
+		return nil
+	}
+
+	var err error
+	if token == "" {
+		err = Credentials.Delete(key)
+	} else {
+		err = Credentials.Set(key, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	storedTokens[key] = token
+	return nil
+}
+
+// Refresh tokens are stored next to access tokens, under a name that no
+// profile can have.
+func refreshTokenKey(profile string) string {
+	return profile + ".refresh"
+}
+
+// Removes the tokens of a profile that no longer exists from Credentials
+func DeleteAccessToken(profile string) error {
+	for _, key := range []string{profile, refreshTokenKey(profile)} {
+		delete(storedTokens, key)
+		if err := Credentials.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryStore is a CredentialStore that only lasts as long as the process,
+// for tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: map[string]string{}}
+}
+
+func (s *MemoryStore) Name() string {
+	return "memory"
+}
+
+func (s *MemoryStore) Get(profile string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[profile]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return token, nil
+}
+
+func (s *MemoryStore) Set(profile, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[profile] = token
+	return nil
+}
+
+func (s *MemoryStore) Delete(profile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, profile)
+	return nil
+}
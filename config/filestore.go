@@ -0,0 +1,235 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	credentialsFileName = "credentials.enc"
+
+	// PBKDF2 iterations used for new credentials files. The number used is
+	// stored in the file, so it can be raised without breaking older files.
+	pbkdf2Iterations = 200000
+
+	keySize  = 32
+	saltSize = 16
+)
+
+var (
+	ErrPassphraseRequired = errors.New("a passphrase is required to unlock the credentials file, set PUBSTORM_PASSPHRASE")
+	ErrPassphraseWrong    = errors.New("the passphrase of the credentials file is wrong, or the file is corrupt")
+)
+
+// FileStore is a CredentialStore that keeps tokens in a file encrypted with
+// AES-256-GCM, using a key derived from a passphrase with PBKDF2-SHA256. The
+// passphrase is read from PUBSTORM_PASSPHRASE, or asked for with
+// PassphrasePrompt, once per process.
+type FileStore struct {
+	path string
+
+	mu         sync.Mutex
+	passphrase string
+}
+
+// The contents of the credentials file
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func credentialsFilePath() string {
+	return filepath.Join(DotRisePath, credentialsFileName)
+}
+
+func (s *FileStore) Name() string {
+	return "encrypted file " + s.path
+}
+
+func (s *FileStore) Get(profile string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	token, ok := tokens[profile]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return token, nil
+}
+
+func (s *FileStore) Set(profile, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	tokens[profile] = token
+	return s.write(tokens)
+}
+
+func (s *FileStore) Delete(profile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[profile]; !ok {
+		return nil
+	}
+
+	delete(tokens, profile)
+	return s.write(tokens)
+}
+
+// Decrypts the file. A file that does not exist holds no tokens.
+func (s *FileStore) read() (map[string]string, error) {
+	b, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, ErrPassphraseWrong
+	}
+
+	passphrase, err := s.unlock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return nil, ErrPassphraseWrong
+	}
+
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		// Ask again next time rather than failing with the same passphrase.
+		s.passphrase = ""
+		return nil, ErrPassphraseWrong
+	}
+
+	tokens := map[string]string{}
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, ErrPassphraseWrong
+	}
+	return tokens, nil
+}
+
+// Encrypts tokens with a new salt and nonce, and replaces the file
+func (s *FileStore) write(tokens map[string]string) error {
+	_, err := os.Stat(s.path)
+	passphrase, err := s.unlock(os.IsNotExist(err))
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	f := encryptedFile{
+		Version:    1,
+		Iterations: pbkdf2Iterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plaintext, nil)
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that the tokens are not lost if
+	// writing fails halfway.
+	tmpPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// Returns the passphrase, asking for it if it has not been given yet
+func (s *FileStore) unlock(isNew bool) (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+
+	passphrase := os.Getenv("PUBSTORM_PASSPHRASE")
+	if passphrase == "" && PassphrasePrompt != nil {
+		var err error
+		if passphrase, err = PassphrasePrompt(isNew); err != nil {
+			return "", err
+		}
+	}
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+
+	s.passphrase = passphrase
+	return passphrase, nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if len(salt) != saltSize || iterations < 1 {
+		return nil, ErrPassphraseWrong
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,42 @@
+package config
+
+import "errors"
+
+var ErrKeyringUnavailable = errors.New("no keyring is available")
+
+// KeyringStore is a CredentialStore that keeps tokens in the keyring of the
+// OS: the Secret Service (GNOME Keyring, KWallet) on Linux, reached over D-Bus
+// through libsecret's secret-tool, the login keychain on macOS and the
+// Credential Manager on Windows.
+type KeyringStore struct {
+	service string
+}
+
+func NewKeyringStore() *KeyringStore {
+	return &KeyringStore{service: keyringService()}
+}
+
+// Returns the name tokens are filed under, which differs between builds so
+// that development builds don't overwrite the tokens of released ones
+func keyringService() string {
+	if BuildEnv != "production" {
+		return AppName + "-" + BuildEnv
+	}
+	return AppName
+}
+
+func (s *KeyringStore) Name() string {
+	return "keyring"
+}
+
+func (s *KeyringStore) Get(profile string) (string, error) {
+	return keyringGet(s.service, profile)
+}
+
+func (s *KeyringStore) Set(profile, token string) error {
+	return keyringSet(s.service, profile, token)
+}
+
+func (s *KeyringStore) Delete(profile string) error {
+	return keyringDelete(s.service, profile)
+}
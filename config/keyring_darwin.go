@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Status security exits with if an item could not be found
+const errSecItemNotFound = 44
+
+// The login keychain is always there.
+func keyringAvailable() bool {
+	return true
+}
+
+func keyringGet(service, profile string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", profile, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func keyringSet(service, profile, token string) error {
+	// Commands are given through stdin in interactive mode, so that other
+	// processes can't see the token in the arguments.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", service, profile, token))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return securityError(err)
+	}
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return errors.New("security: " + msg)
+	}
+	return nil
+}
+
+func keyringDelete(service, profile string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", service, "-a", profile).Run()
+	if err := securityError(err); err != ErrCredentialNotFound {
+		return err
+	}
+	return nil
+}
+
+func securityError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == errSecItemNotFound {
+			return ErrCredentialNotFound
+		}
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return errors.New("security: " + msg)
+		}
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Reports whether secret-tool is installed and there is a D-Bus session for it
+// to reach the Secret Service through
+func keyringAvailable() bool {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return false
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		return true
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		_, err := os.Stat(filepath.Join(dir, "bus"))
+		return err == nil
+	}
+	return false
+}
+
+func keyringGet(service, profile string) (string, error) {
+	out, err := secretTool(nil, "lookup", "service", service, "profile", profile)
+	if err != nil {
+		// secret-tool exits with 1 and prints nothing if there is no such
+		// secret.
+		if exitErr, ok := err.(*exec.ExitError); ok && len(out) == 0 && len(exitErr.Stderr) == 0 {
+			return "", ErrCredentialNotFound
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func keyringSet(service, profile, token string) error {
+	// The token is passed through stdin so that other processes can't see it
+	// in the arguments.
+	_, err := secretTool(strings.NewReader(token), "store", "--label", AppName+" access token ("+profile+")", "service", service, "profile", profile)
+	return err
+}
+
+func keyringDelete(service, profile string) error {
+	_, err := secretTool(nil, "clear", "service", service, "profile", profile)
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) == 0 {
+		// nothing to clear
+		return nil
+	}
+	return err
+}
+
+func secretTool(stdin *strings.Reader, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, ErrKeyringUnavailable
+	}
+
+	cmd := exec.Command("secret-tool", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitErr.Stderr = stderr.Bytes()
+		if stderr.Len() > 0 {
+			return out, errors.New("secret-tool: " + strings.TrimSpace(stderr.String()))
+		}
+	}
+	return out, err
+}
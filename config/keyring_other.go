@@ -0,0 +1,19 @@
+// +build !linux,!darwin,!windows
+
+package config
+
+func keyringAvailable() bool {
+	return false
+}
+
+func keyringGet(service, profile string) (string, error) {
+	return "", ErrKeyringUnavailable
+}
+
+func keyringSet(service, profile, token string) error {
+	return ErrKeyringUnavailable
+}
+
+func keyringDelete(service, profile string) error {
+	return ErrKeyringUnavailable
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"regexp"
+)
+
+const DefaultProfileName = "default"
+
+// Profile holds the account and server a set of commands is run against.
+// Empty Host and DefaultDomain fall back to the values the CLI was built with.
+type Profile struct {
+	Host          string `json:"host,omitempty"`
+	Email         string `json:"email,omitempty"`
+	AccessToken   string `json:"access_token,omitempty"`
+	DefaultDomain string `json:"default_domain,omitempty"`
+
+	// RefreshToken is only ever kept in Credentials.
+	RefreshToken string `json:"-"`
+	// Unix times of when AccessToken expires, if the server said, and of when
+	// it was last found to be valid
+	TokenExpiresAt   int64 `json:"token_expires_at,omitempty"`
+	TokenValidatedAt int64 `json:"token_validated_at,omitempty"`
+
+	// Set once the tokens have been read from Credentials, or from
+	// config.json if they were stored there, or replaced
+	tokenLoaded bool
+}
+
+var (
+	// Profiles keyed by name. The default profile always exists.
+	Profiles = map[string]*Profile{DefaultProfileName: {}}
+	// Profile selected with `storm profile use`
+	SelectedProfile = DefaultProfileName
+	// Profile in use, which Host, Email, AccessToken and DefaultDomain were
+	// set from
+	ProfileName = DefaultProfileName
+
+	ErrProfileNotFound    = errors.New("profile does not exist")
+	ErrProfileNameInvalid = errors.New("Profile name may only contain letters, numbers, hyphens and underscores")
+
+	profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+	buildHost          string
+	buildDefaultDomain string
+)
+
+// Returns the API host of the profile
+func (p *Profile) HostOrDefault() string {
+	if p.Host != "" {
+		return p.Host
+	}
+	return buildHost
+}
+
+// Returns the default domain of the profile
+func (p *Profile) DefaultDomainOrDefault() string {
+	if p.DefaultDomain != "" {
+		return p.DefaultDomain
+	}
+	return buildDefaultDomain
+}
+
+// Switches Host, Email, AccessToken and DefaultDomain to the values of the
+// named profile. RISE_HOST and access tokens given through the environment
+// still take precedence.
+func UseProfile(name string) error {
+	p, ok := Profiles[name]
+	if !ok {
+		return ErrProfileNotFound
+	}
+
+	ProfileName = name
+
+	Host = p.HostOrDefault()
+	if envRiseHost := os.Getenv("RISE_HOST"); envRiseHost != "" {
+		Host = envRiseHost
+	}
+
+	DefaultDomain = p.DefaultDomainOrDefault()
+
+	// AccessToken and RefreshToken stay empty until LoadAccessToken is
+	// called, unless they have been read already.
+	Email = p.Email
+	if AccessTokenEnv == "" {
+		AccessToken = p.AccessToken
+		RefreshToken = p.RefreshToken
+		TokenExpiresAt = unixTime(p.TokenExpiresAt)
+		TokenValidatedAt = unixTime(p.TokenValidatedAt)
+	}
+
+	return nil
+}
+
+// Validates a profile name
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return ErrProfileNameInvalid
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package multipartstream streams multipart/form-data request bodies made up of
+// files on disk, without holding the files in memory.
+package multipartstream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+)
+
+var ErrFileChanged = errors.New("file changed while it was being uploaded")
+
+// File is a file sent as the value of a form field
+type File struct {
+	FieldName string
+	Path      string
+}
+
+// Body is a multipart/form-data body whose length is known before it is
+// written.
+type Body struct {
+	ContentType   string
+	ContentLength int64
+
+	files    []File
+	sizes    []int64
+	boundary string
+}
+
+// Prepares a body containing files, in order. The files are stat'ed but not
+// read until Reader is called.
+func New(files ...File) (*Body, error) {
+	b := &Body{files: files}
+
+	// Write the body with empty files to work out the length of the multipart
+	// framing; the length of the body is that plus the size of the files.
+	frame := &bytes.Buffer{}
+	w := multipart.NewWriter(frame)
+	b.boundary = w.Boundary()
+	b.ContentType = w.FormDataContentType()
+
+	for _, file := range files {
+		fi, err := os.Stat(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		b.sizes = append(b.sizes, fi.Size())
+		b.ContentLength += fi.Size()
+
+		if _, err := w.CreateFormFile(file.FieldName, filepath.Base(file.Path)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	b.ContentLength += int64(frame.Len())
+
+	return b, nil
+}
+
+// Returns a reader that streams the body from disk. It must be closed so that
+// the goroutine writing the body exits if the body is not read to the end.
+func (b *Body) Reader() io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(b.write(pw))
+	}()
+
+	return pr
+}
+
+func (b *Body) write(out io.Writer) error {
+	w := multipart.NewWriter(out)
+	if err := w.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+
+	for i, file := range b.files {
+		part, err := w.CreateFormFile(file.FieldName, filepath.Base(file.Path))
+		if err != nil {
+			return err
+		}
+
+		if err := copyFile(part, file.Path, b.sizes[i]); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// Copies exactly size bytes of the file at path to w, failing if the file no
+// longer has that size, since the Content-Length would then be wrong.
+func copyFile(w io.Writer, path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(w, f, size); err != nil {
+		if err == io.EOF {
+			return ErrFileChanged
+		}
+		return err
+	}
+
+	if n, _ := f.Read(make([]byte, 1)); n != 0 {
+		return ErrFileChanged
+	}
+
+	return nil
+}
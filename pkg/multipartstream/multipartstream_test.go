@@ -0,0 +1,101 @@
+package multipartstream_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nitrous-io/rise-cli-go/pkg/multipartstream"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func readFormFile(fh *multipart.FileHeader) string {
+	f, err := fh.Open()
+	Expect(err).To(BeNil())
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	Expect(err).To(BeNil())
+	return string(b)
+}
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "multipartstream")
+}
+
+var _ = Describe("Body", func() {
+	var (
+		tempDir string
+		err     error
+	)
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "multipartstream")
+		Expect(err).To(BeNil())
+
+		Expect(ioutil.WriteFile(filepath.Join(tempDir, "cert.pem"), []byte("certificate"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tempDir, "key.pem"), []byte("private key"), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	files := func() []multipartstream.File {
+		return []multipartstream.File{
+			{FieldName: "cert", Path: filepath.Join(tempDir, "cert.pem")},
+			{FieldName: "key", Path: filepath.Join(tempDir, "key.pem")},
+		}
+	}
+
+	It("streams a multipart body of the precomputed length", func() {
+		body, err := multipartstream.New(files()...)
+		Expect(err).To(BeNil())
+
+		r := body.Reader()
+		defer r.Close()
+
+		b, err := ioutil.ReadAll(r)
+		Expect(err).To(BeNil())
+		Expect(int64(len(b))).To(Equal(body.ContentLength))
+
+		mediaType, params, err := mime.ParseMediaType(body.ContentType)
+		Expect(err).To(BeNil())
+		Expect(mediaType).To(Equal("multipart/form-data"))
+
+		form, err := multipart.NewReader(bytes.NewReader(b), params["boundary"]).ReadForm(1024)
+		Expect(err).To(BeNil())
+
+		Expect(form.File["cert"]).To(HaveLen(1))
+		Expect(form.File["cert"][0].Filename).To(Equal("cert.pem"))
+		Expect(readFormFile(form.File["cert"][0])).To(Equal("certificate"))
+
+		Expect(form.File["key"]).To(HaveLen(1))
+		Expect(form.File["key"][0].Filename).To(Equal("key.pem"))
+		Expect(readFormFile(form.File["key"][0])).To(Equal("private key"))
+	})
+
+	It("returns an error if a file does not exist", func() {
+		_, err := multipartstream.New(multipartstream.File{FieldName: "payload", Path: filepath.Join(tempDir, "nope")})
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("fails the stream if a file changes size after New", func() {
+		body, err := multipartstream.New(files()...)
+		Expect(err).To(BeNil())
+
+		Expect(ioutil.WriteFile(filepath.Join(tempDir, "key.pem"), []byte("a longer private key"), 0600)).To(Succeed())
+
+		r := body.Reader()
+		defer r.Close()
+
+		_, err = ioutil.ReadAll(r)
+		Expect(err).To(Equal(multipartstream.ErrFileChanged))
+	})
+})
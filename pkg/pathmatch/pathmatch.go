@@ -1,37 +1,272 @@
 package pathmatch
 
 import (
-	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
-func PathMatch(path, pattern string) bool {
-	sep := string(os.PathSeparator)
+// Pattern is a compiled gitignore-style pattern.
+type Pattern struct {
+	// Source is the pattern as it was written.
+	Source string
+	// Base is the slash-separated directory, relative to the root of the
+	// matcher, that the pattern applies to (e.g. the directory containing the
+	// ignore file the pattern was read from). It is empty for the root.
+	Base string
+	// Prefix is the slash-separated path of the root of the matcher relative
+	// to the directory the pattern applies to, if that directory is above the
+	// root (e.g. "public" for a pattern read from the ignore file next to a
+	// project whose files are in "public"). Paths are matched as if they
+	// began with it. Base is empty if Prefix is set.
+	Prefix string
+	// File is the slash-separated path of the ignore file the pattern was
+	// read from, relative to the root of the matcher, for telling users where
+	// a rule comes from. It is empty for patterns that were not read from a
+	// file.
+	File string
+
+	negate   bool
+	dirOnly  bool
+	segments []string
+}
+
+// Compile parses a single gitignore-style pattern. Supported syntax:
+//
+//   - "*", "?" and "[...]" match within a single path element
+//   - "**" matches zero or more directories
+//   - a leading "!" negates the pattern, re-including what it matches
+//   - a trailing "/" only matches directories
+//   - a pattern containing a "/" (other than a trailing one) is anchored to
+//     base, otherwise it matches at any depth below base
+func Compile(pattern, base string) *Pattern {
+	p := &Pattern{Source: pattern, Base: strings.Trim(filepath.ToSlash(base), "/")}
+
+	if strings.HasPrefix(pattern, "!") {
+		p.negate = true
+		pattern = pattern[1:]
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimLeft(pattern, "/")
+
+	if !anchored {
+		p.segments = []string{"**", pattern}
+	} else {
+		p.segments = strings.Split(pattern, "/")
+	}
+
+	return p
+}
+
+// CompileAll compiles patterns that apply to base.
+func CompileAll(base string, patterns ...string) []*Pattern {
+	compiled := make([]*Pattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		compiled = append(compiled, Compile(pattern, base))
+	}
+	return compiled
+}
+
+// Rebase returns a copy of the pattern that matches paths relative to dir, a
+// slash-separated directory relative to the root of the matcher, as the
+// pattern matches them relative to the root. It returns nil if the pattern
+// cannot match anything inside dir.
+func (p *Pattern) Rebase(dir string) *Pattern {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	if dir == "" || dir == "." {
+		return p
+	}
+
+	q := *p
+	switch {
+	case p.Base == "":
+		q.Prefix = path.Join(p.Prefix, dir)
+	case p.Base == dir:
+		q.Base = ""
+	case strings.HasPrefix(dir, p.Base+"/"):
+		q.Base = ""
+		q.Prefix = dir[len(p.Base)+1:]
+	case strings.HasPrefix(p.Base, dir+"/"):
+		q.Base = p.Base[len(dir)+1:]
+	default:
+		return nil
+	}
+	return &q
+}
+
+// Negated reports whether the pattern re-includes the paths it matches.
+func (p *Pattern) Negated() bool {
+	return p.negate
+}
+
+// Match reports whether the slash-separated path, relative to the root of the
+// matcher, matches the pattern. Parent directories are not considered.
+func (p *Pattern) Match(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	relPath = strings.Trim(relPath, "/")
+	if relPath != "" && p.Prefix != "" {
+		relPath = p.Prefix + "/" + relPath
+	}
+	if p.Base != "" {
+		if !strings.HasPrefix(relPath, p.Base+"/") {
+			return false
+		}
+		relPath = relPath[len(p.Base)+1:]
+	}
+
+	if relPath == "" {
+		return false
+	}
+
+	return matchSegments(p.segments, strings.Split(relPath, "/"))
+}
 
-	if !strings.HasPrefix(path, sep) {
-		path = sep + path
+func matchSegments(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// A trailing "**" matches everything inside, but not the directory
+			// itself.
+			if len(pattern) == 1 {
+				return len(elems) > 0
+			}
+			for i := 0; i <= len(elems); i++ {
+				if matchSegments(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(elems) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(pattern[0], elems[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		elems = elems[1:]
 	}
 
-	if !strings.HasSuffix(path, sep) {
-		path = path + sep
+	return len(elems) == 0
+}
+
+// Matcher evaluates a list of patterns the way git evaluates .gitignore files:
+// the last matching pattern wins, and a path inside an ignored directory is
+// ignored regardless of any negated pattern. It is safe for concurrent use.
+type Matcher struct {
+	mu       sync.RWMutex
+	patterns []*Pattern
+}
+
+func NewMatcher() *Matcher {
+	return &Matcher{}
+}
+
+// Add compiles patterns that apply to base, a directory relative to the root
+// of the matcher. Patterns added later take precedence.
+func (m *Matcher) Add(base string, patterns ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.patterns = append(m.patterns, CompileAll(base, patterns...)...)
+}
+
+// AddPatterns adds patterns that have been compiled already. Patterns added
+// later take precedence.
+func (m *Matcher) AddPatterns(patterns ...*Pattern) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.patterns = append(m.patterns, patterns...)
+}
+
+// Patterns returns the patterns of the matcher, in the order they were added.
+func (m *Matcher) Patterns() []*Pattern {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	patterns := make([]*Pattern, len(m.patterns))
+	copy(patterns, m.patterns)
+	return patterns
+}
+
+// Match reports whether relPath is ignored, along with the pattern that
+// decided it. If relPath is re-included by a negated pattern, ignored is
+// false and p is that pattern. If no pattern matches, p is nil.
+func (m *Matcher) Match(relPath string, isDir bool) (ignored bool, p *Pattern) {
+	relPath = strings.Trim(filepath.ToSlash(relPath), "/")
+	if relPath == "" {
+		return false, nil
 	}
 
-	if !strings.HasPrefix(pattern, sep) {
-		pattern = sep + pattern
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	elems := strings.Split(relPath, "/")
+	for i := 1; i < len(elems); i++ {
+		if ignored, p := m.matchOne(strings.Join(elems[:i], "/"), true); ignored {
+			return true, p
+		}
 	}
 
-	if !strings.HasSuffix(pattern, sep) {
-		pattern = pattern + sep
+	return m.matchOne(relPath, isDir)
+}
+
+func (m *Matcher) matchOne(relPath string, isDir bool) (ignored bool, p *Pattern) {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if m.patterns[i].Match(relPath, isDir) {
+			return !m.patterns[i].negate, m.patterns[i]
+		}
 	}
+	return false, nil
+}
 
-	return strings.Contains(path, pattern)
+// PathMatch reports whether path matches the gitignore-style pattern. See
+// PathMatchAny.
+func PathMatch(path, pattern string) bool {
+	return PathMatchAny(path, pattern)
 }
 
+// PathMatchAny reports whether path is matched by patterns. Because path is
+// not relative to a known root, anchored patterns may match starting at any
+// directory in it, and the last element of path is assumed to possibly be a
+// directory. Use a Matcher when the root is known.
 func PathMatchAny(path string, patterns ...string) bool {
+	elems := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+
+	compiled := make([]*Pattern, 0, len(patterns))
 	for _, pattern := range patterns {
-		if PathMatch(path, pattern) {
-			return true
+		compiled = append(compiled, Compile(pattern, ""))
+	}
+
+	for i := range elems {
+		for j := i + 1; j <= len(elems); j++ {
+			subPath := strings.Join(elems[i:j], "/")
+
+			matched := false
+			for _, p := range compiled {
+				if p.Match(subPath, true) {
+					matched = !p.negate
+				}
+			}
+
+			if matched {
+				return true
+			}
 		}
 	}
+
 	return false
 }
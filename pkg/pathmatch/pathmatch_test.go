@@ -60,4 +60,138 @@ var _ = Describe("Pathmatch", func() {
 
 		Entry("does not match", ".unison/foo/bar/baz", []string{".funison", "bar/baw"}, false),
 	)
+
+	DescribeTable("Pattern.Match",
+		func(pattern, base, path string, isDir, match bool) {
+			p := pathmatch.Compile(pattern, base)
+			Expect(p.Match(path, isDir)).To(Equal(match))
+		},
+
+		Entry("glob matches at any depth", "*.map", "", "js/app.js.map", false, true),
+		Entry("glob matches at root", "*.map", "", "app.js.map", false, true),
+		Entry("glob does not match other extension", "*.map", "", "js/app.js", false, false),
+		Entry("glob does not cross directories", "js/*.js", "", "js/vendor/jquery.js", false, false),
+		Entry("question mark", "file?.txt", "", "file1.txt", false, true),
+		Entry("character class", "file[0-9].txt", "", "filea.txt", false, false),
+
+		Entry("double star in the middle", "build/**/*.tmp", "", "build/a/b/c.tmp", false, true),
+		Entry("double star matches zero directories", "build/**/*.tmp", "", "build/c.tmp", false, true),
+		Entry("double star is anchored", "build/**/*.tmp", "", "src/build/c.tmp", false, false),
+		Entry("leading double star", "**/logs", "", "a/b/logs", true, true),
+		Entry("trailing double star matches contents", "docs/**", "", "docs/a/b.html", false, true),
+		Entry("trailing double star does not match directory itself", "docs/**", "", "docs", true, false),
+
+		Entry("leading slash anchors", "/dist", "", "dist", true, true),
+		Entry("leading slash does not match nested", "/dist", "", "src/dist", true, false),
+		Entry("inner slash anchors", "tmp/sessions", "", "foo/tmp/sessions", true, false),
+
+		Entry("trailing slash matches directories", "build/", "", "src/build", true, true),
+		Entry("trailing slash does not match files", "build/", "", "src/build", false, false),
+
+		Entry("base restricts matches", "*.tmp", "sub", "sub/a.tmp", false, true),
+		Entry("base does not match outside", "*.tmp", "sub", "other/a.tmp", false, false),
+		Entry("anchored to base", "/dist", "sub", "sub/dist", true, true),
+		Entry("anchored to base does not match root", "/dist", "sub", "dist", true, false),
+
+		Entry("escaped hash", "\\#notes", "", "#notes", false, true),
+	)
+
+	DescribeTable("Matcher.Match",
+		func(patterns []string, path string, isDir, ignored bool, source string) {
+			m := pathmatch.NewMatcher()
+			m.Add("", patterns...)
+
+			v, p := m.Match(path, isDir)
+			Expect(v).To(Equal(ignored))
+			if source == "" {
+				Expect(p).To(BeNil())
+			} else {
+				Expect(p).NotTo(BeNil())
+				Expect(p.Source).To(Equal(source))
+			}
+		},
+
+		Entry("not matched", []string{"*.map"}, "index.html", false, false, ""),
+		Entry("matched", []string{"*.map"}, "app.js.map", false, true, "*.map"),
+		Entry("negated", []string{"*.txt", "!keep.txt"}, "keep.txt", false, false, "!keep.txt"),
+		Entry("last pattern wins", []string{"!keep.txt", "*.txt"}, "keep.txt", false, true, "*.txt"),
+		Entry("ignored parent wins over negation", []string{"build/", "!build/keep.txt"}, "build/keep.txt", false, true, "build/"),
+	)
+
+	It("applies patterns relative to the directory they were added for", func() {
+		m := pathmatch.NewMatcher()
+		m.Add("", "*.log")
+		m.Add("docs", "/drafts", "!debug.log")
+
+		ignored, _ := m.Match("docs/drafts", true)
+		Expect(ignored).To(BeTrue())
+
+		ignored, _ = m.Match("drafts", true)
+		Expect(ignored).To(BeFalse())
+
+		ignored, _ = m.Match("docs/debug.log", false)
+		Expect(ignored).To(BeFalse())
+
+		ignored, _ = m.Match("debug.log", false)
+		Expect(ignored).To(BeTrue())
+	})
+
+	It("matches patterns with a prefix as if paths began with it", func() {
+		m := pathmatch.NewMatcher()
+		for _, p := range pathmatch.CompileAll("", "public/drafts", "/public/secret.txt", "*.log", "/other") {
+			p.Prefix = "public"
+			m.AddPatterns(p)
+		}
+
+		ignored, _ := m.Match("drafts/a.html", false)
+		Expect(ignored).To(BeTrue())
+
+		ignored, _ = m.Match("secret.txt", false)
+		Expect(ignored).To(BeTrue())
+
+		ignored, _ = m.Match("js/debug.log", false)
+		Expect(ignored).To(BeTrue())
+
+		ignored, _ = m.Match("other", true)
+		Expect(ignored).To(BeFalse())
+
+		ignored, _ = m.Match("public/drafts", true)
+		Expect(ignored).To(BeFalse())
+	})
+
+	DescribeTable("Pattern.Rebase",
+		func(pattern, base, prefix, dir, path string, isDir, match, rebased bool) {
+			p := pathmatch.Compile(pattern, base)
+			p.Prefix = prefix
+
+			q := p.Rebase(dir)
+			if !rebased {
+				Expect(q).To(BeNil())
+				return
+			}
+			Expect(q).NotTo(BeNil())
+			Expect(q.Match(path, isDir)).To(Equal(match))
+			Expect(p.Match(dir+"/"+path, isDir)).To(Equal(match))
+		},
+
+		Entry("unanchored", "*.map", "", "", "js", "app.js.map", false, true, true),
+		Entry("anchored at the root", "/js/vendor", "", "", "js", "vendor", true, true, true),
+		Entry("anchored elsewhere", "/css/vendor", "", "", "js", "vendor", true, false, true),
+		Entry("with a prefix", "public/js/*.map", "", "public", "js", "app.js.map", false, true, true),
+		Entry("from the directory itself", "/drafts", "js", "", "js", "drafts", true, true, true),
+		Entry("from a parent directory", "/b/drafts", "a", "", "a/b", "drafts", true, true, true),
+		Entry("from a subdirectory", "/drafts", "a/b", "", "a", "b/drafts", true, true, true),
+		Entry("from another directory", "*.map", "css", "", "js", "app.js.map", false, false, false),
+	)
+
+	DescribeTable("PathMatchAny with gitignore patterns",
+		func(path string, patterns []string, match bool) {
+			Expect(pathmatch.PathMatchAny(path, patterns...)).To(Equal(match))
+		},
+
+		Entry("glob", "/foo/bar/app.js.map", []string{"*.map"}, true),
+		Entry("double star", "/foo/build/a/b.tmp", []string{"build/**/*.tmp"}, true),
+		Entry("negation", "/foo/keep.txt", []string{"*.txt", "!keep.txt"}, false),
+		Entry("negation of other file", "/foo/drop.txt", []string{"*.txt", "!keep.txt"}, true),
+	)
 })
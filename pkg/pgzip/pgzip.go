@@ -0,0 +1,217 @@
+// Package pgzip writes gzip streams using multiple cores.
+//
+// The input is split into blocks that are compressed concurrently, each primed
+// with the last 32 KiB of the block before it, and the compressed blocks are
+// joined into a single deflate stream. The output is a standard gzip file with
+// a single member, and it only depends on the input, the compression level and
+// the block size, never on the number of workers or on scheduling.
+package pgzip
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	DefaultBlockSize = 1 << 20 // 1 MiB
+
+	// Size of the deflate window, and hence of the dictionary a block is
+	// primed with
+	dictSize = 32 << 10
+)
+
+var ErrClosed = errors.New("pgzip: write to closed writer")
+
+type block struct {
+	data  []byte
+	dict  []byte
+	final bool
+	out   chan []byte
+}
+
+// Writer is an io.WriteCloser that gzips what is written to it. Close must be
+// called to flush the last block and write the gzip trailer.
+type Writer struct {
+	w         io.Writer
+	level     int
+	blockSize int
+
+	buf    []byte
+	dict   []byte
+	crc    uint32
+	size   uint32
+	closed bool
+
+	// Compressed blocks are written to w in the order they are queued.
+	queue chan *block
+	sem   chan struct{}
+	done  chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// Returns a Writer that compresses blocks of DefaultBlockSize bytes with as
+// many workers as there are CPUs
+func NewWriter(w io.Writer) *Writer {
+	zw, _ := NewWriterLevel(w, flate.DefaultCompression, DefaultBlockSize, runtime.NumCPU())
+	return zw
+}
+
+// Returns a Writer with the given compression level, block size and maximum
+// number of blocks compressed at once
+func NewWriterLevel(w io.Writer, level, blockSize, workers int) (*Writer, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return nil, errors.New("pgzip: invalid compression level")
+	}
+	if blockSize <= 0 {
+		return nil, errors.New("pgzip: invalid block size")
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	zw := &Writer{
+		w:         w,
+		level:     level,
+		blockSize: blockSize,
+		buf:       make([]byte, 0, blockSize),
+		queue:     make(chan *block, workers),
+		sem:       make(chan struct{}, workers),
+		done:      make(chan struct{}),
+	}
+	go zw.writeLoop()
+
+	return zw, nil
+}
+
+func (zw *Writer) Write(p []byte) (int, error) {
+	if zw.closed {
+		return 0, ErrClosed
+	}
+	if err := zw.error(); err != nil {
+		return 0, err
+	}
+
+	zw.crc = crc32.Update(zw.crc, crc32.IEEETable, p)
+	zw.size += uint32(len(p))
+
+	n := len(p)
+	for len(p) > 0 {
+		c := copy(zw.buf[len(zw.buf):cap(zw.buf)], p)
+		zw.buf = zw.buf[:len(zw.buf)+c]
+		p = p[c:]
+
+		if len(zw.buf) == cap(zw.buf) {
+			zw.dispatch(false)
+		}
+	}
+
+	return n, zw.error()
+}
+
+// Compresses the last block, and writes it along with the gzip trailer
+func (zw *Writer) Close() error {
+	if zw.closed {
+		return zw.error()
+	}
+	zw.closed = true
+
+	zw.dispatch(true)
+	close(zw.queue)
+	<-zw.done
+
+	if err := zw.error(); err != nil {
+		return err
+	}
+
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[0:4], zw.crc)
+	binary.LittleEndian.PutUint32(trailer[4:8], zw.size)
+	_, err := zw.w.Write(trailer[:])
+	return err
+}
+
+// Starts compressing the buffered data
+func (zw *Writer) dispatch(final bool) {
+	b := &block{
+		data:  zw.buf,
+		dict:  zw.dict,
+		final: final,
+		out:   make(chan []byte, 1),
+	}
+
+	// The next block is primed with the end of this one.
+	if len(b.data) >= dictSize {
+		zw.dict = b.data[len(b.data)-dictSize:]
+	} else {
+		zw.dict = append(append([]byte{}, zw.dict...), b.data...)
+		if len(zw.dict) > dictSize {
+			zw.dict = zw.dict[len(zw.dict)-dictSize:]
+		}
+	}
+	zw.buf = make([]byte, 0, zw.blockSize)
+
+	zw.queue <- b
+	zw.sem <- struct{}{}
+	go func() {
+		defer func() { <-zw.sem }()
+		b.out <- compress(b, zw.level)
+	}()
+}
+
+func compress(b *block, level int) []byte {
+	var out bytes.Buffer
+
+	// The level has been validated already, so this cannot fail.
+	fw, _ := flate.NewWriterDict(&out, level, b.dict)
+	fw.Write(b.data)
+	if b.final {
+		fw.Close()
+	} else {
+		// Ends the block on a byte boundary without marking the end of the
+		// stream, so that the next block can follow it directly.
+		fw.Flush()
+	}
+
+	return out.Bytes()
+}
+
+func (zw *Writer) writeLoop() {
+	defer close(zw.done)
+
+	header := []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 255}
+	if _, err := zw.w.Write(header); err != nil {
+		zw.setError(err)
+	}
+
+	for b := range zw.queue {
+		out := <-b.out
+		if zw.error() != nil {
+			continue
+		}
+		if _, err := zw.w.Write(out); err != nil {
+			zw.setError(err)
+		}
+	}
+}
+
+func (zw *Writer) error() error {
+	zw.mu.Lock()
+	defer zw.mu.Unlock()
+	return zw.err
+}
+
+func (zw *Writer) setError(err error) {
+	zw.mu.Lock()
+	defer zw.mu.Unlock()
+	if zw.err == nil {
+		zw.err = err
+	}
+}
@@ -0,0 +1,103 @@
+package pgzip_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/nitrous-io/rise-cli-go/pkg/pgzip"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "pgzip")
+}
+
+// Returns n bytes that compress somewhat, like text
+func testData(n int) []byte {
+	r := rand.New(rand.NewSource(int64(n)))
+	words := []string{"storm ", "publish ", "bundle ", "<div>", "</div>\n", "index.html ", "function() { ", "} "}
+
+	var buf bytes.Buffer
+	for buf.Len() < n {
+		buf.WriteString(words[r.Intn(len(words))])
+	}
+	return buf.Bytes()[:n]
+}
+
+func compress(data []byte, blockSize, workers int, writeSize int) []byte {
+	var buf bytes.Buffer
+	zw, err := pgzip.NewWriterLevel(&buf, flate.DefaultCompression, blockSize, workers)
+	Expect(err).To(BeNil())
+
+	for len(data) > 0 {
+		n := writeSize
+		if n > len(data) {
+			n = len(data)
+		}
+		_, err := zw.Write(data[:n])
+		Expect(err).To(BeNil())
+		data = data[n:]
+	}
+	Expect(zw.Close()).To(Succeed())
+
+	return buf.Bytes()
+}
+
+func decompress(b []byte) []byte {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	Expect(err).To(BeNil())
+
+	// The output must be a single gzip member.
+	zr.Multistream(false)
+
+	out, err := ioutil.ReadAll(zr)
+	Expect(err).To(BeNil())
+	return out
+}
+
+var _ = Describe("Writer", func() {
+	for _, n := range []int{0, 1, 1000, 64 << 10, 100<<10 + 7, 1 << 20} {
+		n := n
+
+		It("round-trips data that is written in pieces", func() {
+			data := testData(n)
+
+			out := decompress(compress(data, 16<<10, 4, 4000))
+			Expect(out).To(HaveLen(len(data)))
+			Expect(bytes.Equal(out, data)).To(BeTrue())
+		})
+	}
+
+	It("produces the same output regardless of the number of workers and writes", func() {
+		data := testData(300 << 10)
+
+		expected := compress(data, 32<<10, 1, len(data))
+		Expect(compress(data, 32<<10, 8, 1000)).To(Equal(expected))
+		Expect(compress(data, 32<<10, 3, 77777)).To(Equal(expected))
+	})
+
+	It("compresses as well as compress/gzip with large enough blocks", func() {
+		data := testData(1 << 20)
+
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		gw.Write(data)
+		gw.Close()
+
+		Expect(len(compress(data, pgzip.DefaultBlockSize, 4, len(data)))).To(BeNumerically("<", buf.Len()*11/10))
+	})
+
+	It("refuses writes after it has been closed", func() {
+		zw := pgzip.NewWriter(ioutil.Discard)
+		Expect(zw.Close()).To(Succeed())
+
+		_, err := zw.Write([]byte("foo"))
+		Expect(err).To(Equal(pgzip.ErrClosed))
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/nitrous-io/rise-cli-go/pkg/term"
@@ -13,14 +14,51 @@ import (
 var (
 	Input  io.Reader = os.Stdin
 	Output io.Writer = os.Stdout
+
+	// If false, Read and ReadSecurely never prompt. They return the default
+	// value if there is one, or a NonInteractiveError otherwise.
+	Interactive = true
+
+	ansiEscapeRe    = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	promptDefaultRe = regexp.MustCompile(`\s*\[[^\]]*\]\s*$`)
 )
 
+// NonInteractiveError is returned when a value has to be prompted for while
+// Interactive is false.
+type NonInteractiveError struct {
+	Name string
+}
+
+func (e *NonInteractiveError) Error() string {
+	return fmt.Sprintf("%q is required, but cannot be prompted for in non-interactive mode", e.Name)
+}
+
+// Derives the name of the value being asked for from a prompt such as
+// "Enter Email: " or "Enter branch [master]"
+func promptName(prompt string) string {
+	name := ansiEscapeRe.ReplaceAllString(prompt, "")
+	name = strings.TrimRight(name, " :?")
+	name = promptDefaultRe.ReplaceAllString(name, "")
+	return strings.TrimRight(name, " :?")
+}
+
+func nonInteractiveRead(prompt string, retry bool, def string) (string, error) {
+	if def != "" || !retry {
+		return def, nil
+	}
+	return "", &NonInteractiveError{Name: promptName(prompt)}
+}
+
 func Read(prompt string, retry bool, def string) (string, error) {
 	var (
 		s   string
 		err error
 	)
 
+	if !Interactive {
+		return nonInteractiveRead(prompt, retry, def)
+	}
+
 	in := bufio.NewReader(Input)
 
 	for {
@@ -51,6 +89,10 @@ func ReadSecurely(prompt string, retry bool, def string) (string, error) {
 		err error
 	)
 
+	if !Interactive {
+		return nonInteractiveRead(prompt, retry, def)
+	}
+
 	in := bufio.NewReader(Input)
 
 	for {
@@ -83,4 +83,39 @@ var _ = Describe("Readline", func() {
 		Entry("string followed by DEL char", []byte{'a', 'b', 127 /* DEL */, 'c', '\n'}, false, "", "ac", nil),
 		Entry("non printable chars", []byte{'a', 'b', 128, 'c', 130, 'd', 31, 'e', '\n'}, false, "", "abcde", nil),
 	)
+
+	Describe("non-interactive mode", func() {
+		BeforeEach(func() {
+			readline.Interactive = false
+		})
+
+		AfterEach(func() {
+			readline.Interactive = true
+		})
+
+		DescribeTable("Read and ReadSecurely",
+			func(prompt string, retry bool, def, expected, errName string) {
+				writeToInput([]byte("Hello world\n"))
+
+				for _, read := range []func(string, bool, string) (string, error){readline.Read, readline.ReadSecurely} {
+					result, err := read(prompt, retry, def)
+					if errName != "" {
+						Expect(err).To(Equal(&readline.NonInteractiveError{Name: errName}))
+					} else {
+						Expect(err).To(BeNil())
+					}
+
+					Expect(result).To(Equal(expected))
+				}
+
+				Expect(input.Len()).To(Equal(12))
+				Expect(output.Len()).To(BeZero())
+			},
+			Entry("with default", "Enter: ", true, "Hello", "Hello", ""),
+			Entry("without default or retry", "Enter: ", false, "", "", ""),
+			Entry("without default with retry", "Enter Email: ", true, "", "", "Enter Email"),
+			Entry("colored prompt", "\x1b[1mEnter Domain Name\x1b[0m: ", true, "", "", "Enter Domain Name"),
+			Entry("prompt with default value", "Enter Branch [master]: ", true, "", "", "Enter Branch"),
+		)
+	})
 })
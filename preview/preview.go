@@ -0,0 +1,361 @@
+// Package preview serves a project locally the way PubStorm serves it once it
+// is published.
+package preview
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+)
+
+const (
+	// Path of the script that defines the project's JS environment variables,
+	// as generated by PubStorm when a project is deployed
+	JSEnvVarsPath = "/jsenv.js"
+	// Name of the global object holding the JS environment variables
+	JSEnvVarsGlobal = "RISE_ENV"
+
+	liveReloadPath   = "/__storm/livereload"
+	liveReloadJSPath = "/__storm/livereload.js"
+
+	basicAuthRealm = "Restricted"
+)
+
+const liveReloadJS = `(function() {
+  if (!window.EventSource) { return; }
+  var source = new EventSource("` + liveReloadPath + `");
+  source.addEventListener("reload", function() { window.location.reload(); });
+})();
+`
+
+var bodyCloseTagRe = regexp.MustCompile(`(?i)</body\s*>`)
+
+// Server serves the files of a project that would be included in its bundle.
+type Server struct {
+	// Root is the project path
+	Root string
+	// IgnoreList holds the patterns of files to exclude, as with
+	// bundle.Assemble
+	IgnoreList []*pathmatch.Pattern
+
+	// JSEnvVars are served at JSEnvVarsPath. No script is served if nil.
+	JSEnvVars map[string]string
+
+	// Requests must be authenticated with these credentials, if set
+	BasicAuthUsername string
+	BasicAuthPassword string
+
+	// ForceHTTPS redirects plain HTTP requests to HTTPSPort
+	ForceHTTPS bool
+	HTTPSPort  int
+
+	// LiveReload injects a script into HTML pages that reloads them when
+	// Reload is called
+	LiveReload bool
+
+	mu          sync.RWMutex
+	files       map[string]bool
+	fingerprint string
+	clients     map[chan struct{}]bool
+}
+
+// Returns a server for the project at root
+func New(root string, ignoreList []*pathmatch.Pattern) *Server {
+	return &Server{
+		Root:       root,
+		IgnoreList: ignoreList,
+		files:      map[string]bool{},
+		clients:    map[chan struct{}]bool{},
+	}
+}
+
+// Assembles the set of files to serve. Returns whether any file was added,
+// removed or modified since the last scan.
+func (s *Server) Scan() (changed bool, err error) {
+	bun := bundle.New(s.Root)
+	if _, _, err := bun.Assemble(s.IgnoreList, false); err != nil {
+		return false, err
+	}
+
+	fileList := bun.FileList()
+	sort.Strings(fileList)
+
+	files := make(map[string]bool, len(fileList))
+	h := sha1.New()
+	for _, p := range fileList {
+		files[filepath.ToSlash(p)] = true
+
+		fi, err := os.Stat(filepath.Join(s.Root, p))
+		if err != nil {
+			// The file was removed after it was assembled. The next scan picks
+			// it up.
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", p, fi.Size(), fi.ModTime().UnixNano())
+	}
+	fingerprint := hex.EncodeToString(h.Sum(nil))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed = s.fingerprint != "" && fingerprint != s.fingerprint
+	s.files = files
+	s.fingerprint = fingerprint
+
+	return changed, nil
+}
+
+// Rescans the project every interval until stop is closed, and reloads the
+// browsers that are showing it whenever a file changes. onChange, if not nil,
+// is called after every change, and with the error of a failed scan unless
+// the previous scan failed the same way.
+func (s *Server) Watch(interval time.Duration, stop <-chan struct{}, onChange func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := s.Scan()
+			if err != nil {
+				if onChange != nil && (lastErr == nil || lastErr.Error() != err.Error()) {
+					onChange(err)
+				}
+				lastErr = err
+				continue
+			}
+			lastErr = nil
+
+			if changed {
+				s.Reload()
+				if onChange != nil {
+					onChange(nil)
+				}
+			}
+		}
+	}
+}
+
+// Tells every browser showing the project to reload the page
+func (s *Server) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.ForceHTTPS && r.TLS == nil {
+		s.redirectToHTTPS(w, r)
+		return
+	}
+
+	if s.BasicAuthUsername != "" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", basicAuthRealm))
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case liveReloadPath:
+		if s.LiveReload {
+			s.serveLiveReloadEvents(w, r)
+			return
+		}
+	case liveReloadJSPath:
+		if s.LiveReload {
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte(liveReloadJS))
+			return
+		}
+	case JSEnvVarsPath:
+		if s.JSEnvVars != nil {
+			s.serveJSEnvVars(w)
+			return
+		}
+	}
+
+	s.serveFile(w, r)
+}
+
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.HTTPSPort != 0 && s.HTTPSPort != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(s.HTTPSPort))
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(s.BasicAuthUsername)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.BasicAuthPassword)) == 1
+	return usernameOK && passwordOK
+}
+
+func (s *Server) serveJSEnvVars(w http.ResponseWriter) {
+	b, err := json.Marshal(s.JSEnvVars)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, "window.%s = %s;\n", JSEnvVarsGlobal, b)
+}
+
+func (s *Server) serveLiveReloadEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	notifier, ok2 := w.(http.CloseNotifier)
+	if !ok || !ok2 {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	gone := notifier.CloseNotify()
+
+	c := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case <-c:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Resolves the request path to a file in the project: "/" and paths ending in
+// a slash are served by the index.html file in that directory, and a
+// directory without a trailing slash is redirected to one with it. Requests
+// for files that are not part of the bundle are served by 404.html.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if strings.HasSuffix(r.URL.Path, "/") && p != "" {
+		p += "/"
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := http.StatusOK
+	switch {
+	case p == "" || strings.HasSuffix(p, "/"):
+		p += "index.html"
+	case !s.files[p] && s.files[p+"/index.html"]:
+		http.Redirect(w, r, "/"+p+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	if !s.files[p] {
+		if !s.files["404.html"] {
+			http.NotFound(w, r)
+			return
+		}
+		p = "404.html"
+		status = http.StatusNotFound
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(s.Root, filepath.FromSlash(p)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(p))
+	if contentType == "" {
+		contentType = http.DetectContentType(b)
+	}
+
+	if s.LiveReload && strings.HasPrefix(contentType, "text/html") {
+		b = injectLiveReload(b)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+	if r.Method != "HEAD" {
+		w.Write(b)
+	}
+}
+
+// Inserts the live reload script before the closing body tag, or at the end
+// of the page if it has none
+func injectLiveReload(page []byte) []byte {
+	script := []byte(`<script src="` + liveReloadJSPath + `"></script>`)
+
+	locs := bodyCloseTagRe.FindAllIndex(page, -1)
+	if len(locs) == 0 {
+		return append(page, script...)
+	}
+
+	i := locs[len(locs)-1][0]
+	var buf bytes.Buffer
+	buf.Grow(len(page) + len(script))
+	buf.Write(page[:i])
+	buf.Write(script)
+	buf.Write(page[i:])
+	return buf.Bytes()
+}
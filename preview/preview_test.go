@@ -0,0 +1,206 @@
+package preview_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+	"github.com/nitrous-io/rise-cli-go/preview"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "preview")
+}
+
+var _ = Describe("Server", func() {
+	var (
+		tempDir string
+		srv     *preview.Server
+		err     error
+	)
+
+	writeFile := func(path, content string) {
+		p := filepath.Join(tempDir, path)
+		Expect(os.MkdirAll(filepath.Dir(p), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(p, []byte(content), 0600)).To(Succeed())
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		Expect(err).To(BeNil())
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "preview")
+		Expect(err).To(BeNil())
+
+		writeFile("index.html", "<html><body>Home</body></html>")
+		writeFile("about/index.html", "<html><body>About</body></html>")
+		writeFile("app.js", "console.log('hi');")
+		writeFile("secret.txt", "s3cr3t")
+		writeFile(".stormignore", "secret.txt\n")
+
+		srv = preview.New(tempDir, pathmatch.CompileAll("", "pubstorm.json"))
+		_, err = srv.Scan()
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("serves index.html for directories", func() {
+		w := get("/")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+		Expect(w.Body.String()).To(Equal("<html><body>Home</body></html>"))
+
+		w = get("/about/")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring("About"))
+	})
+
+	It("redirects directories without a trailing slash", func() {
+		w := get("/about")
+		Expect(w.Code).To(Equal(http.StatusMovedPermanently))
+		Expect(w.Header().Get("Location")).To(Equal("/about/"))
+	})
+
+	It("serves files with their content type", func() {
+		w := get("/app.js")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(ContainSubstring("javascript"))
+		Expect(w.Body.String()).To(Equal("console.log('hi');"))
+	})
+
+	It("does not serve files that are excluded from the bundle", func() {
+		Expect(get("/secret.txt").Code).To(Equal(http.StatusNotFound))
+		Expect(get("/.stormignore").Code).To(Equal(http.StatusNotFound))
+		Expect(get("/../secret.txt").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("serves 404.html for missing files, if there is one", func() {
+		writeFile("404.html", "Not here")
+		_, err := srv.Scan()
+		Expect(err).To(BeNil())
+
+		w := get("/nope")
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+		Expect(w.Body.String()).To(Equal("Not here"))
+	})
+
+	It("serves the JS environment variables", func() {
+		srv.JSEnvVars = map[string]string{"API_URL": "https://api.example.com"}
+
+		w := get(preview.JSEnvVarsPath)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal(`window.RISE_ENV = {"API_URL":"https://api.example.com"};` + "\n"))
+	})
+
+	It("requires basic authentication if the project is protected", func() {
+		srv.BasicAuthUsername = "foo"
+		srv.BasicAuthPassword = "bar"
+
+		w := get("/")
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).To(HavePrefix("Basic"))
+
+		req, err := http.NewRequest("GET", "/", nil)
+		Expect(err).To(BeNil())
+		req.SetBasicAuth("foo", "wrong")
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+
+		req.SetBasicAuth("foo", "bar")
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	It("redirects to HTTPS if the project forces HTTPS", func() {
+		srv.ForceHTTPS = true
+		srv.HTTPSPort = 8443
+
+		w := get("http://localhost:8080/about/?a=b")
+		Expect(w.Code).To(Equal(http.StatusMovedPermanently))
+		Expect(w.Header().Get("Location")).To(Equal("https://localhost:8443/about/?a=b"))
+
+		req, err := http.NewRequest("GET", "https://localhost:8443/", nil)
+		Expect(err).To(BeNil())
+		req.TLS = &tls.ConnectionState{}
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	Describe("live reload", func() {
+		BeforeEach(func() {
+			srv.LiveReload = true
+		})
+
+		It("injects the live reload script into HTML pages", func() {
+			Expect(get("/").Body.String()).To(Equal(`<html><body>Home<script src="/__storm/livereload.js"></script></body></html>`))
+			Expect(get("/app.js").Body.String()).To(Equal("console.log('hi');"))
+			Expect(get("/__storm/livereload.js").Code).To(Equal(http.StatusOK))
+		})
+
+		It("detects changed files", func() {
+			changed, err := srv.Scan()
+			Expect(err).To(BeNil())
+			Expect(changed).To(BeFalse())
+
+			writeFile("new.html", "New")
+			changed, err = srv.Scan()
+			Expect(err).To(BeNil())
+			Expect(changed).To(BeTrue())
+			Expect(get("/new.html").Code).To(Equal(http.StatusOK))
+
+			// Files excluded from the bundle are not watched.
+			writeFile("secret.txt", "changed")
+			changed, err = srv.Scan()
+			Expect(err).To(BeNil())
+			Expect(changed).To(BeFalse())
+		})
+
+		It("tells browsers to reload", func() {
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			res, err := http.Get(ts.URL + "/__storm/livereload")
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+			Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+			lines := make(chan string)
+			go func() {
+				defer GinkgoRecover()
+				r := bufio.NewReader(res.Body)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					lines <- line
+				}
+			}()
+
+			// The client is registered once the response headers are sent.
+			srv.Reload()
+			Eventually(lines, time.Second).Should(Receive(Equal("event: reload\n")))
+		})
+	})
+})
@@ -20,10 +20,14 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 	n, err = w.Writer.Write(p)
 	w.bytesWritten += int64(n)
 
-	if err == nil {
+	if err == nil && w.totalBytes > 0 {
 		draw(w.output, float64(w.bytesWritten), float64(w.totalBytes))
-	} else if err == io.EOF {
-		fmt.Fprintln(w.output)
+
+		// Unlike readers, writers are never told that they have reached the
+		// end, so finish the bar once everything has been written.
+		if n > 0 && w.bytesWritten == w.totalBytes {
+			fmt.Fprintln(w.output)
+		}
 	}
 
 	return n, err
@@ -19,13 +19,22 @@ type Project struct {
 	DefaultDomainEnabled bool `json:"default_domain_enabled"`
 	ForceHTTPS           bool `json:"force_https"`
 	SkipBuild            bool `json:"skip_build"`
+
+	// BasicAuthUsername is set if the project is protected by HTTP basic
+	// authentication. The password is never returned by the server.
+	BasicAuthUsername string `json:"basic_auth_username,omitempty"`
+
+	// Profile is the name of the config profile the project belongs to, if it
+	// is pinned to one. It is only stored locally.
+	Profile string `json:"-"`
 }
 
 // projConfig is used to marshal and unmarshal data that's written to the local
 // project configuration file.
 type projConfig struct {
-	Name string `json:"name"`
-	Path string `json:"path"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Profile string `json:"profile,omitempty"`
 }
 
 var (
@@ -86,8 +95,9 @@ func (p *Project) Save() error {
 	defer f.Close()
 
 	return json.NewEncoder(f).Encode(projConfig{
-		Name: p.Name,
-		Path: p.Path,
+		Name:    p.Name,
+		Path:    p.Path,
+		Profile: p.Profile,
 	})
 }
 
@@ -104,8 +114,9 @@ func Load() (*Project, error) {
 	}
 
 	return &Project{
-		Name: pcfg.Name,
-		Path: pcfg.Path,
+		Name:    pcfg.Name,
+		Path:    pcfg.Path,
+		Profile: pcfg.Profile,
 	}, nil
 }
 
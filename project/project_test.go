@@ -150,6 +150,21 @@ var _ = Describe("Project", func() {
 					"path": "./build",
 				}))
 			})
+
+			It("persists the pinned profile if there is one", func() {
+				proj := &project.Project{
+					Name:    "foo-bar-express",
+					Path:    "./build",
+					Profile: "staging",
+				}
+
+				err = proj.Save()
+				Expect(err).To(BeNil())
+
+				proj, err = project.Load()
+				Expect(err).To(BeNil())
+				Expect(proj.Profile).To(Equal("staging"))
+			})
 		})
 
 		Describe("Load()", func() {
@@ -8,25 +8,37 @@ import (
 	"github.com/codegangsta/cli"
 	"github.com/franela/goreq"
 
+	"github.com/nitrous-io/rise-cli-go/cli/account"
+	"github.com/nitrous-io/rise-cli-go/cli/check"
 	"github.com/nitrous-io/rise-cli-go/cli/collab"
 	"github.com/nitrous-io/rise-cli-go/cli/common"
 	"github.com/nitrous-io/rise-cli-go/cli/configuration"
 	"github.com/nitrous-io/rise-cli-go/cli/deploy"
+	"github.com/nitrous-io/rise-cli-go/cli/deployments"
+	"github.com/nitrous-io/rise-cli-go/cli/diff"
 	"github.com/nitrous-io/rise-cli-go/cli/domains"
 	"github.com/nitrous-io/rise-cli-go/cli/env"
+	"github.com/nitrous-io/rise-cli-go/cli/fixfilenames"
 	"github.com/nitrous-io/rise-cli-go/cli/initcmd"
 	"github.com/nitrous-io/rise-cli-go/cli/login"
 	"github.com/nitrous-io/rise-cli-go/cli/logout"
 	"github.com/nitrous-io/rise-cli-go/cli/password"
+	"github.com/nitrous-io/rise-cli-go/cli/profile"
 	"github.com/nitrous-io/rise-cli-go/cli/projects"
 	"github.com/nitrous-io/rise-cli-go/cli/protect"
+	"github.com/nitrous-io/rise-cli-go/cli/pull"
 	"github.com/nitrous-io/rise-cli-go/cli/repo"
 	"github.com/nitrous-io/rise-cli-go/cli/rollback"
+	"github.com/nitrous-io/rise-cli-go/cli/serve"
 	"github.com/nitrous-io/rise-cli-go/cli/signup"
 	"github.com/nitrous-io/rise-cli-go/cli/ssl"
+	"github.com/nitrous-io/rise-cli-go/cli/tokens"
+	"github.com/nitrous-io/rise-cli-go/cli/twofactor"
 	"github.com/nitrous-io/rise-cli-go/cli/versions"
+	"github.com/nitrous-io/rise-cli-go/client/api"
 	"github.com/nitrous-io/rise-cli-go/config"
 	"github.com/nitrous-io/rise-cli-go/pkg/readline"
+	"github.com/nitrous-io/rise-cli-go/project"
 	"github.com/nitrous-io/rise-cli-go/tr"
 	"github.com/nitrous-io/rise-cli-go/tui"
 
@@ -64,8 +76,6 @@ func main() {
 	log.SetLevel(log.InfoLevel)
 	readline.Output = tui.Out
 
-	common.CheckForUpdates()
-
 	// Set Goreq's connect timeout to 10s globally (its default is 1s which
 	// can be too short).
 	goreq.SetConnectTimeout(10 * time.Second)
@@ -74,6 +84,89 @@ func main() {
 	app.Version = config.Version
 	app.Usage = tr.T("rise_cli_desc")
 
+	app.Flags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "non-interactive",
+			Usage: tr.T("non_interactive_desc"),
+		},
+		cli.StringFlag{
+			Name:   "profile, p",
+			Usage:  tr.T("profile_flag_desc"),
+			EnvVar: "PUBSTORM_PROFILE",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Value: common.OutputText,
+			Usage: tr.T("output_desc"),
+		},
+		cli.BoolFlag{
+			Name:   "debug-http",
+			Usage:  tr.T("debug_http_desc"),
+			EnvVar: "PUBSTORM_DEBUG_HTTP",
+		},
+		cli.DurationFlag{
+			Name:  "http-timeout",
+			Value: api.DefaultTimeout,
+			Usage: tr.T("http_timeout_desc"),
+		},
+	}
+
+	app.Before = func(c *cli.Context) error {
+		if c.Bool("non-interactive") || os.Getenv("CI") == "true" {
+			readline.Interactive = false
+		}
+
+		switch format := c.String("output"); format {
+		case common.OutputText:
+		case common.OutputJSON:
+			common.OutputFormat = common.OutputJSON
+			tui.UseStderr()
+			log.SetOutput(tui.Out)
+			readline.Output = tui.Out
+		default:
+			log.Fatalf(tr.T("invalid_output_format"), format)
+		}
+
+		// A profile given on the command line takes precedence over the one the
+		// project in the current directory is pinned to.
+		profileName := c.String("profile")
+		if profileName == "" {
+			if proj, err := project.Load(); err == nil {
+				profileName = proj.Profile
+			}
+		}
+		if profileName != "" {
+			if err := config.UseProfile(profileName); err != nil {
+				log.Fatalf(tr.T("profile_not_found"), profileName)
+			}
+		}
+
+		config.PassphrasePrompt = common.ReadPassphrase
+		if err := config.MigrateCredentials(); err != nil {
+			log.Warnf(tr.T("credentials_migration_failed"), config.Credentials.Name(), err)
+		}
+
+		api.DefaultTimeout = c.Duration("http-timeout")
+		api.MaxRetries = 3
+		if c.Bool("debug-http") {
+			api.EnableDebug(common.DebugLog())
+		}
+
+		common.CheckForUpdates()
+		return nil
+	}
+
+	profileAddFlags := []cli.Flag{
+		cli.StringFlag{
+			Name:  "host",
+			Usage: tr.T("profile_host_desc"),
+		},
+		cli.StringFlag{
+			Name:  "default-domain",
+			Usage: tr.T("profile_domain_desc"),
+		},
+	}
+
 	app.Commands = []cli.Command{
 		{
 			Name:   "signup",
@@ -84,6 +177,16 @@ func main() {
 			Name:   "login",
 			Usage:  tr.T("login_desc"),
 			Action: login.Login,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "web",
+					Usage: tr.T("login_web_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "device",
+					Usage: tr.T("login_device_desc"),
+				},
+			},
 		},
 		{
 			Name:   "logout",
@@ -127,6 +230,96 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "account",
+			Usage:  tr.T("account_desc"),
+			Action: account.Show,
+			Subcommands: []cli.Command{
+				{
+					Name:   "show",
+					Usage:  tr.T("account_desc"),
+					Action: account.Show,
+				},
+				{
+					Name:   "update",
+					Usage:  tr.T("account_update_desc"),
+					Action: account.Update,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "name",
+							Usage: tr.T("account_update_name"),
+						},
+						cli.StringFlag{
+							Name:  "organization",
+							Usage: tr.T("account_update_org"),
+						},
+					},
+				},
+				{
+					Name:      "email",
+					Usage:     tr.T("account_email_desc"),
+					Action:    account.Email,
+					ArgsUsage: tr.T("account_email_args"),
+				},
+				{
+					Name:   "delete",
+					Usage:  tr.T("account_delete_desc"),
+					Action: account.Delete,
+				},
+			},
+		},
+		{
+			Name:   "account.update",
+			Usage:  tr.T("account_update_desc"),
+			Action: account.Update,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "name",
+					Usage: tr.T("account_update_name"),
+				},
+				cli.StringFlag{
+					Name:  "organization",
+					Usage: tr.T("account_update_org"),
+				},
+			},
+		},
+		{
+			Name:      "account.email",
+			Usage:     tr.T("account_email_desc"),
+			Action:    account.Email,
+			ArgsUsage: tr.T("account_email_args"),
+		},
+		{
+			Name:   "account.delete",
+			Usage:  tr.T("account_delete_desc"),
+			Action: account.Delete,
+		},
+		{
+			Name:  "2fa",
+			Usage: tr.T("2fa_desc"),
+			Subcommands: []cli.Command{
+				{
+					Name:   "enable",
+					Usage:  tr.T("2fa_enable_desc"),
+					Action: twofactor.Enable,
+				},
+				{
+					Name:   "disable",
+					Usage:  tr.T("2fa_disable_desc"),
+					Action: twofactor.Disable,
+				},
+			},
+		},
+		{
+			Name:   "2fa.enable",
+			Usage:  tr.T("2fa_enable_desc"),
+			Action: twofactor.Enable,
+		},
+		{
+			Name:   "2fa.disable",
+			Usage:  tr.T("2fa_disable_desc"),
+			Action: twofactor.Disable,
+		},
 		{
 			Name:   "init",
 			Usage:  tr.T("init_desc"),
@@ -138,15 +331,188 @@ func main() {
 			Action: configuration.Update,
 		},
 		{
-			Name:    "publish",
-			Aliases: []string{"deploy"},
-			Usage:   tr.T("publish_desc"),
-			Action:  deploy.Deploy,
+			Name:        "publish",
+			Aliases:     []string{"deploy"},
+			Usage:       tr.T("publish_desc"),
+			Description: tr.T("publish_exit_codes"),
+			Action:      deploy.Deploy,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:  "verbose, v",
 					Usage: tr.T("publish_verbose"),
 				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: tr.T("publish_dry_run"),
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: tr.T("publish_json"),
+				},
+				cli.BoolFlag{
+					Name:  "logs",
+					Usage: tr.T("publish_logs"),
+				},
+				cli.BoolFlag{
+					Name:  "no-wait",
+					Usage: tr.T("publish_no_wait"),
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: tr.T("publish_timeout"),
+				},
+				cli.BoolFlag{
+					Name:  "no-reproducible",
+					Usage: tr.T("publish_no_reproducible"),
+				},
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: tr.T("publish_check"),
+				},
+				cli.BoolFlag{
+					Name:  "allow-dropped",
+					Usage: tr.T("publish_allow_dropped"),
+				},
+			},
+		},
+		{
+			Name:   "deployments",
+			Usage:  tr.T("deployments_desc"),
+			Action: deployments.List,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "state",
+					Usage: tr.T("deployments_state"),
+				},
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "show",
+					Usage:     tr.T("deployments_show_desc"),
+					Action:    deployments.Show,
+					ArgsUsage: tr.T("deployments_show_args"),
+				},
+				{
+					Name:      "watch",
+					Usage:     tr.T("deployments_watch_desc"),
+					Action:    deployments.Watch,
+					ArgsUsage: tr.T("deployments_watch_args"),
+					Flags: []cli.Flag{
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: tr.T("deployments_timeout"),
+						},
+					},
+				},
+				{
+					Name:      "logs",
+					Usage:     tr.T("deployments_logs_desc"),
+					Action:    deployments.Logs,
+					ArgsUsage: tr.T("deployments_logs_args"),
+					Flags: []cli.Flag{
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: tr.T("deployments_timeout"),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:      "diff",
+			Usage:     tr.T("diff_desc"),
+			ArgsUsage: tr.T("diff_args"),
+			Action:    diff.Diff,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "stat",
+					Usage: tr.T("diff_stat_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: tr.T("diff_json_desc"),
+				},
+			},
+		},
+		{
+			Name:      "pull",
+			Usage:     tr.T("pull_desc"),
+			ArgsUsage: tr.T("pull_args"),
+			Action:    pull.Pull,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "optimized",
+					Usage: tr.T("pull_optimized_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: tr.T("pull_force_desc"),
+				},
+			},
+		},
+		{
+			Name:   "check",
+			Usage:  tr.T("check_desc"),
+			Action: check.Check,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: tr.T("check_offline_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: tr.T("check_json_desc"),
+				},
+			},
+		},
+		{
+			Name:   "fix-filenames",
+			Usage:  tr.T("fix_filenames_desc"),
+			Action: fixfilenames.FixFilenames,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: tr.T("fix_filenames_dry_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: tr.T("fix_filenames_yes_desc"),
+				},
+			},
+		},
+		{
+			Name:   "serve",
+			Usage:  tr.T("serve_desc"),
+			Action: serve.Serve,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "port",
+					Value: 8080,
+					Usage: tr.T("serve_port_desc"),
+				},
+				cli.IntFlag{
+					Name:  "https-port",
+					Value: 8443,
+					Usage: tr.T("serve_https_port_desc"),
+				},
+				cli.StringFlag{
+					Name:  "host",
+					Value: "localhost",
+					Usage: tr.T("serve_host_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "no-reload",
+					Usage: tr.T("serve_no_reload_desc"),
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: tr.T("serve_offline_desc"),
+				},
+				cli.StringFlag{
+					Name:   "password",
+					Usage:  tr.T("serve_password_desc"),
+					EnvVar: "PUBSTORM_SERVE_PASSWORD",
+				},
 			},
 		},
 		{
@@ -168,6 +534,36 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:      "deployments.show",
+			Usage:     tr.T("deployments_show_desc"),
+			Action:    deployments.Show,
+			ArgsUsage: tr.T("deployments_show_args"),
+		},
+		{
+			Name:      "deployments.watch",
+			Usage:     tr.T("deployments_watch_desc"),
+			Action:    deployments.Watch,
+			ArgsUsage: tr.T("deployments_watch_args"),
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: tr.T("deployments_timeout"),
+				},
+			},
+		},
+		{
+			Name:      "deployments.logs",
+			Usage:     tr.T("deployments_logs_desc"),
+			Action:    deployments.Logs,
+			ArgsUsage: tr.T("deployments_logs_args"),
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: tr.T("deployments_timeout"),
+				},
+			},
+		},
 		{
 			Name:      "domains.add",
 			Usage:     tr.T("domains_add_desc"),
@@ -241,6 +637,16 @@ func main() {
 			Usage:     tr.T("rollback_desc"),
 			ArgsUsage: tr.T("rollback_args"),
 			Action:    rollback.Rollback,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: tr.T("rollback_yes"),
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: tr.T("rollback_dry_run_desc"),
+				},
+			},
 		},
 		{
 			Name:   "versions",
@@ -395,6 +801,102 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:      "profile.add",
+			Usage:     tr.T("profile_add_desc"),
+			Action:    profile.Add,
+			ArgsUsage: tr.T("profile_args"),
+			Flags:     profileAddFlags,
+		},
+		{
+			Name:      "profile.use",
+			Usage:     tr.T("profile_use_desc"),
+			Action:    profile.Use,
+			ArgsUsage: tr.T("profile_args"),
+		},
+		{
+			Name:   "profile.list",
+			Usage:  tr.T("profile_desc"),
+			Action: profile.List,
+		},
+		{
+			Name:      "profile.rm",
+			Usage:     tr.T("profile_rm_desc"),
+			Action:    profile.Remove,
+			ArgsUsage: tr.T("profile_args"),
+		},
+		{
+			Name:   "profile",
+			Usage:  tr.T("profile_desc"),
+			Action: profile.List,
+			Subcommands: []cli.Command{
+				{
+					Name:      "add",
+					Usage:     tr.T("profile_add_desc"),
+					Action:    profile.Add,
+					ArgsUsage: tr.T("profile_args"),
+					Flags:     profileAddFlags,
+				},
+				{
+					Name:      "use",
+					Usage:     tr.T("profile_use_desc"),
+					Action:    profile.Use,
+					ArgsUsage: tr.T("profile_args"),
+				},
+				{
+					Name:   "list",
+					Usage:  tr.T("profile_desc"),
+					Action: profile.List,
+				},
+				{
+					Name:      "rm",
+					Usage:     tr.T("profile_rm_desc"),
+					Action:    profile.Remove,
+					ArgsUsage: tr.T("profile_args"),
+				},
+			},
+		},
+		{
+			Name:      "token.create",
+			Usage:     tr.T("token_create_desc"),
+			Action:    tokens.Create,
+			ArgsUsage: tr.T("token_create_args"),
+		},
+		{
+			Name:   "token.list",
+			Usage:  tr.T("token_desc"),
+			Action: tokens.List,
+		},
+		{
+			Name:      "token.revoke",
+			Usage:     tr.T("token_revoke_desc"),
+			Action:    tokens.Revoke,
+			ArgsUsage: tr.T("token_revoke_args"),
+		},
+		{
+			Name:   "token",
+			Usage:  tr.T("token_desc"),
+			Action: tokens.List,
+			Subcommands: []cli.Command{
+				{
+					Name:      "create",
+					Usage:     tr.T("token_create_desc"),
+					Action:    tokens.Create,
+					ArgsUsage: tr.T("token_create_args"),
+				},
+				{
+					Name:   "list",
+					Usage:  tr.T("token_desc"),
+					Action: tokens.List,
+				},
+				{
+					Name:      "revoke",
+					Usage:     tr.T("token_revoke_desc"),
+					Action:    tokens.Revoke,
+					ArgsUsage: tr.T("token_revoke_args"),
+				},
+			},
+		},
 	}
 
 	app.Run(os.Args)
@@ -0,0 +1,66 @@
+package sitecheck
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Rewrites references to renamed files and directories in the content of an
+// HTML, CSS or JavaScript file. renames maps old names to new ones, each being
+// a single path component. A name is only replaced where it is a whole
+// component of a quoted string, attribute value or url(), written as is,
+// HTML-escaped or URL-encoded. Returns the new content and the number of
+// references that were changed.
+func RewriteReferences(content []byte, renames map[string]string) ([]byte, int) {
+	count := 0
+	for oldName, newName := range renames {
+		for _, form := range encodings(oldName) {
+			re := regexp.MustCompile("([\"'`(=/])" + regexp.QuoteMeta(form) + "([\"'`)/?#>\\s])")
+			repl := []byte("${1}" + newName + "${2}")
+
+			// Matches can't overlap, so "/a b/a b/" takes more than one pass.
+			for {
+				n := len(re.FindAllIndex(content, -1))
+				if n == 0 {
+					break
+				}
+				content = re.ReplaceAll(content, repl)
+				count += n
+			}
+		}
+	}
+	return content, count
+}
+
+// Returns the ways name may be written in a reference
+func encodings(name string) []string {
+	forms := []string{
+		name,
+		html.EscapeString(name),
+		(&url.URL{Path: name}).EscapedPath(),
+		strings.Replace(name, " ", "%20", -1),
+	}
+
+	var unique []string
+	seen := map[string]bool{}
+	for _, form := range forms {
+		if !seen[form] {
+			seen[form] = true
+			unique = append(unique, form)
+		}
+	}
+	return unique
+}
+
+// Reports whether the file at path may contain references that
+// RewriteReferences can update
+func Rewritable(path string) bool {
+	for _, ext := range []string{".html", ".htm", ".css", ".js"} {
+		if strings.HasSuffix(strings.ToLower(path), ext) {
+			return true
+		}
+	}
+	return false
+}
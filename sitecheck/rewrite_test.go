@@ -0,0 +1,42 @@
+package sitecheck_test
+
+import (
+	"github.com/nitrous-io/rise-cli-go/sitecheck"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RewriteReferences()", func() {
+	renames := map[string]string{
+		"my photo.jpg": "my-photo.jpg",
+		"wèîrd dir":    "weird-dir",
+	}
+
+	It("rewrites references written as is, HTML-escaped or URL-encoded", func() {
+		content := `<img src="my photo.jpg">
+<img src=img/my%20photo.jpg>
+<a href="/wèîrd dir/wèîrd dir/index.html">
+<a href="w%C3%A8%C3%AEr%20dir/x.html">
+<div style="background: url(my%20photo.jpg)"></div>
+h1 { background: url('../wèîrd dir/my photo.jpg?v=1') }
+var img = "/img/my photo.jpg#top";`
+
+		out, n := sitecheck.RewriteReferences([]byte(content), renames)
+		Expect(n).To(Equal(8))
+		Expect(string(out)).To(Equal(`<img src="my-photo.jpg">
+<img src=img/my-photo.jpg>
+<a href="/weird-dir/weird-dir/index.html">
+<a href="w%C3%A8%C3%AEr%20dir/x.html">
+<div style="background: url(my-photo.jpg)"></div>
+h1 { background: url('../weird-dir/my-photo.jpg?v=1') }
+var img = "/img/my-photo.jpg#top";`))
+	})
+
+	It("leaves names that are only part of a path component alone", func() {
+		content := `<p>See my photo.jpg</p><img src="not my photo.jpg">`
+
+		out, n := sitecheck.RewriteReferences([]byte(content), renames)
+		Expect(n).To(BeZero())
+		Expect(string(out)).To(Equal(content))
+	})
+})
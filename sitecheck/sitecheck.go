@@ -0,0 +1,333 @@
+// Package sitecheck finds references in the HTML and CSS files of a bundle that
+// will not work once the bundle is published.
+package sitecheck
+
+import (
+	"html"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nitrous-io/rise-cli-go/bundle"
+)
+
+// Kinds of problems
+const (
+	// The referenced file is not in the bundle.
+	KindBroken = "broken"
+
+	// The referenced file exists, but is excluded from the bundle.
+	KindIgnored = "ignored"
+
+	// The referenced file is only in the bundle with a different case. This
+	// works on case-insensitive file systems, like macOS's, but not once
+	// published.
+	KindCaseMismatch = "case_mismatch"
+
+	// An asset is loaded over plain HTTP on a page that is forced to HTTPS,
+	// so browsers will block it.
+	KindInsecure = "insecure"
+)
+
+// Problem is a reference that will not work once the bundle is published.
+type Problem struct {
+	Kind string `json:"kind"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Ref  string `json:"ref"`
+
+	// Target is the path the reference resolves to, or the path of the file
+	// it matches with a different case if Kind is KindCaseMismatch.
+	Target string `json:"target,omitempty"`
+
+	// Skipped describes why the target was excluded if Kind is KindIgnored.
+	Skipped *bundle.SkippedFile `json:"skipped,omitempty"`
+}
+
+// A reference found in a file. Assets are loaded by the page, as opposed to
+// links the user may follow.
+type ref struct {
+	value string
+	line  int
+	asset bool
+}
+
+var (
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	scriptRe      = regexp.MustCompile(`(?is)<script\b[^>]*>(.*?)</script>`)
+	styleRe       = regexp.MustCompile(`(?is)<style\b[^>]*>(.*?)</style>`)
+	tagRe         = regexp.MustCompile(`(?s)<([a-zA-Z][a-zA-Z0-9-]*)\b([^>]*)>`)
+	attrRe        = regexp.MustCompile(`(?is)\b(href|src|srcset|poster|style)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+	cssCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssURLRe     = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+	cssImportRe  = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)')`)
+)
+
+type checker struct {
+	files      map[string]bool
+	lowerFiles map[string]string
+	skipped    []*bundle.SkippedFile
+	forceHTTPS bool
+}
+
+// Checks the references in every HTML and CSS file of an assembled bundle,
+// whose files are in the directory root. Problems are sorted by file and line.
+func Check(root string, bun *bundle.Bundle, forceHTTPS bool) ([]*Problem, error) {
+	report := bun.Report(0)
+
+	c := &checker{
+		files:      make(map[string]bool, len(report.Included)),
+		lowerFiles: make(map[string]string, len(report.Included)),
+		skipped:    report.Skipped,
+		forceHTTPS: forceHTTPS,
+	}
+	for _, f := range report.Included {
+		c.files[f.Path] = true
+		c.lowerFiles[strings.ToLower(f.Path)] = f.Path
+	}
+
+	problems := []*Problem{}
+	for _, f := range report.Included {
+		var refs []*ref
+		switch strings.ToLower(path.Ext(f.Path)) {
+		case ".html", ".htm":
+			b, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(f.Path)))
+			if err != nil {
+				return nil, err
+			}
+			refs = htmlRefs(b)
+		case ".css":
+			b, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(f.Path)))
+			if err != nil {
+				return nil, err
+			}
+			refs = cssRefs(b)
+		default:
+			continue
+		}
+
+		for _, r := range refs {
+			if p := c.check(f.Path, r); p != nil {
+				problems = append(problems, p)
+			}
+		}
+	}
+
+	sort.Stable(byLocation(problems))
+	return problems, nil
+}
+
+func (c *checker) check(file string, r *ref) *Problem {
+	v := strings.TrimSpace(r.value)
+
+	// Pure fragments, protocol-relative URLs and templates can't be checked.
+	if v == "" || strings.HasPrefix(v, "#") || strings.HasPrefix(v, "//") ||
+		strings.Contains(v, "{{") || strings.Contains(v, "${") {
+		return nil
+	}
+
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil
+	}
+
+	if u.Scheme != "" || u.Host != "" {
+		if c.forceHTTPS && r.asset && strings.EqualFold(u.Scheme, "http") {
+			return &Problem{Kind: KindInsecure, File: file, Line: r.line, Ref: v}
+		}
+		return nil
+	}
+	if u.Path == "" {
+		return nil
+	}
+
+	target := u.Path
+	if !strings.HasPrefix(target, "/") {
+		target = path.Join(path.Dir("/"+file), target)
+	}
+	target = strings.TrimPrefix(path.Clean(target), "/")
+
+	// The server serves index.html for directories, with or without a
+	// trailing slash.
+	var candidates []string
+	if target == "" || strings.HasSuffix(u.Path, "/") {
+		candidates = []string{path.Join(target, "index.html")}
+	} else {
+		candidates = []string{target, target + "/index.html"}
+	}
+
+	for _, p := range candidates {
+		if c.files[p] {
+			return nil
+		}
+	}
+
+	problem := &Problem{Kind: KindBroken, File: file, Line: r.line, Ref: v, Target: candidates[0]}
+	for _, p := range candidates {
+		if skipped := c.skippedFile(p); skipped != nil {
+			problem.Kind = KindIgnored
+			problem.Target = p
+			problem.Skipped = skipped
+			return problem
+		}
+	}
+	for _, p := range candidates {
+		if actual, ok := c.lowerFiles[strings.ToLower(p)]; ok {
+			problem.Kind = KindCaseMismatch
+			problem.Target = actual
+			return problem
+		}
+	}
+
+	return problem
+}
+
+// Returns the skipped file that is p, or the skipped directory p is in
+func (c *checker) skippedFile(p string) *bundle.SkippedFile {
+	for _, s := range c.skipped {
+		if s.Path == p || (s.IsDir && strings.HasPrefix(p, s.Path+"/")) {
+			return s
+		}
+	}
+	return nil
+}
+
+func htmlRefs(b []byte) []*ref {
+	lines := newLineIndex(b)
+
+	// Blank out what can't contain references, keeping offsets intact so that
+	// line numbers stay right.
+	b = append([]byte{}, b...)
+	blank(b, htmlCommentRe, 0)
+	blank(b, scriptRe, 1)
+
+	var refs []*ref
+	for _, m := range styleRe.FindAllSubmatchIndex(b, -1) {
+		refs = append(refs, cssRefsAt(b[m[2]:m[3]], m[2], lines)...)
+	}
+
+	for _, m := range tagRe.FindAllSubmatchIndex(b, -1) {
+		tag := strings.ToLower(string(b[m[2]:m[3]]))
+		if tag == "base" {
+			continue
+		}
+
+		for _, am := range attrRe.FindAllSubmatchIndex(b[m[4]:m[5]], -1) {
+			attr := strings.ToLower(string(b[m[4]+am[2] : m[4]+am[3]]))
+
+			var value string
+			var offset int
+			for i := 4; i <= 8; i += 2 {
+				if am[i] >= 0 {
+					value = html.UnescapeString(string(b[m[4]+am[i] : m[4]+am[i+1]]))
+					offset = m[4] + am[i]
+					break
+				}
+			}
+			line := lines.line(offset)
+
+			switch attr {
+			case "style":
+				for _, v := range matchValues(cssURLRe, []byte(value)) {
+					refs = append(refs, &ref{value: v, line: line, asset: true})
+				}
+			case "srcset":
+				for _, candidate := range strings.Split(value, ",") {
+					if fields := strings.Fields(candidate); len(fields) > 0 {
+						refs = append(refs, &ref{value: fields[0], line: line, asset: true})
+					}
+				}
+			case "href":
+				refs = append(refs, &ref{value: value, line: line, asset: tag == "link"})
+			default:
+				refs = append(refs, &ref{value: value, line: line, asset: true})
+			}
+		}
+	}
+
+	return refs
+}
+
+func cssRefs(b []byte) []*ref {
+	b = append([]byte{}, b...)
+	return cssRefsAt(b, 0, newLineIndex(b))
+}
+
+// Finds url() and @import references in b, which starts at offset in the file
+// lines indexes
+func cssRefsAt(b []byte, offset int, lines lineIndex) []*ref {
+	blank(b, cssCommentRe, 0)
+
+	var refs []*ref
+	for _, re := range []*regexp.Regexp{cssURLRe, cssImportRe} {
+		for _, m := range re.FindAllSubmatchIndex(b, -1) {
+			for i := 2; i < len(m); i += 2 {
+				if m[i] >= 0 {
+					refs = append(refs, &ref{value: string(b[m[i]:m[i+1]]), line: lines.line(offset + m[i]), asset: true})
+					break
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// Returns the first group that matched in every match of re
+func matchValues(re *regexp.Regexp, b []byte) []string {
+	var values []string
+	for _, m := range re.FindAllSubmatch(b, -1) {
+		for _, g := range m[1:] {
+			if g != nil {
+				values = append(values, string(g))
+				break
+			}
+		}
+	}
+	return values
+}
+
+// Replaces what group of every match of re captured with spaces, except for
+// newlines
+func blank(b []byte, re *regexp.Regexp, group int) {
+	for _, m := range re.FindAllSubmatchIndex(b, -1) {
+		for i := m[2*group]; i < m[2*group+1]; i++ {
+			if b[i] != '\n' {
+				b[i] = ' '
+			}
+		}
+	}
+}
+
+// Offsets of the newlines in a file
+type lineIndex []int
+
+func newLineIndex(b []byte) lineIndex {
+	var idx lineIndex
+	for i, c := range b {
+		if c == '\n' {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// Returns the 1-based line number of offset
+func (idx lineIndex) line(offset int) int {
+	return sort.SearchInts(idx, offset) + 1
+}
+
+type byLocation []*Problem
+
+func (a byLocation) Len() int      { return len(a) }
+func (a byLocation) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byLocation) Less(i, j int) bool {
+	if a[i].File != a[j].File {
+		return a[i].File < a[j].File
+	}
+	return a[i].Line < a[j].Line
+}
@@ -0,0 +1,129 @@
+package sitecheck_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nitrous-io/rise-cli-go/bundle"
+	"github.com/nitrous-io/rise-cli-go/pkg/pathmatch"
+	"github.com/nitrous-io/rise-cli-go/sitecheck"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "sitecheck")
+}
+
+var _ = Describe("Check()", func() {
+	var (
+		tempDir string
+		err     error
+	)
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "rise-test")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	writeFiles := func(files map[string]string) {
+		for name, content := range files {
+			p := filepath.Join(tempDir, filepath.FromSlash(name))
+			Expect(os.MkdirAll(filepath.Dir(p), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(p, []byte(content), 0600)).To(Succeed())
+		}
+	}
+
+	check := func(ignoreList []string, forceHTTPS bool) []*sitecheck.Problem {
+		bun := bundle.New(tempDir)
+		_, _, err := bun.Assemble(pathmatch.CompileAll("", ignoreList...), false)
+		Expect(err).To(BeNil())
+
+		problems, err := sitecheck.Check(tempDir, bun, forceHTTPS)
+		Expect(err).To(BeNil())
+		return problems
+	}
+
+	It("returns nothing if every reference resolves", func() {
+		writeFiles(map[string]string{
+			"index.html": `<!DOCTYPE html>
+<html>
+<head>
+  <link rel="stylesheet" href="/css/app.css">
+  <style>body { background: url('img/bg.png'); }</style>
+  <script src="js/app.js"></script>
+  <script>var s = "<a href='missing.html'>";</script>
+</head>
+<body>
+  <!-- <a href="gone.html">gone</a> -->
+  <a href="docs/">Docs</a>
+  <a href="docs">Docs</a>
+  <a href="#top">Top</a>
+  <a href="mailto:hi@example.com">Mail</a>
+  <a href="https://example.com/nope.html">Elsewhere</a>
+  <a href="docs/intro.html?page=2#install">Intro</a>
+  <img srcset="img/bg.png 1x, img/bg.png 2x" src="img/bg.png" alt="">
+  <a href="{{ url }}">Template</a>
+</body>
+</html>`,
+			"css/app.css":     `@import "base.css"; /* url(missing.png) */ h1 { background: url(../img/bg.png) }`,
+			"css/base.css":    `body { margin: 0 }`,
+			"js/app.js":       `console.log("hi")`,
+			"img/bg.png":      "png",
+			"docs/index.html": `<a href="../index.html">Home</a>`,
+			"docs/intro.html": `<a href=intro.html>Intro</a>`,
+		})
+
+		Expect(check(nil, true)).To(BeEmpty())
+	})
+
+	It("reports broken, ignored, case-mismatched and insecure references", func() {
+		writeFiles(map[string]string{
+			"index.html": `<html>
+<head>
+  <link rel="stylesheet" href="css/App.css">
+  <script src="http://cdn.example.com/lib.js"></script>
+</head>
+<body>
+  <a href="about.html">About</a>
+  <a href="http://example.com/">Example</a>
+  <img src="drafts/draft.png" style="background: url(&quot;img/missing.png&quot;)">
+</body>
+</html>`,
+			"css/app.css":      `h1 { background: url("../img/logo.PNG") }`,
+			"img/logo.png":     "png",
+			"drafts/draft.png": "png",
+		})
+
+		problems := check([]string{"drafts/"}, true)
+		Expect(problems).To(HaveLen(6))
+
+		Expect(*problems[0]).To(Equal(sitecheck.Problem{Kind: sitecheck.KindCaseMismatch, File: "css/app.css", Line: 1, Ref: "../img/logo.PNG", Target: "img/logo.png"}))
+		Expect(*problems[1]).To(Equal(sitecheck.Problem{Kind: sitecheck.KindCaseMismatch, File: "index.html", Line: 3, Ref: "css/App.css", Target: "css/app.css"}))
+		Expect(*problems[2]).To(Equal(sitecheck.Problem{Kind: sitecheck.KindInsecure, File: "index.html", Line: 4, Ref: "http://cdn.example.com/lib.js"}))
+		Expect(*problems[3]).To(Equal(sitecheck.Problem{Kind: sitecheck.KindBroken, File: "index.html", Line: 7, Ref: "about.html", Target: "about.html"}))
+
+		Expect(problems[4].Kind).To(Equal(sitecheck.KindIgnored))
+		Expect(problems[4].Line).To(Equal(9))
+		Expect(problems[4].Target).To(Equal("drafts/draft.png"))
+		Expect(problems[4].Skipped.Path).To(Equal("drafts"))
+		Expect(problems[4].Skipped.Pattern).To(Equal("drafts/"))
+
+		Expect(*problems[5]).To(Equal(sitecheck.Problem{Kind: sitecheck.KindBroken, File: "index.html", Line: 9, Ref: "img/missing.png", Target: "img/missing.png"}))
+	})
+
+	It("does not report insecure assets if the project is not forced to HTTPS", func() {
+		writeFiles(map[string]string{
+			"index.html": `<img src="http://example.com/a.png">`,
+		})
+
+		Expect(check(nil, false)).To(BeEmpty())
+	})
+})
@@ -9,10 +9,22 @@ var strs = map[string]map[string]string{
 		"password_change_desc":    "Change your PubStorm password",
 		"password_reset_desc":     "Reset your PubStorm password",
 		"password_reset_continue": "Specify this flag if you already have a password reset token",
+		"account_desc":            "Show your PubStorm account",
+		"account_update_desc":     "Change the name and organization of your PubStorm account",
+		"account_update_name":     "Your name",
+		"account_update_org":      "Your organization",
+		"account_email_desc":      "Change the email address of your PubStorm account",
+		"account_email_args":      "[NEW EMAIL]",
+		"account_delete_desc":     "Permanently delete your PubStorm account and all of its projects",
+		"2fa_desc":                "Manage two-factor authentication for your PubStorm account",
+		"2fa_enable_desc":         "Turn on two-factor authentication with an authenticator app",
+		"2fa_disable_desc":        "Turn off two-factor authentication",
 		"init_desc":               "Initialize a PubStorm project",
 		"config_desc":             "Configure a PubStorm project",
 		"publish_desc":            "Publish a PubStorm project",
 		"publish_verbose":         "Show additional information",
+		"publish_dry_run":         "List the files that would be published without packing or uploading them",
+		"publish_json":            "Print the --dry-run report as JSON (same as --output json)",
 		"domains_desc":            "List all domains for a PubStorm project",
 		"domains_add_desc":        "Add a new domain to a PubStorm project",
 		"domains_add_args":        "[DOMAIN]\n\nDOMAIN: Domain to add. Specify \"default\" to enable the default .%s domain.",
@@ -22,7 +34,9 @@ var strs = map[string]map[string]string{
 		"projects_rm_desc":        "Delete a PubStorm project",
 		"projects_rm_force":       "Delete project without confirmation",
 		"rollback_desc":           "Rollback to a previous or a specified version",
-		"rollback_args":           "[VERSION]\n\nVERSION: Version to rollback to. Example: v1",
+		"rollback_args":           "[VERSION]\n\nVERSION: Version to rollback to, either a version such as v1, \"previous\" (the default) or a version relative to the live one such as HEAD~2",
+		"rollback_yes":            "Rollback without asking for confirmation",
+		"rollback_dry_run_desc":   "Show what would change without rolling back",
 		"versions_desc":           "List versions of all completed deployments for a PubStorm project",
 		"collab_desc":             "Lists collaborators for the current project",
 		"collab_add_desc":         "Add a collaborator to the current project",
@@ -56,6 +70,70 @@ var strs = map[string]map[string]string{
 		"repo_link_desc":          "Link the current project to a GitHub repository so that pushes to the repository will publish your project",
 		"repo_unlink_desc":        "Unlink the current project from its GitHub repository",
 		"repo_info_desc":          "Displays the linked GitHub repository information and setup instructions",
+		"token_desc":              "List your deploy tokens",
+		"token_create_desc":       "Create a named, long-lived deploy token for use in CI",
+		"token_create_args":       "[NAME]",
+		"token_revoke_desc":       "Revoke a deploy token",
+		"token_revoke_args":       "[ID or NAME]",
+		"profile_desc":            "List config profiles",
+		"profile_add_desc":        "Add a config profile for another account or API host",
+		"profile_use_desc":        "Select the config profile to use by default",
+		"profile_rm_desc":         "Remove a config profile",
+		"profile_args":            "[NAME]",
+		"profile_host_desc":       "API host of the profile",
+		"profile_domain_desc":     "Default domain of projects on the API host",
+		"serve_desc":              "Preview a PubStorm project locally, reloading the browser when files change",
+		"serve_port_desc":         "Port to serve the project on",
+		"serve_https_port_desc":   "Port to serve the project on over HTTPS, if the project forces HTTPS",
+		"serve_host_desc":         "Address to listen on",
+		"serve_no_reload_desc":    "Do not reload the browser when files change",
+		"serve_offline_desc":      "Serve without fetching the project's settings from PubStorm",
+		"serve_password_desc":     "Basic authentication password to require, if the project is protected",
+		"diff_desc":               "Show which files have changed compared to the live version, or to VERSION",
+		"diff_args":               "[VERSION]",
+		"diff_stat_desc":          "Only show the number of added, removed and modified files",
+		"diff_json_desc":          "Print the changes as JSON (same as --output json)",
+		"pull_desc":               "Download the files of the live version, or of VERSION, into a directory",
+		"pull_args":               "[VERSION] [DIR]\n\nDIR defaults to PROJECT-vVERSION in the current directory.",
+		"pull_optimized_desc":     "Download the files as they are served after optimization, instead of as they were published",
+		"pull_force_desc":         "Extract into DIR even if it is not empty, overwriting files",
+		"check_desc":              "Check the HTML and CSS files that would be published for broken links and missing assets",
+		"check_offline_desc":      "Check without fetching the project's settings from PubStorm, skipping the HTTPS checks",
+		"check_json_desc":         "Print the problems as JSON (same as --output json)",
+		"publish_check":           "Check for broken links and missing assets first, and do not publish if there are any (see `storm check`)",
+		"publish_allow_dropped":   "Publish even if pages refer to files that are left out of the bundle",
+		"fix_filenames_desc":      "Rename files that are left out of bundles because of unsafe characters, and update references to them",
+		"fix_filenames_dry_desc":  "List what would be renamed without renaming anything",
+		"fix_filenames_yes_desc":  "Do not ask for confirmation",
+		"deployments_desc":        "List the deployments of a PubStorm project in all states, including failed ones",
+		"deployments_state":       "Only list deployments in these comma separated states: live, deployed, pending, optimizing, deploying or failed",
+		"deployments_show_desc":   "Show the details of a deployment, including why it failed",
+		"deployments_show_args":   "<ID|vVERSION>",
+		"deployments_watch_desc":  "Stream the output of a deployment until it finishes (defaults to the most recent one)",
+		"deployments_watch_args":  "[ID]",
+		"deployments_logs_desc":   "Show the output of a version, following it if it is in progress (defaults to the live version)",
+		"deployments_logs_args":   "[VERSION]",
+		"publish_logs":            "Stream optimizer and deployment output instead of showing a spinner",
+		"publish_no_wait":         "Exit as soon as the deployment has been created instead of waiting for it to go live",
+		"publish_timeout":         "Exit if the deployment has not gone live after this long, e.g. 10m (waits indefinitely by default)",
+		"publish_no_reproducible": "Keep modification times, owners and permissions of files in the bundle. This changes its checksum whenever files are touched, so identical bundles are uploaded again",
+		"deployments_timeout":     "Exit if the deployment has not finished after this long, e.g. 10m (waits indefinitely by default)",
+		"publish_exit_codes": `Exit codes:
+     0  published (or deployment created, with --no-wait)
+     1  any other error
+     2  not logged in, or login expired
+     3  invalid project, e.g. too large
+     4  project is locked
+     5  upload failed
+     6  optimizing or deploying failed
+     7  timed out waiting for the deployment (see --timeout)`,
+
+		"profile_flag_desc":     "Config profile to use, overriding the selected profile and the one the project is pinned to",
+		"non_interactive_desc":  "Fail instead of prompting for input. Enabled automatically when CI=true",
+		"output_desc":           "Output format, \"text\" or \"json\". JSON is written to stdout and everything else to stderr",
+		"invalid_output_format": "Invalid output format %q. It must be either \"text\" or \"json\".",
+		"debug_http_desc":       "Log HTTP requests and responses, with credentials redacted, to ~/.pubstorm/debug.log",
+		"http_timeout_desc":     "Timeout of each request to the PubStorm API",
 
 		"update_available":       "A PubStorm update is available.",
 		"update_current_version": "Your version: %s",
@@ -84,6 +162,20 @@ var strs = map[string]map[string]string{
 		"enter_confirmation_resend": "Enter Confirmation Code (Or enter \"resend\" if you need it sent again)",
 		"confirmation_resent":       "Confirmation code has been resent. You will receive your confirmation code shortly via email.",
 
+		"login_web_desc":              "Log in with your browser instead of typing your password",
+		"login_device_desc":           "Log in by entering a code on another device, e.g. on a machine without a browser",
+		"login_web_device_conflict":   "--web and --device cannot be used together.",
+		"login_web_open":              "Opening %s in your browser. If it doesn't open, visit it to log in.",
+		"login_web_waiting":           "Waiting for you to log in in your browser...",
+		"login_web_done_page":         "You are logged in to the PubStorm CLI. You can close this window.",
+		"login_web_failed_page":       "Logging in to the PubStorm CLI failed. Please return to your terminal.",
+		"login_web_invalid_state":     "This login link is invalid or has already been used.",
+		"login_device_visit":          "Visit %s on any device and enter this code:",
+		"login_device_visit_complete": "Or visit %s to skip entering the code.",
+		"login_device_waiting":        "Waiting for the code to be entered...",
+		"login_access_denied":         "Login was denied.",
+		"login_code_expired":          "Login timed out. Please try again by running `storm login`.",
+
 		"reset_password": "Reset your PubStorm password",
 		"reset_password_quote": `"I forgot the password for the file where I keep all my passwords"
                                               - Not you, hopefully`,
@@ -96,6 +188,52 @@ var strs = map[string]map[string]string{
 		"logout_success":       "You are now logged out.",
 		"access_token_cleared": "Access token cleared.",
 
+		"access_token_env_set":     "The access token is being read from %s. Unset it to log in or out with a stored access token.",
+		"access_token_env_invalid": "The access token in %s is invalid or has been revoked.",
+
+		"credentials_read_failed":      "Could not read the access token from the %s: %v",
+		"credentials_migration_failed": "Could not move access tokens out of config.json into the %s, they stay there for now: %v",
+		"new_passphrase_info":          "No keyring is available, so access tokens will be stored in a file encrypted with a passphrase. Set PUBSTORM_PASSPHRASE to avoid entering it every time.",
+		"enter_passphrase":             "Enter Passphrase",
+		"enter_new_passphrase":         "Enter New Passphrase",
+		"confirm_passphrase":           "Confirm Passphrase",
+		"passphrase_mismatch":          "Passphrases do not match. Please re-enter passphrase.",
+
+		"enter_otp":                  "Enter Two-Factor Code (or a recovery code)",
+		"otp_required":               "A two-factor authentication code is required.",
+		"otp_invalid":                "The two-factor authentication code is invalid. Please try again.",
+		"2fa_already_enabled":        "Two-factor authentication is already turned on for your account. Run `storm 2fa disable` to turn it off.",
+		"2fa_enable_title":           "Two-Factor Authentication",
+		"2fa_scan_qr":                "Scan this QR code with an authenticator app, such as Google Authenticator or 1Password:",
+		"2fa_enter_secret":           "If you cannot scan it, enter this key in the app instead:",
+		"2fa_enabled":                "Two-factor authentication is now turned on. You will be asked for a code from your authenticator app when you log in.",
+		"2fa_recovery_codes":         "Recovery codes",
+		"2fa_recovery_codes_warning": "Keep these recovery codes somewhere safe. Each of them can be used once in place of a code if you lose access to your authenticator app. They will not be shown again.",
+		"2fa_disable_info":           "Enter a code from your authenticator app, or a recovery code, to turn off two-factor authentication.",
+		"2fa_not_enabled":            "Two-factor authentication is not turned on for your account.",
+		"2fa_disabled":               "Two-factor authentication is now turned off.",
+
+		"token_enter_name":      "Enter Token Name (e.g. \"travis-ci\")",
+		"token_enter_id":        "Enter ID or Name of Token to Revoke",
+		"token_created":         "Created deploy token \"%s\":",
+		"token_shown_once":      "Copy this token now, it will not be shown again. To use it, set the PUBSTORM_TOKEN environment variable.",
+		"token_list_header":     "Deploy tokens",
+		"token_never_used":      "never",
+		"no_tokens":             "You do not have any deploy tokens. Create one by running `storm token create`.",
+		"token_not_found":       "Could not find a deploy token with the ID or name \"%s\".",
+		"token_revoked_success": "Revoked deploy token \"%s\".",
+
+		"profile_list_header":    "Profiles",
+		"profile_not_logged_in":  "not logged in",
+		"profile_enter_name":     "Enter Profile Name",
+		"profile_enter_host":     "Enter API Host",
+		"profile_already_exists": "A profile named \"%s\" already exists.",
+		"profile_not_found":      "There is no profile named \"%s\". Run `storm profile list` to see your profiles.",
+		"profile_added":          "Added profile \"%s\". Log in to it by running `storm --profile %s login`.",
+		"profile_selected":       "Now using profile \"%s\".",
+		"profile_rm_default":     "The default profile cannot be removed.",
+		"profile_removed":        "Removed profile \"%s\".",
+
 		"not_logged_in":   "You are not logged in. Please login by running `storm login` or create a new account by running `storm signup`.",
 		"login_expired":   "Your previous session has expired. Please login again by running `storm login`.",
 		"no_rise_project": "Could not find a PubStorm project in current working directory. To initialize a new PubStorm project here, run `storm init`.",
@@ -119,6 +257,10 @@ var strs = map[string]map[string]string{
 		"packing_bundle":            "Packing bundle \"%s\"...",
 		"bundle_size_exceeded":      "Your bundle size cannot exceed %s!",
 		"uploading_bundle":          "Uploading bundle \"%s\" to PubStorm Cloud...",
+		"computing_manifest":        "Computing checksums of %s files...",
+		"uploading_changed_files":   "Uploading %s changed files (%s) to PubStorm Cloud...",
+		"no_changed_files":          "No files have changed since they were last uploaded.",
+		"incremental_unsupported":   "Incremental publishing is not supported by the server, uploading the whole bundle instead.",
 		"optimizing":                "Optimizing...",
 		"launching":                 "Launching v%d...",
 		"published":                 "Successfully published \"%s\" on PubStorm Cloud.",
@@ -126,6 +268,13 @@ var strs = map[string]map[string]string{
 		"deployment_failure":        "Could not publish \"%s\" due to \"%s\".",
 		"bundle_has_many_files":     "You can create a .stormignore file to specify which files to ignore when publishing your project",
 
+		"dry_run_included":        "Files that would be published for \"%s\"",
+		"dry_run_skipped":         "Files that would be skipped for \"%s\"",
+		"dry_run_largest":         "Largest Files",
+		"dry_run_matched_pattern": "matches \"%s\" in %s",
+		"dry_run_matched_builtin": "matches built-in pattern \"%s\"",
+		"dry_run_summary":         "%s files (%s) would be published, %s files or directories would be skipped.",
+
 		"ignore_file_reason":          "Ignoring \"%s\", %s...",
 		"symlink_error":               "could not follow symlink",
 		"symlink_to_dir":              "symlink points to a directory",
@@ -140,6 +289,7 @@ var strs = map[string]map[string]string{
 		"stat_failed":       "Could not get file info for \"%s\"; aborting.",
 		"write_failed":      "Failed to write to \"%s\"; aborting.",
 		"file_size_changed": "File size of \"%s\" changed while packing; aborting.",
+		"file_blob_changed": "\"%s\" changed while it was being uploaded; aborting.",
 
 		"domain_list":                    "List of Domains for \"%s\"",
 		"enter_domain_name_to_add":       "Enter Domain Name to Add",
@@ -169,8 +319,29 @@ var strs = map[string]map[string]string{
 		"password_changed":            "Your password has been changed.",
 		"reenter_email":               "Please re-enter your email address to login with your new password.",
 		"existing_password_incorrect": "The existing password you've entered is incorrect.",
+		"password_incorrect":          "The password you've entered is incorrect.",
 		"new_password_same":           "You cannot reuse your previous password.",
 
+		"account_header":                 "Account",
+		"account_email":                  "Email",
+		"account_name":                   "Name",
+		"account_organization":           "Organization",
+		"account_2fa":                    "Two-factor auth",
+		"account_2fa_on":                 "on",
+		"account_2fa_off":                "off",
+		"account_not_set":                "not set",
+		"account_enter_name":             "Enter Name",
+		"account_enter_organization":     "Enter Organization",
+		"account_updated":                "Your account has been updated.",
+		"account_enter_new_email":        "Enter New Email",
+		"account_email_changed":          "Your email address has been changed to %s. You will receive a confirmation code shortly via email.",
+		"account_email_confirmed":        "Thanks for confirming your new email address!",
+		"account_delete_cannot_undo":     "This action cannot be undone!",
+		"account_delete_permanent":       "This will permanently delete the account %s, along with all of its projects and their domains. To abort, press Ctrl-C.",
+		"account_enter_email_to_confirm": "Enter \"%s\" (without quotes) to confirm",
+		"account_email_does_not_match":   "The email address you've entered does not match your account, please try again.",
+		"account_deleted":                "The account %s has been deleted.",
+
 		"project_rm_cannot_undo":        "This action cannot be undone!",
 		"project_rm_permanent_delete":   "This will permanently delete \"%s\" project. To abort, press Ctrl-C.",
 		"enter_project_name_to_confirm": "Enter \"%s\" (without quotes) to confirm",
@@ -193,6 +364,16 @@ var strs = map[string]map[string]string{
 		"rollback_invalid_version":        "The specified version is not valid",
 		"rollback_version_not_found":      "Version v%d could not be found",
 		"rollback_version_already_active": "This PubStorm project is already on v%d",
+		"rollback_not_enough_versions":    "Cannot rollback to %s, there are only %d completed versions older than v%d.",
+		"rollback_summary":                "Rolling back \"%s\" from v%d to v%d",
+		"rollback_manifests_unavailable":  "The server does not have the list of files in both versions, so the changed files cannot be shown.",
+		"rollback_no_file_changes":        "Both versions contain identical files.",
+		"rollback_file_changes":           "%d files will be added, %d removed and %d changed:",
+		"rollback_more_changes":           "... and %d more",
+		"rollback_confirm":                "Rollback \"%s\" to v%d",
+		"rollback_needs_yes":              "Cannot ask for confirmation in non-interactive mode. Use --yes to rollback anyway.",
+		"rollback_aborted":                "Rollback aborted.",
+		"rollback_dry_run":                "Dry run, \"%s\" has not been rolled back to v%d.",
 
 		"project_locked": "This PubStorm project is locked",
 
@@ -283,6 +464,70 @@ var strs = map[string]map[string]string{
 		"project_not_linked":            "Project \"%s\" is not linked to any GitHub repository.",
 		"unlink_repo_success":           "Unlinked project \"%s\" from its GitHub repository.",
 		"linked_repo_info_repo":         "Project \"%s\" is linked to \"%s@%s\"",
+
+		"serve_listening":     "Serving project \"%s\" at %s",
+		"serve_env_vars":      "%d JS environment variable(s) are available at %s.",
+		"serve_force_https":   "HTTP requests are redirected to HTTPS, which uses a self-signed certificate that your browser will warn about.",
+		"serve_offline":       "Serving offline: JS environment variables, basic authentication and forced HTTPS are not applied.",
+		"serve_protected":     "Project is protected. Enter the password to require for user \"%s\" when previewing it.",
+		"serve_stop":          "Press Ctrl-C to stop.",
+		"serve_reloaded":      "Files changed, reloading.",
+		"serve_scan_failed":   "Failed to scan project path: %v",
+		"serve_listen_failed": "Could not listen on port %d: %v",
+
+		"deployment_state_deployed":   "Deployed v%d.",
+		"deployment_state_failed":     "Deploying v%d failed: %s",
+		"deployment_state_other":      "Deployment is %s.",
+		"deployment_logs_unsupported": "Deployment logs are not available from the server. Only state changes will be shown.",
+		"deployment_id_invalid":       "%q is not a valid deployment ID.",
+		"deployment_not_found":        "Deployment %d could not be found.",
+		"version_invalid":             "%q is not a valid version. Versions look like v3.",
+		"version_not_found":           "Version v%d of project \"%s\" could not be found.",
+		"no_deployments":              "Project \"%s\" has not been published yet.",
+		"no_live_version":             "Project \"%s\" does not have a live version.",
+		"deployments_list":            "Deployments of \"%s\"",
+		"deployments_none_found":      "No deployments of \"%s\" were found.",
+		"deployments_state_invalid":   "Unknown deployment state %q. It must be one of live, deployed, pending, optimizing, deploying or failed.",
+		"deployment_show":             "Deployment %d (v%d) of \"%s\"",
+		"diff_title":                  "Changes to \"%s\" since v%d",
+		"diff_stat":                   "%s, %s, %s",
+		"diff_none":                   "No files have changed since v%d.",
+		"diff_manifest_unavailable":   "The server does not have the list of files in v%d, so it cannot be compared.",
+		"pull_dir_not_empty":          "Directory \"%s\" is not empty. Use --force to extract into it anyway.",
+		"pull_bundle_not_found":       "The %s bundle of v%d is not available.",
+		"pull_not_supported":          "The server does not allow published files to be downloaded.",
+		"pull_downloading":            "Downloading %s bundle of v%d of \"%s\" (%s)...",
+		"pull_checksum_mismatch":      "The downloaded bundle is corrupt, its checksum does not match. Please try again.",
+		"pull_checksum_missing":       "The bundle cannot be verified because the server did not send its checksum.",
+		"pull_extract_failed":         "Could not extract the bundle into \"%s\": %v",
+		"pull_success":                "Pulled v%d of \"%s\", %s files were extracted into \"%s\".",
+		"publish_started":             "Publishing v%d of \"%s\". Run `storm deployments watch %d` to follow its progress.",
+		"deployment_timed_out":        "Timed out after %s waiting for v%d of \"%s\" to go live. It is still in progress, run `storm deployments watch %d` to follow it.",
+		"checking_site":               "Checking links and assets of \"%s\"...",
+		"check_broken":                "\"%s\" is not in the bundle",
+		"check_ignored":               "\"%s\" refers to %s, which is excluded from the bundle (%s)",
+		"check_case_mismatch":         "\"%s\" only matches %s, which differs in case and will not be found once published",
+		"check_insecure":              "\"%s\" is loaded over HTTP, which browsers block on pages served over HTTPS",
+		"check_ok":                    "No broken links or missing assets were found.",
+		"check_problems":              "Problems found: %d.",
+		"check_failed":                "Some links or assets will not work once published.",
+		"check_failed_publish":        "Some links or assets will not work once published. Fix them, or publish without --check.",
+		"dropped_unsafe_names":        "%d files or directories will not be published because their names contain spaces, accents or other unsafe characters:",
+		"dropped_more":                "...and %d more",
+		"dropped_fix_hint":            "Run `storm fix-filenames` to rename them and update the references to them.",
+		"dropped_others":              "%d other files or directories were skipped. Run `storm publish --dry-run` to list them.",
+		"dropped_referenced":          "Pages refer to files that will not be published. Run `storm fix-filenames` to rename files with unsafe names, or publish with --allow-dropped.",
+		"fix_filenames_none":          "All file names are safe, there is nothing to rename.",
+		"fix_filenames_title":         "Files and directories to rename",
+		"fix_filenames_dry_run":       "%d files or directories would be renamed. Nothing has been changed.",
+		"fix_filenames_needs_yes":     "Not renaming files without confirmation. Use --yes to rename them.",
+		"fix_filenames_confirm":       "Rename %d files or directories",
+		"fix_filenames_aborted":       "Nothing has been renamed.",
+		"fix_filenames_rename_failed": "Could not rename \"%s\": %v",
+		"fix_filenames_renamed":       "Renamed %d files or directories.",
+		"fix_filenames_ambiguous":     "More than one \"%s\" was renamed to different names, so references to it have to be updated by hand.",
+		"fix_filenames_write_failed":  "Could not update \"%s\": %v",
+		"fix_filenames_rewritten":     "Updated %d references in %d files.",
 	},
 }
 
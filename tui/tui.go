@@ -17,6 +17,15 @@ func init() {
 	}
 }
 
+// Sends all output to stderr instead, so that stdout can be reserved for
+// machine readable output.
+func UseStderr() {
+	Out = os.Stderr
+	if isatty.IsTerminal(os.Stderr.Fd()) {
+		Out = colorable.NewColorableStderr()
+	}
+}
+
 func Print(a ...interface{}) (n int, err error) {
 	return fmt.Fprint(Out, a...)
 }